@@ -1,4 +1,5 @@
 // @SubApi Order management API [/orders]
+// @SubApi Test API [/testapi]
 package example
 
 import (
@@ -133,6 +134,17 @@ func (c *Context) GetStruct3(rw web.ResponseWriter, req *web.Request) {
 	c.WriteResponse(StructureWithSlice{})
 }
 
+// @Title AddPet
+// @Description add a cat or a dog
+// @Accept  json
+// @Param   pet     body    {oneOf} Cat,Dog     true        "The cat or dog to add"
+// @Success 200 {oneOf} Cat,Dog
+// @Failure 400 {object} APIError "Pet is not valid"
+// @Router /testapi/pets [post]
+func (c *Context) AddPet(rw web.ResponseWriter, req *web.Request) {
+	c.WriteResponse(Dog{})
+}
+
 func InitRouter() *web.Router {
 	router := web.New(Context{}).
 		Middleware(web.LoggerMiddleware).
@@ -149,7 +161,8 @@ func InitRouter() *web.Router {
 		Get("/testapi/get-simple-aliased", (*Context).GetSimpleAliased).
 		Get("/testapi/get-array-of-interfaces", (*Context).GetArrayOfInterfaces).
 		Get("/testapi/get-struct3", (*Context).GetStruct3).
-		Get("/testapi/get-struct2-by-int/{some_id}", (*Context).GetStruct2ByInt)
+		Get("/testapi/get-struct2-by-int/{some_id}", (*Context).GetStruct2ByInt).
+		Post("/testapi/pets", (*Context).AddPet)
 
 	return router
 }
@@ -1,6 +1,17 @@
 package example
 
-//	"github.com/RobotsAndPencils/go-swaggerLite/example/subpackage"
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	_ "github.com/RobotsAndPencils/go-swaggerLite/example/sideeffect"
+	"github.com/RobotsAndPencils/go-swaggerLite/example/pagination"
+	"github.com/RobotsAndPencils/go-swaggerLite/example/subpackage"
+	"github.com/RobotsAndPencils/go-swaggerLite/example/users"
+	"github.com/RobotsAndPencils/go-swaggerLite/example/uuid"
+)
 
 type InterfaceType interface{}
 
@@ -32,3 +43,313 @@ type APIError struct {
 	ErrorCode    int
 	ErrorMessage string
 }
+
+type Page[T any] struct {
+	Items []T
+	Total int
+}
+
+// The type parameter is named like a type of subpackage, which Label still refers to
+type Labeled[SimpleStructure any] struct {
+	Value SimpleStructure
+	Label subpackage.SimpleStructure
+}
+
+// Written as XML as well, e.g. <order id="1"><customer>...</customer><lines><line>...</line></lines></order>
+type XmlOrder struct {
+	XMLName  xml.Name `xml:"order"`
+	Id       int      `json:"id" xml:"id,attr"`
+	Customer string   `json:"customer" xml:"customer"`
+	Lines    []string `json:"lines" xml:"lines>line"`
+	Note     string   `json:"note" xml:"-"`
+}
+
+// Pointer fields are optional, only the explicitly required ones are required
+type StructureWithPointers struct {
+	Id    int         `json:"id" required:"true"`
+	Owner *users.User `json:"owner"`
+	Count *int        `json:"count"`
+	Names *[]string   `json:"names"`
+	Note  *string     `json:"note,required"`
+}
+
+type StructureWithNullableFields struct {
+	// DeletedAt is only set once the structure is deleted
+	// @Nullable
+	DeletedAt time.Time `json:"deletedAt"`
+	// @Nullable false
+	Owner  *users.User      `json:"owner"`
+	Parent *SimpleStructure `json:"parent"`
+	Name   string           `json:"name"`
+}
+
+type StructureWithOptionalFields struct {
+	Id      int     `json:"id"`
+	Note    string  `json:"note,omitempty"`
+	Owner   *string `json:"owner"`
+	Secret  string  `json:"-"`
+	Version int     `json:"version" required:"false"`
+}
+
+// @Required id,name
+type StructureWithRequiredComment struct {
+	Id    int     `json:"id,omitempty"`
+	Name  *string `json:"name"`
+	Notes string  `json:"notes"`
+}
+
+type StructureWithComposedTypes struct {
+	PointerToSliceOfMaps *[]map[string]*SimpleStructure
+	SliceOfSlices        [][]int
+	SliceOfPointers      []*SimpleStructure
+	PointerToPointer     **int
+	MapOfSlices          map[string][]string
+	MapOfMaps            map[string]map[int]SimpleStructure
+	MapOfInts            map[string]int
+	AnonymousStructure   struct{ Name string }
+}
+
+type StructureWithJsonTags struct {
+	UserName string `json:"user_name"`
+	Password string `json:"-"`
+	Nickname string `json:"nickname,omitempty"`
+	Email    string `json:",omitempty"`
+	Dash     string `json:"-,"`
+	Age      int    `json:"age,string"`
+	Id       int64  `json:"id,string"`
+}
+
+// @ModelTag TaggedStructure identity,account
+// @ModelTag TaggedStructure audit
+type TaggedStructure struct {
+	Id int
+}
+
+type BaseModel struct {
+	Id    int
+	Owner SimpleStructure `json:"owner" required:"true"`
+}
+
+type StructureWithEmbededBaseModel struct {
+	BaseModel
+	Name string
+}
+
+// Embedded structs named by their json tag are nested objects, rather than flattened
+type StructureWithNamedEmbededStructures struct {
+	BaseModel                   `json:"base"`
+	*subpackage.SimpleStructure `json:"simple,omitempty"`
+	TaggedStructure             `json:"-"`
+	Name                        string
+}
+
+type StructureWithEmbededSubpackageStructure struct {
+	subpackage.SimpleStructure
+	Extra string
+}
+
+type StructureWithEmbededSubpackagePointer struct {
+	*subpackage.SimpleStructure
+	Name []string
+}
+
+type Paging struct {
+	Page  int `query:"page" default:"1"`
+	Limit int `query:"limit" validate:"min=1,required"`
+}
+
+// ListOrdersRequest is bound from the request by the handler, see @Params
+type ListOrdersRequest struct {
+	Paging
+	// Id of the customer
+	CustomerId int64         `path:"customerId"`
+	Status     []OrderStatus `query:"status"`
+	RequestId  *string       `header:"X-Request-Id" description:"Id of the request, for tracing"`
+	Internal   string        `json:"internal"`
+}
+
+type OrderStatus string
+
+const (
+	OrderStatusActive   OrderStatus = "active"
+	OrderStatusInactive OrderStatus = "inactive"
+	OrderStatusPending  OrderStatus = "pending"
+)
+
+// OrderId is declared with a type documented as a string, see parser.DefaultTypeMappings
+type OrderId uuid.UUID
+
+type StructureWithEnum struct {
+	Status OrderStatus
+}
+
+type StructureWithComments struct {
+	// Id identifies the structure,
+	// it never changes
+	Id   int    `json:"id"`
+	Name string `json:"name"` // Name of the structure
+	/* Notes about the structure */
+	Notes string
+	// Overridden by the description tag
+	Tagged string `description:"From the tag"`
+	Plain  string
+}
+
+type StructureWithExamples struct {
+	Id      int64             `json:"id" example:"42"`
+	Name    string            `json:"name" example:"Bob"`
+	Price   float64           `json:"price" example:"9.99"`
+	Active  bool              `json:"active" example:"true"`
+	Tags    []string          `json:"tags" example:"[\"new\", \"sale\"]"`
+	Labels  map[string]string `json:"labels" example:"{\"color\": \"red\"}"`
+	Invalid int               `json:"invalid" example:"many"`
+}
+
+type UserID = int64
+
+type SimpleStructureAlias = SimpleStructure
+
+// The aliases are documented as the aliased types, while SimpleAlias is a type of its own
+type StructureWithAliases struct {
+	Id     UserID
+	Ids    []UserID
+	Simple SimpleStructureAlias
+	Owner  subpackage.User
+	Code   subpackage.Code
+	Alias  SimpleAlias
+}
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+type Priority int
+
+const (
+	PriorityLow    Priority = 1
+	PriorityNormal Priority = 5
+	PriorityHigh   Priority = Priority(10)
+)
+
+// The zero value is skipped, the others are multiples of 10
+type Weight int
+
+const (
+	_ Weight = iota * 10
+	WeightLight
+	WeightHeavy
+)
+
+type StructureWithIntEnums struct {
+	Color    Color
+	Priority Priority
+	Weight   Weight
+}
+
+// Money is marshaled as a single string, e.g. "12.50 USD", not as its fields
+type Money struct {
+	Cents    int64
+	Currency string
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%d.%02d %s"`, m.Cents/100, m.Cents%100, m.Currency)), nil
+}
+
+type StructureWithMoney struct {
+	Id    int
+	Price Money
+}
+
+type Animal struct {
+	Name string
+}
+
+type Cat struct {
+	Lives int
+}
+
+// Dog has every property of an Animal, besides its own
+// @AllOf Dog Animal
+type Dog struct {
+	Breed string
+}
+
+// Timestamp is sent as an RFC 3339 string
+// @Schema {"type":"string","format":"date-time"}
+type Timestamp struct {
+	Seconds int64
+	Nanos   int32
+}
+
+type StructureWithTimestamp struct {
+	Id        int
+	CreatedAt Timestamp
+}
+
+type StructureWithStdlibTypes struct {
+	CreatedAt time.Time
+	Timeout   time.Duration
+	Payload   json.RawMessage
+	Checksum  []byte
+	Uid       uuid.UUID
+	History   []time.Time
+}
+
+type StructureWithCrossPackageGeneric struct {
+	Users   pagination.Page[users.User]
+	Structs pagination.Page[SimpleStructure]
+}
+
+type TreeNode struct {
+	Value    string
+	Parent   *TreeNode
+	Children []TreeNode
+}
+
+type Department struct {
+	Name      string
+	Employees []Employee
+}
+
+type Employee struct {
+	Name       string
+	Department *Department
+	Manager    *Employee
+}
+
+// Shape is implemented by the shapes a drawing is made of
+type Shape interface {
+	Area() float64
+}
+
+type Drawing struct {
+	Anything   interface{}
+	Attributes map[string]any
+	Main       Shape
+	Shapes     []Shape
+	Layers     map[string][]Shape
+	Owner      SimpleStructure
+}
+
+// Pet is either a cat or a dog, as told by its petType
+// @Discriminator petType
+// @SubType PetCat
+// @SubType PetDog
+type Pet struct {
+	Name    string `json:"name"`
+	PetType string `json:"petType"`
+}
+
+type PetCat struct {
+	Lives int `json:"lives"`
+}
+
+// @AllOf PetDog Pet
+type PetDog struct {
+	Breed string `json:"breed"`
+}
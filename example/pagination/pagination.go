@@ -0,0 +1,6 @@
+package pagination
+
+type Page[T any] struct {
+	Total int
+	Items []T
+}
@@ -0,0 +1,6 @@
+package sideeffect
+
+type Widget struct {
+	Id   int
+	Name string
+}
@@ -1,6 +1,16 @@
 package subpackage
 
+import "github.com/RobotsAndPencils/go-swaggerLite/example/users"
+
 type SimpleStructure struct {
 	Id   int
 	Name string
 }
+
+// Aliases name the same types as those they alias
+type User = users.User
+
+type Code = string
+
+// OrderNumber is a named primitive, which params are documented as
+type OrderNumber int64
@@ -0,0 +1,6 @@
+package users
+
+type User struct {
+	Id   int
+	Name string
+}
@@ -0,0 +1,4 @@
+package uuid
+
+// UUID stands in for the UUID types of third party packages, e.g. github.com/google/uuid
+type UUID [16]byte
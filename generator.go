@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"go/ast"
+	"go/build"
 	"log"
 	"os"
 	"path"
@@ -17,15 +17,28 @@ import (
 )
 
 const (
-	AVAILABLE_FORMATS = "go|markdown"
+	AVAILABLE_FORMATS = "go|markdown|swagger12|swagger20|openapi3"
+	DEFAULT_OUTPUT    = "generatedSwaggerSpec.go"
 )
 
-var apiPackage = flag.String("apiPackage", "", "The package that implements the API controllers, relative to $GOPATH/src")
-var mainApiFile = flag.String("mainApiFile", "", "The file that contains the general API annotations, relative to $GOPATH/src")
+var apiPackage = flag.String("apiPackage", "", "The package that implements the API controllers, relative to $GOPATH/src, or a local directory like ./internal/api. Comma separated, packages prefixed with - are excluded, e.g. -./internal/api/admin/...")
+var mainApiFile = flag.String("mainApiFile", "", "The file that contains the general API annotations, relative to $GOPATH/src, or a local file like ./main.go")
 var basePath = flag.String("basePath", "", "Web service base path")
 var outputFormat = flag.String("format", "go", "Output format type for the generated files: "+AVAILABLE_FORMATS)
-var output = flag.String("output", "generatedSwaggerSpec.go", "The opitonal name of the output file to be generated")
+var output = flag.String("output", DEFAULT_OUTPUT, "The opitonal name of the output file to be generated")
+var outputType = flag.String("outputType", "json", "Encoding of the swagger20 and openapi3 formats: json|yaml")
 var generatedPackage = flag.String("package", "main", "The opitonal package name of the output file to be generated")
+var maxScanDepth = flag.Int("maxScanDepth", 0, "How deep to look for nested packages below apiPackage, 0 means no limit")
+var buildTags = flag.String("tags", "", "Comma separated build tags the parsed files must match, besides $GOOS and $GOARCH")
+var cacheDir = flag.String("cacheDir", "", "Directory to cache the declarations of the parsed packages in between runs, optional")
+var goSwagger = flag.Bool("goSwagger", false, "Also parse the swagger:route and swagger:operation comments of go-swagger")
+var groupByReceiver = flag.Bool("groupByReceiver", false, "Group the operations of controller methods under a resource named after their receiver type")
+var routePrefix = flag.String("routePrefix", "", "Path prefix the operations are mounted under, prepended to the paths of their @Router annotations, e.g. /api/v2")
+var colonPathParams = flag.Bool("colonPathParams", false, "Read the path params of @Router written :param, e.g. /users/:id, as {param}")
+var includeAllModels = flag.Bool("includeAllModels", false, "Also define the models of the swagger20 and openapi3 formats which no operation references")
+var flatten = flag.Bool("flatten", false, "Write the swagger12 format as a single api declaration file of all the resources, rather than a directory")
+var validate = flag.Bool("validate", false, "Only validate the annotations of apiPackage: report every problem found, without writing any output")
+var verbose = flag.Bool("verbose", false, "Trace the resolution of packages and the parsing of models, e.g. when a model definition can not be found")
 
 var generatedFileTemplate = `package {{generagedPackage}}
 //This file is generated automatically. Do not edit it manually.
@@ -72,12 +85,6 @@ var swaggerResourceListing = {{resourceListing}}
 var swaggerApiDescriptions = {{apiDescriptions}}
 `
 
-// It must return true if funcDeclaration is controller. We will try to parse only comments before controllers
-// Stubbed out for now
-func IsController(funcDeclaration *ast.FuncDecl) bool {
-	return true
-}
-
 func generateSwaggerDocs(parser *parser.Parser) {
 	fd, err := os.Create(path.Join("./", *output))
 	if err != nil {
@@ -105,16 +112,84 @@ func generateSwaggerDocs(parser *parser.Parser) {
 	fd.WriteString(doc)
 }
 
+// generateSpec writes the spec of one of the formats of parser.GenerateSpec
+func generateSpec(format string) {
+	if *output == DEFAULT_OUTPUT {
+		switch format {
+		case parser.FormatSwagger12:
+			*output = "apidocs"
+			if *flatten {
+				*output += ".json"
+			}
+		default:
+			*output = format + "." + strings.ToLower(*outputType)
+		}
+	}
+	p := InitParser()
+	err := p.GenerateSpec(parser.GenerateOptions{
+		Packages:   strings.Split(*apiPackage, ","),
+		MainFile:   *mainApiFile,
+		Format:     format,
+		Output:     *outputType,
+		OutputPath: *output,
+		Flatten:    *flatten,
+	})
+	for _, warning := range p.Warnings {
+		log.Printf("%v\n", warning)
+	}
+	if err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	for _, err := range p.Validate() {
+		log.Printf("%v\n", err)
+	}
+	log.Printf("Spec written to %s\n", *output)
+}
+
+// validateApi reports the problems of the annotations, and exits with status 1 if there are any
+func validateApi() {
+	errs := InitParser().ValidateApi(*apiPackage)
+	for _, err := range errs {
+		log.Printf("%v\n", err)
+	}
+	if len(errs) > 0 {
+		log.Fatalf("%d problems found\n", len(errs))
+	}
+	log.Println("No problems found")
+}
+
 func InitParser() *parser.Parser {
+	var pathNormalizer func(path string) string
+	if *colonPathParams {
+		pathNormalizer = parser.ColonPathParams
+	}
 	parser := parser.NewParser()
 
 	parser.BasePath = *basePath
-	parser.IsController = IsController
+	parser.MaxScanDepth = *maxScanDepth
+	if *buildTags != "" {
+		buildContext := build.Default
+		buildContext.BuildTags = strings.Split(*buildTags, ",")
+		parser.BuildContext = &buildContext
+	}
+	if *cacheDir != "" {
+		if err := parser.EnableDeclarationCache(*cacheDir); err != nil {
+			log.Fatalf("%v\n", err)
+		}
+	}
+	parser.GoSwaggerAnnotations = *goSwagger
+	parser.GroupByReceiver = *groupByReceiver
+	parser.RoutePrefix = *routePrefix
+	parser.PathNormalizer = pathNormalizer
+	parser.IncludeAllModels = *includeAllModels
+	if *verbose {
+		parser.Logger = log.New(os.Stderr, "[debug] ", log.LstdFlags)
+	}
 
-	parser.TypesImplementingMarshalInterface["NullString"] = "string"
-	parser.TypesImplementingMarshalInterface["NullInt64"] = "int"
-	parser.TypesImplementingMarshalInterface["NullFloat64"] = "float"
-	parser.TypesImplementingMarshalInterface["NullBool"] = "bool"
+	parser.TypesImplementingMarshalInterface["database/sql.NullString"] = "string"
+	parser.TypesImplementingMarshalInterface["database/sql.NullInt64"] = "int"
+	parser.TypesImplementingMarshalInterface["database/sql.NullFloat64"] = "float"
+	parser.TypesImplementingMarshalInterface["database/sql.NullBool"] = "bool"
 
 	return parser
 }
@@ -123,25 +198,50 @@ func main() {
 	flag.Parse()
 
 	if *mainApiFile == "" {
-		*mainApiFile = *apiPackage + "/main.go"
+		// in the first package given, rather than one excluded
+		for _, packageName := range strings.Split(*apiPackage, ",") {
+			if !strings.HasPrefix(packageName, "-") {
+				*mainApiFile = strings.TrimSuffix(packageName, "/...") + "/main.go"
+				break
+			}
+		}
 	}
 	if *apiPackage == "" {
 		flag.PrintDefaults()
 		return
 	}
 
-	parser := InitParser()
-	gopath := os.Getenv("GOPATH")
-	if gopath == "" {
-		log.Fatalf("Please, set $GOPATH environment variable\n")
+	if *validate {
+		validateApi()
+		return
 	}
 
+	switch format := strings.ToLower(*outputFormat); format {
+	case parser.FormatSwagger12, parser.FormatSwagger20, parser.FormatOpenAPI3:
+		generateSpec(format)
+		return
+	}
+
+	// a local main file is parsed as it is, rather than looked up in $GOPATH
+	gopaths := []string{""}
+	if !parser.IsLocalPackagePath(*mainApiFile) {
+		gopath := os.Getenv("GOPATH")
+		if gopath == "" {
+			log.Fatalf("Please, set $GOPATH environment variable\n")
+		}
+		gopaths = filepath.SplitList(gopath)
+	}
+
+	parser := InitParser()
 	log.Println("Start parsing")
-	gopaths := filepath.SplitList(gopath)
 	var err error
 	var errs string
 	for _, gop := range gopaths {
-		err = parser.ParseGeneralAPIInfo(path.Join(gop, "src", *mainApiFile))
+		mainFile := *mainApiFile
+		if gop != "" {
+			mainFile = path.Join(gop, "src", *mainApiFile)
+		}
+		err = parser.ParseGeneralAPIInfo(mainFile)
 		if err != nil {
 			errs += fmt.Sprintf("    %s\n", err)
 		} else {
@@ -152,6 +252,9 @@ func main() {
 		log.Fatalf("Error locating main API File:\n%s", errs)
 	}
 	parser.ParseApi(*apiPackage)
+	for _, err := range parser.Validate() {
+		log.Printf("%v\n", err)
+	}
 	log.Println("Finish parsing")
 
 	format := strings.ToLower(*outputFormat)
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitParserMarshalInterfaceTypes(t *testing.T) {
+	p := InitParser()
+	// ParseModel looks the types up qualified with the real path of their package
+	sqlPackage := p.CheckRealPackagePath("database/sql")
+	assert.NotEmpty(t, sqlPackage, "database/sql should be found in GOROOT")
+	assert.True(t, p.IsImplementMarshalInterface(sqlPackage+".NullString"), "sql.NullString should be documented as a generic model")
+	assert.True(t, p.IsImplementMarshalInterface(sqlPackage+".NullInt64"), "sql.NullInt64 should be documented as a generic model")
+	assert.False(t, p.IsImplementMarshalInterface(sqlPackage+".DB"), "sql.DB has no MarshalJSON method")
+	assert.False(t, p.IsImplementMarshalInterface("/src/example.com/mysql.NullString"), "A type of another package named like sql.NullString should not match")
+}
@@ -51,6 +51,16 @@ func GenerateMarkup(parser *parser.Parser, markup Markup, outputSpec *string, de
 	}
 	defer fd.Close()
 
+	fd.WriteString(RenderMarkup(parser, markup))
+}
+
+// ToMarkdown renders the parsed API as a Markdown reference, grouped by resource
+func ToMarkdown(parser *parser.Parser) string {
+	return RenderMarkup(parser, new(MarkupMarkDown))
+}
+
+// RenderMarkup renders the parsed API using the given markup
+func RenderMarkup(parser *parser.Parser, markup Markup) string {
 	var buf bytes.Buffer
 
 	/***************************************************************
@@ -65,7 +75,12 @@ func GenerateMarkup(parser *parser.Parser, markup Markup, outputSpec *string, de
 	buf.WriteString("Table of Contents\n\n")
 	subApiKeys, subApiKeyIndex := alphabeticalKeysOfSubApis(parser.Listing.Apis)
 	for _, subApiKey := range subApiKeys {
-		buf.WriteString(markup.numberedItem(1, markup.link(subApiKey, parser.Listing.Apis[subApiKeyIndex[subApiKey]].Description)))
+		// resources without a @SubApi or @Resource description are listed by their name
+		title := parser.Listing.Apis[subApiKeyIndex[subApiKey]].Description
+		if title == "" {
+			title = subApiKey
+		}
+		buf.WriteString(markup.numberedItem(1, markup.link(subApiKey, title)))
 	}
 	buf.WriteString("\n")
 
@@ -164,7 +179,7 @@ func GenerateMarkup(parser *parser.Parser, markup Markup, outputSpec *string, de
 
 	}
 
-	fd.WriteString(buf.String())
+	return buf.String()
 }
 
 func shortModelName(longModelName string) string {
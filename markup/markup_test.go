@@ -0,0 +1,63 @@
+package markup_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RobotsAndPencils/go-swaggerLite/markup"
+	"github.com/RobotsAndPencils/go-swaggerLite/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MarkupSuite struct {
+	suite.Suite
+	parser *parser.Parser
+}
+
+func (suite *MarkupSuite) SetupSuite() {
+	suite.parser = parser.NewParser()
+	suite.parser.Listing.Infos.Title = "Order API"
+	suite.parser.Listing.Infos.Description = "Manages orders"
+
+	operationComment := `
+// @Title getOrderByNumber
+// @Resource /order Order management
+// @Description Return order by order number
+// @Accept  json
+// @Param   order_nr     path    string  true	"Order number"
+// @Success 200 {array}  int
+// @Failure 400 {simple} string     "Order ID must be specified"
+// @Router /order/by-number/{order_nr} [get]
+`
+	op := parser.NewOperation(suite.parser, "test")
+	for _, line := range strings.Split(operationComment, "\n") {
+		if err := op.ParseComment(line); err != nil {
+			suite.T().Fatalf("Can not parse operation comment: %v", err)
+		}
+	}
+	suite.parser.AddOperation(op)
+}
+
+func (suite *MarkupSuite) TestToMarkdown() {
+	doc := markup.ToMarkdown(suite.parser)
+
+	assert.Contains(suite.T(), doc, "\n# Order API\n", "Title not rendered")
+	assert.Contains(suite.T(), doc, "Manages orders", "Description not rendered")
+	assert.Contains(suite.T(), doc, "Table of Contents", "Table of contents not rendered")
+	assert.Contains(suite.T(), doc, "1. [Order management](#order)", "Table of contents entry not rendered")
+
+	assert.Contains(suite.T(), doc, "<a name=\"order\"></a>", "Resource anchor not rendered")
+	assert.Contains(suite.T(), doc, "\n## order\n", "Resource section not rendered")
+	assert.Contains(suite.T(), doc, "\n### Operations\n", "Operations section not rendered")
+	assert.Contains(suite.T(), doc, "| /order/by-number/\\{order_nr\\} | [GET](#getOrderByNumber) | Return order by order number |", "Operation summary not rendered")
+
+	assert.Contains(suite.T(), doc, "<a name=\"getOrderByNumber\"></a>", "Operation anchor not rendered")
+	assert.Contains(suite.T(), doc, "| order_nr | path | string | Order number | Yes |", "Parameters not rendered")
+	assert.Contains(suite.T(), doc, "| 400 | Order ID must be specified | string |", "Response messages not rendered")
+	assert.Contains(suite.T(), doc, "\n### Models\n", "Models section not rendered")
+}
+
+func TestMarkupSuite(t *testing.T) {
+	suite.Run(t, &MarkupSuite{})
+}
@@ -5,6 +5,7 @@ type ApiDeclaration struct {
 	ApiVersion     string            `json:"apiVersion"`
 	SwaggerVersion string            `json:"swaggerVersion"`
 	BasePath       string            `json:"basePath"`
+	Schemes        []string          `json:"schemes,omitempty"`
 	ResourcePath   string            `json:"resourcePath"` // must start with /
 	Consumes       []string          `json:"-"`
 	Produces       []string          `json:"produces,omitempty"`
@@ -51,11 +52,21 @@ func (api *ApiDeclaration) AddProducesTypes(op *Operation) {
 	}
 }
 func (api *ApiDeclaration) AddModels(op *Operation) {
+	producesXml := false
+	for _, contentType := range op.Produces {
+		if contentType == ContentTypeXml {
+			producesXml = true
+		}
+	}
 	for _, m := range op.Models {
 		if m != nil {
 			if _, ok := api.Models[m.Id]; !ok {
 				api.Models[m.Id] = m
 			}
+			// the model may be shared with operations, or resources, which do not produce XML
+			if producesXml {
+				api.Models[m.Id] = api.Models[m.Id].WithXML()
+			}
 		}
 	}
 }
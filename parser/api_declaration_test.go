@@ -93,6 +93,37 @@ func (suite *ApiDeclarationSuite) TestAddModel() {
 	assert.Equal(suite.T(), api.Models, expected, "Models not added correctly")
 }
 
+func (suite *ApiDeclarationSuite) TestAddModelProducingXml() {
+	p := parser.NewParser()
+	p.ParseTypeDefinitions(ExamplePackageName)
+	p.CurrentPackage = ExamplePackageName
+
+	api := parser.NewApiDeclaration()
+	jsonOperation := parser.NewOperation(p, ExamplePackageName)
+	assert.Nil(suite.T(), jsonOperation.ParseComment("// @Success 200 {object} XmlOrder"), "Can not parse response comment")
+	api.AddModels(jsonOperation)
+	modelId := jsonOperation.Models[0].Id
+	assert.Nil(suite.T(), api.Models[modelId].XML, "Model of an operation not producing XML should not have XML objects")
+
+	xmlOperation := parser.NewOperation(p, ExamplePackageName)
+	assert.Nil(suite.T(), xmlOperation.ParseComment("// @Produce xml"), "Can not parse produce comment")
+	assert.Nil(suite.T(), xmlOperation.ParseComment("// @Success 200 {object} XmlOrder"), "Can not parse response comment")
+	api.AddModels(xmlOperation)
+	assert.Equal(suite.T(), &parser.XMLObject{Name: "order"}, api.Models[modelId].XML, "Model of an operation producing XML should have XML objects")
+	assert.Nil(suite.T(), jsonOperation.Models[0].XML, "Model of the operation not producing XML should be left without XML objects")
+
+	// the model is shared with the resources of operations which do not produce XML
+	jsonApi := parser.NewApiDeclaration()
+	jsonApi.AddModels(xmlOperation)
+	otherApi := parser.NewApiDeclaration()
+	otherApi.AddModels(jsonOperation)
+	for _, property := range otherApi.Models[modelId].Properties {
+		assert.Nil(suite.T(), property.XML, "XML objects should not leak into the resource not producing XML")
+	}
+	assert.Nil(suite.T(), otherApi.Models[modelId].XML, "XML objects should not leak into the resource not producing XML")
+	assert.NotNil(suite.T(), jsonApi.Models[modelId].XML, "Model of an operation producing XML should have XML objects")
+}
+
 func (suite *ApiDeclarationSuite) TestAddSubApi() {
 	api := parser.NewApiDeclaration()
 
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strings"
+)
+
+// BindingTags are the struct tags which give the location of the fields of a request binding struct, see
+// ParseParamsComment
+var BindingTags = []string{"path", "query", "header", "form"}
+
+// @Params models.ListOrdersRequest
+//
+// Expands the fields of a request binding struct into params, each in the location given by its tag, e.g.
+//
+//	type ListOrdersRequest struct {
+//		// Maximum number of orders
+//		Limit  int      `query:"limit" default:"20"`
+//		Status []string `query:"status"`
+//		UserId int64    `path:"userId"`
+//	}
+//
+// The fields are documented like the fields of a model, and the untagged ones are skipped. A path param is
+// always required, the others are when tagged `binding:"required"`, `validate:"required"` or `required:"true"`
+func (operation *Operation) ParseParamsComment(commentLine string) (err error) {
+	defer recoverFatal(&err)
+	return operation.parseParamsComment(commentLine)
+}
+
+func (operation *Operation) parseParamsComment(commentLine string) error {
+	typeName := strings.TrimSpace(commentLine[len("@Params"):])
+	if typeName == "" || strings.ContainsAny(typeName, " \t") {
+		return fmt.Errorf("Can not parse params comment \"%s\", expected the type of a request binding struct.", commentLine)
+	}
+	params, err := operation.bindingParams(typeName, operation.parser.CurrentPackage)
+	if err != nil {
+		return fmt.Errorf("Can not parse params comment \"%s\", %v.", commentLine, err)
+	}
+	if len(params) == 0 {
+		return fmt.Errorf("Can not parse params comment \"%s\", %s has no field tagged with %s.", commentLine, typeName, strings.Join(BindingTags, ", "))
+	}
+	for _, param := range params {
+		operation.addParameter(param)
+	}
+	return nil
+}
+
+// bindingParams returns the params of the tagged fields of a struct, and of the structs it embeds
+func (operation *Operation) bindingParams(typeName string, currentPackage string) ([]Parameter, error) {
+	typeSpec, modelPackage := operation.parser.findModelDefinition(typeName, currentPackage)
+	structType, ok := typeSpec.Type.(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct", typeName)
+	}
+
+	params := make([]Parameter, 0)
+	for _, field := range structType.Fields.List {
+		paramType, name := bindingTag(field)
+		if paramType == "" {
+			if embeddedType := NewModelProperty().GetTypeAsString(field.Type); len(field.Names) == 0 && !IsBasicType(embeddedType) {
+				embeddedParams, err := operation.bindingParams(embeddedType, modelPackage)
+				if err != nil {
+					return nil, err
+				}
+				params = append(params, embeddedParams...)
+			}
+			continue
+		}
+		param, err := operation.bindingParam(field, paramType, name, modelPackage)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+// bindingTag returns the location and name a field is tagged with, or "" if it is not tagged with any of BindingTags
+func bindingTag(field *ast.Field) (string, string) {
+	if field.Tag == nil {
+		return "", ""
+	}
+	structTag := reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+	for _, paramType := range BindingTags {
+		if name := strings.Split(structTag.Get(paramType), ",")[0]; name != "" && name != "-" {
+			return paramType, name
+		}
+	}
+	return "", ""
+}
+
+// bindingParam documents a tagged field like the field of a model, and turns it into a param
+func (operation *Operation) bindingParam(field *ast.Field, paramType string, name string, modelPackage string) (Parameter, error) {
+	m := NewModel(operation.parser)
+	m.Properties = make(map[string]*ModelProperty)
+	m.parseModelProperty(&ast.Field{Doc: field.Doc, Comment: field.Comment, Names: []*ast.Ident{ast.NewIdent(name)}, Type: field.Type}, modelPackage)
+	property, ok := m.Properties[name]
+	if !ok {
+		return Parameter{}, fmt.Errorf("field %s can not be parsed", name)
+	}
+
+	param := Parameter{
+		ParamType:   paramType,
+		Name:        name,
+		Description: property.Description,
+		Format:      property.Format,
+		Enum:        property.Enum,
+		Required:    paramType == "path",
+	}
+	// the elements of an array may have an enum type, e.g. []OrderStatus
+	if property.Type == "array" && property.Items.Ref != "" {
+		if underlyingType, enumValues, _ := operation.parser.findEnumValues(property.Items.Ref, modelPackage); enumValues != nil {
+			property.Items = ModelPropertyItems{Type: underlyingType}
+			param.Enum = enumValues
+		}
+	}
+	switch {
+	case IsBasicType(property.Type):
+		param.Type = property.Type
+		param.DataType = property.Type
+	case property.Type == "array" && property.Items.Type != "" && IsBasicType(property.Items.Type):
+		param.Type = "array"
+		param.DataType = "array"
+		param.Items = &OperationItems{Type: property.Items.Type}
+		param.AllowMultiple = true
+		param.CollectionFormat = "csv"
+	default:
+		return Parameter{}, fmt.Errorf("field %s must have a basic type or be an array of one, not %s", name, property.Type)
+	}
+	if param.Format == "" {
+		param.Format = ParamFormat(param.Type)
+	}
+
+	structTag := reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+	for _, tag := range []string{"binding", "validate"} {
+		for _, rule := range strings.Split(structTag.Get(tag), ",") {
+			if rule == "required" {
+				param.Required = true
+			}
+		}
+	}
+	if required := structTag.Get("required"); required != "" {
+		param.Required = param.Required || required != "false"
+	}
+	if description := structTag.Get("description"); description != "" {
+		param.Description = description
+	}
+	if defaultTag := structTag.Get("default"); defaultTag != "" {
+		defaultValue, err := CoerceLiteral(defaultTag, param.DataType)
+		if err != nil {
+			return Parameter{}, fmt.Errorf("invalid default of field %s: %v", name, err)
+		}
+		param.Default = defaultValue
+	}
+	if exampleTag := structTag.Get("example"); exampleTag != "" {
+		example, err := ParseExample(exampleTag, param.DataType)
+		if err != nil {
+			return Parameter{}, fmt.Errorf("invalid example of field %s: %v", name, err)
+		}
+		param.Example = example
+	}
+	return param, nil
+}
@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// declarationCacheVersion is part of the keys of the cached packages, so a cache written by another version is not used
+const declarationCacheVersion = "2"
+
+// declarationCacheEntry is a cached package: the declarations of its files, i.e. their source with the bodies of the
+// functions blanked out, which parses much faster than the whole source, see BenchmarkDeclarationCache. The ASTs
+// themselves can not be cached, they do not survive encoding
+type declarationCacheEntry struct {
+	Key   string            // of the package path and the names, sizes and modification times of its files
+	Files map[string]string // declarations of each file, by name
+}
+
+// EnableDeclarationCache keeps the declarations of the parsed packages in the directory, so the next runs, e.g. of the
+// generator in a development loop, only parse those instead of the whole source. A package is parsed again once any of
+// its files is added, removed or modified
+func (parser *Parser) EnableDeclarationCache(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("Can not create cache directory %s: %v", dir, err)
+	}
+	parser.declarationCacheDir = dir
+	return nil
+}
+
+// parseDirCached parses a package like goparser.ParseDir, from the declaration cache when its files did not change
+func (parser *Parser) parseDirCached(fileSet *token.FileSet, packagePath string, filter func(os.FileInfo) bool) (map[string]*ast.Package, error) {
+	key, fileNames, err := declarationCacheKey(packagePath, filter)
+	if err != nil {
+		return nil, err
+	}
+	pathHash := sha256.Sum256([]byte(packagePath))
+	cacheFileName := filepath.Join(parser.declarationCacheDir, hex.EncodeToString(pathHash[:16])+".json.gz")
+
+	if entry, err := readDeclarationCacheEntry(cacheFileName); err == nil && entry.Key == key {
+		if astPackages, err := parseCachedFiles(fileSet, packagePath, entry); err == nil {
+			parser.debugf("Package %s loaded from the declaration cache\n", packagePath)
+			return astPackages, nil
+		}
+	}
+
+	entry := &declarationCacheEntry{Key: key, Files: make(map[string]string)}
+	astPackages := make(map[string]*ast.Package)
+	for _, fileName := range fileNames {
+		src, err := ioutil.ReadFile(filepath.Join(packagePath, fileName))
+		if err != nil {
+			return nil, err
+		}
+		astFile, err := goparser.ParseFile(fileSet, filepath.Join(packagePath, fileName), src, goparser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		addFile(astPackages, filepath.Join(packagePath, fileName), astFile)
+		entry.Files[fileName] = string(declarationsOnly(fileSet, astFile, src))
+	}
+	if err := writeDeclarationCacheEntry(cacheFileName, entry); err != nil {
+		parser.warnf("Can not write the declaration cache of package %s: %v\n", packagePath, err)
+	}
+	return astPackages, nil
+}
+
+// declarationCacheKey returns the key of the files of the package the filter accepts, and their names
+func declarationCacheKey(packagePath string, filter func(os.FileInfo) bool) (string, []string, error) {
+	infos, err := ioutil.ReadDir(packagePath)
+	if err != nil {
+		return "", nil, err
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n", declarationCacheVersion, packagePath)
+	fileNames := make([]string, 0)
+	for _, info := range infos {
+		if filter(info) {
+			fileNames = append(fileNames, info.Name())
+			fmt.Fprintf(hash, "%s %d %d\n", info.Name(), info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	sort.Strings(fileNames)
+	return hex.EncodeToString(hash.Sum(nil)), fileNames, nil
+}
+
+func parseCachedFiles(fileSet *token.FileSet, packagePath string, entry *declarationCacheEntry) (map[string]*ast.Package, error) {
+	fileNames := make([]string, 0, len(entry.Files))
+	for fileName := range entry.Files {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	astPackages := make(map[string]*ast.Package)
+	for _, fileName := range fileNames {
+		astFile, err := goparser.ParseFile(fileSet, filepath.Join(packagePath, fileName), entry.Files[fileName], goparser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		addFile(astPackages, filepath.Join(packagePath, fileName), astFile)
+	}
+	return astPackages, nil
+}
+
+// addFile adds a file to its package, as goparser.ParseDir does
+func addFile(astPackages map[string]*ast.Package, fileName string, astFile *ast.File) {
+	name := astFile.Name.Name
+	astPackage, ok := astPackages[name]
+	if !ok {
+		astPackage = &ast.Package{Name: name, Files: make(map[string]*ast.File)}
+		astPackages[name] = astPackage
+	}
+	astPackage.Files[fileName] = astFile
+}
+
+// declarationsOnly blanks out the bodies of the functions of a file, except the comments in them, which may hold
+// annotations like @SubApi. The newlines are kept, so everything left is at the same position as in the source
+func declarationsOnly(fileSet *token.FileSet, astFile *ast.File, src []byte) []byte {
+	tokenFile := fileSet.File(astFile.Pos())
+	declarations := append([]byte(nil), src...)
+	for _, declaration := range astFile.Decls {
+		funcDeclaration, ok := declaration.(*ast.FuncDecl)
+		if !ok || funcDeclaration.Body == nil {
+			continue
+		}
+		for i := tokenFile.Offset(funcDeclaration.Body.Lbrace) + 1; i < tokenFile.Offset(funcDeclaration.Body.Rbrace); i++ {
+			if declarations[i] != '\n' {
+				declarations[i] = ' '
+			}
+		}
+	}
+	for _, commentGroup := range astFile.Comments {
+		for _, comment := range commentGroup.List {
+			start := tokenFile.Offset(comment.Slash)
+			end := commentEnd(src, start)
+			copy(declarations[start:end], src[start:end])
+		}
+	}
+	return declarations
+}
+
+// commentEnd returns the offset following the comment starting at the offset
+func commentEnd(src []byte, start int) int {
+	if bytes.HasPrefix(src[start:], []byte("/*")) {
+		if end := bytes.Index(src[start+2:], []byte("*/")); end != -1 {
+			return start + 2 + end + 2
+		}
+		return len(src)
+	}
+	if end := bytes.IndexByte(src[start:], '\n'); end != -1 {
+		return start + end
+	}
+	return len(src)
+}
+
+func readDeclarationCacheEntry(fileName string) (*declarationCacheEntry, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	entry := &declarationCacheEntry{}
+	if err := json.NewDecoder(reader).Decode(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// writeDeclarationCacheEntry writes the entry to a temporary file first, so a concurrent run never reads half of it
+func writeDeclarationCacheEntry(fileName string, entry *declarationCacheEntry) error {
+	file, err := ioutil.TempFile(filepath.Dir(fileName), filepath.Base(fileName)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	writer := gzip.NewWriter(file)
+	err = json.NewEncoder(writer).Encode(entry)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), fileName)
+}
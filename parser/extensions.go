@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Extensions are the vendor extensions of an operation or of the API, from @x-name annotations, by their name
+// with its x- prefix. They are written as fields of the operation or of the spec
+type Extensions map[string]interface{}
+
+// builtInExtensions are the x- fields the parser writes itself, from other annotations, by their lowercase name
+var builtInExtensions = map[string]string{
+	"x-operationid":  "@ID",
+	"x-sunset":       "@Deprecated",
+	"x-idempotent":   "@Idempotent",
+	"x-cacheable":    "@Cacheable",
+	"x-externaldocs": "@ExternalDocs",
+}
+
+// @x-codegen-request-body-name order
+// @x-internal
+//
+// The value is JSON when it starts with { or [, e.g. @x-rate-limit {"requests": 100}, a string otherwise, and true
+// when it is left out. The x- fields the parser writes itself, like x-sunset, can not be given this way
+func ParseExtensionComment(commentLine string) (string, interface{}, error) {
+	fields := strings.SplitN(strings.TrimSpace(commentLine), " ", 2)
+	name := strings.TrimPrefix(fields[0], "@")
+	if !strings.HasPrefix(strings.ToLower(name), "x-") || len(name) == len("x-") {
+		return "", nil, fmt.Errorf("Can not parse extension comment \"%s\", expected @x-name value.", commentLine)
+	}
+	if annotation, ok := builtInExtensions[strings.ToLower(name)]; ok {
+		return "", nil, fmt.Errorf("Can not parse extension comment \"%s\", %s is written from %s.", commentLine, name, annotation)
+	}
+
+	var value interface{} = true
+	if len(fields) == 2 {
+		if text := strings.TrimSpace(fields[1]); strings.HasPrefix(text, "{") || strings.HasPrefix(text, "[") {
+			if err := json.Unmarshal([]byte(text), &value); err != nil {
+				return "", nil, fmt.Errorf("Can not parse extension comment \"%s\", invalid JSON: %v.", commentLine, err)
+			}
+		} else if text != "" {
+			value = text
+		}
+	}
+	return name, value, nil
+}
+
+// add adds an extension parsed from its annotation to the extensions, which are created if there are none
+func (extensions *Extensions) add(commentLine string) error {
+	name, value, err := ParseExtensionComment(commentLine)
+	if err != nil {
+		return err
+	}
+	if *extensions == nil {
+		*extensions = make(Extensions)
+	}
+	(*extensions)[name] = value
+	return nil
+}
+
+// marshalWithExtensions adds the extensions as fields of a JSON object, sorted by name after the other fields
+func marshalWithExtensions(object []byte, extensions Extensions) ([]byte, error) {
+	if len(extensions) == 0 {
+		return object, nil
+	}
+	names := make([]string, 0, len(extensions))
+	for name := range extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.Write(object[:len(object)-1])
+	for i, name := range names {
+		value, err := json.Marshal(extensions[name])
+		if err != nil {
+			return nil, fmt.Errorf("Can not marshal extension %s: %v", name, err)
+		}
+		if i > 0 || len(object) > 2 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,242 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Formats of the specs GenerateSpec writes
+const (
+	FormatSwagger12 = "swagger12" // a resource listing and an api declaration per resource, see WriteApiDescriptions
+	FormatSwagger20 = "swagger20" // a single Swagger 2.0 file
+	FormatOpenAPI3  = "openapi3"  // a single OpenAPI 3.0 file
+)
+
+// Encodings of the specs GenerateSpec writes
+const (
+	OutputJson = "json"
+	OutputYaml = "yaml"
+)
+
+// GenerateOptions selects the API GenerateSpec parses, and the spec it writes
+type GenerateOptions struct {
+	Packages   []string // the packages implementing the API controllers, relative to $GOPATH/src, or directories like ./api, see ScanPackages for exclusions
+	MainFile   string   // the file with the general API annotations, absolute or relative to $GOPATH/src, optional
+	Format     string   // one of FormatSwagger12 (the default), FormatSwagger20 or FormatOpenAPI3
+	Output     string   // OutputJson (the default) or OutputYaml, Swagger 1.2 is written as JSON only
+	OutputPath string   // the file the spec is written to, or the directory of the Swagger 1.2 files
+	Flatten    bool     // Swagger 1.2 only: write the resources merged into a single api declaration file at OutputPath
+}
+
+// GenerateSpec parses the API with a new parser, and writes its spec, see (*Parser).GenerateSpec
+func GenerateSpec(opts GenerateOptions) error {
+	return NewParser().GenerateSpec(opts)
+}
+
+// GenerateSpec parses the general API info and the API of the packages, and writes the spec in the selected format.
+// Parsing runs in LibraryMode, so failures are returned rather than terminating the process, and the failures
+// parsing goes on after are collected into Warnings
+func (parser *Parser) GenerateSpec(opts GenerateOptions) error {
+	format := strings.ToLower(opts.Format)
+	if format == "" {
+		format = FormatSwagger12
+	}
+	output := strings.ToLower(opts.Output)
+	if output == "" {
+		output = OutputJson
+	}
+	switch {
+	case len(opts.Packages) == 0:
+		return fmt.Errorf("Can not generate spec, no packages given.")
+	case opts.OutputPath == "":
+		return fmt.Errorf("Can not generate spec, no output path given.")
+	case format != FormatSwagger12 && format != FormatSwagger20 && format != FormatOpenAPI3:
+		return fmt.Errorf("Can not generate spec, format must be one of %s, %s or %s, not %s.", FormatSwagger12, FormatSwagger20, FormatOpenAPI3, opts.Format)
+	case output != OutputJson && output != OutputYaml:
+		return fmt.Errorf("Can not generate spec, output must be %s or %s, not %s.", OutputJson, OutputYaml, opts.Output)
+	case format == FormatSwagger12 && output != OutputJson:
+		return fmt.Errorf("Can not generate spec, %s is written as %s only.", FormatSwagger12, OutputJson)
+	}
+
+	libraryMode := parser.LibraryMode
+	parser.LibraryMode = true
+	defer func() {
+		parser.LibraryMode = libraryMode
+	}()
+
+	if opts.MainFile != "" {
+		if err := parser.ParseGeneralAPIInfo(findMainFile(opts.MainFile)); err != nil {
+			return fmt.Errorf("Can not parse general API info: %v", err)
+		}
+	}
+	if err := parser.ParseApiContext(context.Background(), strings.Join(opts.Packages, ",")); err != nil {
+		return fmt.Errorf("Can not parse API: %v", err)
+	}
+
+	var spec interface{}
+	switch format {
+	case FormatSwagger12:
+		if !opts.Flatten {
+			return parser.WriteApiDescriptions(opts.OutputPath)
+		}
+		spec = parser.MergedApiDeclaration()
+	case FormatSwagger20:
+		spec = parser.Swagger20()
+	case FormatOpenAPI3:
+		spec = parser.OpenAPI3()
+	}
+	data, err := json.MarshalIndent(spec, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Can not serialise spec to JSON: %v", err)
+	}
+	if output == OutputYaml {
+		if data, err = JsonToYaml(data); err != nil {
+			return fmt.Errorf("Can not serialise spec to YAML: %v", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.OutputPath), 0755); err != nil {
+		return fmt.Errorf("Can not create spec directory %s: %v", filepath.Dir(opts.OutputPath), err)
+	}
+	if err := ioutil.WriteFile(opts.OutputPath, append(bytes.TrimRight(data, "\n"), '\n'), 0644); err != nil {
+		return fmt.Errorf("Can not write spec file %s: %v", opts.OutputPath, err)
+	}
+	return nil
+}
+
+// findMainFile returns the main file relative to the first of the $GOPATH entries it is found in, or as it is
+func findMainFile(mainFile string) string {
+	if filepath.IsAbs(mainFile) {
+		return mainFile
+	}
+	for _, gopath := range filepath.SplitList(os.Getenv("GOPATH")) {
+		if fileName := filepath.Join(gopath, "src", mainFile); fileExists(fileName) {
+			return fileName
+		}
+	}
+	return mainFile
+}
+
+func fileExists(fileName string) bool {
+	info, err := os.Stat(fileName)
+	return err == nil && !info.IsDir()
+}
+
+// yamlNode is a JSON value in the order of the document, objects keep the order of their keys
+type yamlNode struct {
+	keys   []string // objects only
+	values []interface{}
+	object bool
+}
+
+// JsonToYaml converts a JSON document to block style YAML, keeping the order of the object keys
+func JsonToYaml(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := decodeYamlNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	writeYamlValue(&buffer, value, 0)
+	return buffer.Bytes(), nil
+}
+
+func decodeYamlNode(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	node := &yamlNode{object: delim == '{'}
+	for decoder.More() {
+		if node.object {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			node.keys = append(node.keys, key.(string))
+		}
+		value, err := decodeYamlNode(decoder)
+		if err != nil {
+			return nil, err
+		}
+		node.values = append(node.values, value)
+	}
+	// the closing delimiter
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// writeYamlValue writes a value following a key or a dash, which is already written
+func writeYamlValue(buffer *bytes.Buffer, value interface{}, indent int) {
+	node, ok := value.(*yamlNode)
+	if !ok {
+		buffer.WriteString(" " + yamlScalar(value) + "\n")
+		return
+	}
+	if len(node.values) == 0 {
+		if node.object {
+			buffer.WriteString(" {}\n")
+		} else {
+			buffer.WriteString(" []\n")
+		}
+		return
+	}
+	if buffer.Len() > 0 {
+		buffer.WriteString("\n")
+	}
+	writeYamlNode(buffer, node, indent)
+}
+
+func writeYamlNode(buffer *bytes.Buffer, node *yamlNode, indent int) {
+	for i, value := range node.values {
+		if node.object {
+			buffer.WriteString(strings.Repeat(" ", indent) + yamlScalar(node.keys[i]) + ":")
+			writeYamlValue(buffer, value, indent+2)
+			continue
+		}
+		buffer.WriteString(strings.Repeat(" ", indent) + "-")
+		if item, ok := value.(*yamlNode); ok && len(item.values) > 0 {
+			// the first line of the item follows the dash
+			var itemBuffer bytes.Buffer
+			writeYamlNode(&itemBuffer, item, indent+2)
+			buffer.WriteString(" " + strings.TrimPrefix(itemBuffer.String(), strings.Repeat(" ", indent+2)))
+			continue
+		}
+		writeYamlValue(buffer, value, indent+2)
+	}
+}
+
+var yamlPlainScalar = regexp.MustCompile(`^[A-Za-z_/$][-\w./${}]*$`)
+
+// yamlScalar writes strings which YAML would read as another type, or which contain special characters,
+// as double-quoted, which accepts the escapes of JSON strings
+func yamlScalar(value interface{}) string {
+	switch scalar := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		switch strings.ToLower(scalar) {
+		case "y", "n", "yes", "no", "on", "off", "true", "false", "null":
+		default:
+			if yamlPlainScalar.MatchString(scalar) {
+				return scalar
+			}
+		}
+		quoted, _ := json.Marshal(scalar)
+		return string(quoted)
+	}
+	return fmt.Sprint(value)
+}
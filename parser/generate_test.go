@@ -0,0 +1,590 @@
+package parser_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RobotsAndPencils/go-swaggerLite/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+const exampleMainFile = "github.com/RobotsAndPencils/go-swaggerLite/example/web/main.go"
+
+const exampleModelPrefix = "github.com.RobotsAndPencils.go-swaggerLite.example."
+
+// parseOperation parses the comment of an operation of the example package, and adds it to the parser
+func parseOperation(t *testing.T, p *parser.Parser, comment string) {
+	op := parser.NewOperation(p, ExamplePackageName)
+	for _, line := range strings.Split(strings.TrimSpace(comment), "\n") {
+		assert.Nil(t, op.ParseComment(line), "Can not parse operation comment %s", line)
+	}
+	p.AddOperation(op)
+}
+
+func TestSwagger20(t *testing.T) {
+	p := parser.NewParser()
+	p.BasePath = exampleBasePath
+	assert.Nil(t, p.ParseGeneralAPIInfo(path.Join(os.Getenv("GOPATH"), "src", exampleMainFile)), "Can not parse general API info")
+	p.ParseApi(ExamplePackageName)
+
+	spec := p.Swagger20()
+	assert.Equal(t, "2.0", spec.Swagger, "Swagger version not set")
+	assert.Equal(t, "Swagger Example API", spec.Info.Title, "Info not converted")
+	assert.Equal(t, "1.0.0", spec.Info.Version, "Info not converted")
+	assert.Equal(t, "127.0.0.1:3000", spec.Host, "Host should be taken from the base path URL")
+	assert.Equal(t, "/", spec.BasePath, "Base path should be the path of the base path URL")
+	assert.Equal(t, []string{"http"}, spec.Schemes, "Scheme should be taken from the base path URL")
+
+	op := spec.Paths["/testapi/get-struct-by-int/{some_id}"]["get"]
+	if !assert.NotNil(t, op, "Operation not converted") {
+		return
+	}
+	assert.Equal(t, "GetStructByInt", op.OperationId, "Nickname should be the operation id")
+	assert.Equal(t, &parser.Swagger20Parameter{Name: "some_id", In: "path", Description: "Some ID", Required: true, Type: "integer"}, op.Parameters[0], "Param not converted")
+	assert.Equal(t, "#/definitions/"+exampleModelPrefix+"StructureWithEmbededStructure", op.Responses["200"].Schema.Ref, "Response model not referenced")
+	assert.Equal(t, "We need ID!!", op.Responses["400"].Description, "Response message should be the description")
+
+	arrayOp := spec.Paths["/testapi/get-struct-array-by-string/{some_id}"]["get"]
+	if assert.NotNil(t, arrayOp, "Operation not converted") {
+		assert.Equal(t, "array", arrayOp.Responses["200"].Schema.Type, "Array response not converted")
+		assert.Equal(t, "#/definitions/"+exampleModelPrefix+"SimpleStructureWithAnnotations", arrayOp.Responses["200"].Schema.Items.Ref, "Array response not converted")
+	}
+
+	apiError := spec.Definitions[exampleModelPrefix+"APIError"]
+	if assert.NotNil(t, apiError, "Model not converted to a definition") {
+		assert.Equal(t, "object", apiError.Type, "Model not converted to a definition")
+		assert.Equal(t, &parser.Schema{Type: "integer"}, apiError.Properties["ErrorCode"], "Property not converted")
+		assert.Equal(t, &parser.Schema{Type: "string"}, apiError.Properties["ErrorMessage"], "Property not converted")
+	}
+}
+
+func TestSpecLocation(t *testing.T) {
+	cases := []struct {
+		basePath string
+		schemes  []string
+		host     string
+		path     string
+		servers  []parser.OpenAPI3Server
+	}{
+		{"http://127.0.0.1:3000/", nil, "127.0.0.1:3000", "/", []parser.OpenAPI3Server{{Url: "http://127.0.0.1:3000/"}}},
+		{"https://api.example.com/v1", []string{"https", "wss"}, "api.example.com", "/v1", []parser.OpenAPI3Server{{Url: "https://api.example.com/v1"}, {Url: "wss://api.example.com/v1"}}},
+		{"http://api.example.com", []string{"https"}, "api.example.com", "/", []parser.OpenAPI3Server{{Url: "https://api.example.com/"}}},
+		{"/api", []string{"https"}, "", "/api", []parser.OpenAPI3Server{{Url: "/api"}}},
+		{"api", nil, "", "/api", []parser.OpenAPI3Server{{Url: "/api"}}},
+		{"", nil, "", "", nil},
+	}
+	for _, c := range cases {
+		p := parser.NewParser()
+		p.BasePath = c.basePath
+		p.Schemes = c.schemes
+		spec := p.Swagger20()
+		assert.Equal(t, c.host, spec.Host, "Host of base path %s", c.basePath)
+		assert.Equal(t, c.path, spec.BasePath, "Base path of base path %s", c.basePath)
+		assert.Equal(t, c.servers, p.OpenAPI3().Servers, "Servers of base path %s", c.basePath)
+		if c.schemes == nil && c.host != "" {
+			assert.Equal(t, []string{"http"}, spec.Schemes, "Scheme of base path %s", c.basePath)
+		} else {
+			assert.Equal(t, c.schemes, spec.Schemes, "Schemes of base path %s", c.basePath)
+		}
+	}
+}
+
+func TestSwagger20BodyAndFormParams(t *testing.T) {
+	p := parser.NewParser()
+	p.ParseTypeDefinitions(ExamplePackageName)
+	p.CurrentPackage = ExamplePackageName
+	parseOperation(t, p, `
+// @Title CreateStructures
+// @ID createStructures
+// @Param   body     body    []SimpleStructure  true  "The structures"
+// @Param   X-Trace  header  string             false "Trace ID" minLength(8)
+// @Success 201 {object} SimpleStructure "Created"
+// @Header  201 Location string "URL of the structure"
+// @Security api_key
+// @Router /structures [post]
+`)
+	parseOperation(t, p, `
+// @Title Upload
+// @Accept mpfd
+// @Param   name  form  string  true  "File name"
+// @Param   size  form  int64   false "File size" minimum(1)
+// @Success 204 {string} string
+// @Router /structures/upload [post]
+`)
+
+	spec := p.Swagger20()
+	create := spec.Paths["/structures"]["post"]
+	body := create.Parameters[0]
+	assert.Equal(t, "createStructures", create.OperationId, "Operation id not converted")
+	assert.Equal(t, "body", body.In, "Body param not converted")
+	assert.Equal(t, &parser.Schema{Type: "array", Items: &parser.Schema{Ref: "#/definitions/" + exampleModelPrefix + "SimpleStructure"}}, body.Schema, "Body param should have a schema")
+	assert.Empty(t, body.Type, "Body param should only have a schema")
+	assert.Equal(t, 8, *create.Parameters[1].MinLength, "Param validation not converted")
+	assert.Equal(t, "Created", create.Responses["201"].Description, "Response not converted")
+	assert.Equal(t, map[string]*parser.Schema{"Location": {Type: "string", Description: "URL of the structure"}}, create.Responses["201"].Headers, "Response headers not converted")
+	assert.Equal(t, []map[string][]string{{"api_key": {}}}, create.Security, "Security not converted")
+	assert.Contains(t, spec.Definitions, exampleModelPrefix+"SimpleStructure", "Model of the body param not converted")
+
+	upload := spec.Paths["/structures/upload"]["post"]
+	assert.Equal(t, "formData", upload.Parameters[0].In, "Form param not converted")
+	assert.Equal(t, "int64", upload.Parameters[1].Format, "Param format not converted")
+	assert.Equal(t, "No Content", upload.Responses["204"].Description, "Response without message should be described by its status")
+
+	openAPI := p.OpenAPI3()
+	assert.Equal(t, "3.0.3", openAPI.OpenAPI, "OpenAPI version not set")
+	create3 := openAPI.Paths["/structures"]["post"]
+	if assert.NotNil(t, create3.RequestBody, "Body param should be the request body") {
+		assert.True(t, create3.RequestBody.Required, "Body param should be the request body")
+		assert.Equal(t, "#/components/schemas/"+exampleModelPrefix+"SimpleStructure", create3.RequestBody.Content["application/json"].Schema.Items.Ref, "Body param should be the request body")
+	}
+	assert.Len(t, create3.Parameters, 1, "Body param should not be a parameter")
+	assert.Equal(t, "header", create3.Parameters[0].In, "Header param not converted")
+	assert.Equal(t, "#/components/schemas/"+exampleModelPrefix+"SimpleStructure", create3.Responses["201"].Content["application/json"].Schema.Ref, "Response not converted")
+	assert.Contains(t, openAPI.Components.Schemas, exampleModelPrefix+"SimpleStructure", "Model not converted to a component")
+
+	upload3 := openAPI.Paths["/structures/upload"]["post"]
+	assert.Empty(t, upload3.Parameters, "Form params should be the request body")
+	if assert.NotNil(t, upload3.RequestBody, "Form params should be the request body") {
+		form := upload3.RequestBody.Content["multipart/form-data"].Schema
+		assert.Equal(t, []string{"name"}, form.Required, "Form params should be the request body")
+		assert.Equal(t, &parser.Schema{Type: "integer", Format: "int64", Description: "File size", Minimum: form.Properties["size"].Minimum}, form.Properties["size"], "Form params should be the request body")
+	}
+}
+
+func TestSwagger20Discriminator(t *testing.T) {
+	p := parser.NewParser()
+	p.ParseTypeDefinitions(ExamplePackageName)
+	p.CurrentPackage = ExamplePackageName
+	parseOperation(t, p, `
+// @Title GetPet
+// @Success 200 {object} Pet
+// @Router /pets/{id} [get]
+`)
+
+	petRef := &parser.Schema{Ref: "#/definitions/" + exampleModelPrefix + "Pet"}
+	spec := p.Swagger20()
+	assert.Equal(t, "petType", spec.Definitions[exampleModelPrefix+"Pet"].Discriminator, "Discriminator not converted")
+	cat := spec.Definitions[exampleModelPrefix+"PetCat"]
+	if assert.Len(t, cat.AllOf, 2, "Subtype should be composed of the model") {
+		assert.Equal(t, petRef, cat.AllOf[0], "Subtype should be composed of the model")
+		assert.Contains(t, cat.AllOf[1].Properties, "lives", "Subtype should be composed of the model")
+	}
+	dog := spec.Definitions[exampleModelPrefix+"PetDog"]
+	if assert.Len(t, dog.AllOf, 2, "Subtype already composed of the model should be kept") {
+		assert.Equal(t, petRef, dog.AllOf[0], "Subtype already composed of the model should be kept")
+	}
+
+	openAPI := p.OpenAPI3()
+	assert.Equal(t, &parser.OpenAPI3Discriminator{PropertyName: "petType"}, openAPI.Components.Schemas[exampleModelPrefix+"Pet"].Discriminator, "Discriminator not converted")
+}
+
+func TestOpenAPI3DiscriminatorOfComposedModel(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	dir := filepath.Join(gopath, "src", "example.com/zoo/models")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	source := `package models
+
+type Animal struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+// @Discriminator kind
+// @AllOf Pet Animal
+type Pet struct {
+	Kind string ` + "`json:\"kind\"`" + `
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "models.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Can not write package source: %v", err)
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.ParseTypeDefinitions("example.com/zoo/models")
+	op := parser.NewOperation(p, "example.com/zoo/models")
+	for _, line := range []string{"// @Title GetPet", "// @Success 200 {object} Pet", "// @Router /pets/{id} [get]"} {
+		assert.Nil(t, op.ParseComment(line), "Can not parse operation comment %s", line)
+	}
+	p.AddOperation(op)
+
+	pet := p.OpenAPI3().Components.Schemas["example.com.zoo.models.Pet"]
+	if assert.NotNil(t, pet, "Composed model not converted") && assert.Len(t, pet.AllOf, 2, "Model should be composed") {
+		assert.Nil(t, pet.Discriminator, "Discriminator should be in the own schema of the composed model")
+		assert.Equal(t, &parser.OpenAPI3Discriminator{PropertyName: "kind"}, pet.AllOf[1].Discriminator, "Discriminator of a composed model not converted")
+	}
+}
+
+func TestOpenAPI3Nullable(t *testing.T) {
+	p := parser.NewParser()
+	p.ParseTypeDefinitions(ExamplePackageName)
+	p.CurrentPackage = ExamplePackageName
+	parseOperation(t, p, `
+// @Title GetStructure
+// @Success 200 {object} StructureWithNullableFields
+// @Router /structures/{id} [get]
+`)
+	modelId := exampleModelPrefix + "StructureWithNullableFields"
+	simpleStructureId := exampleModelPrefix + "SimpleStructure"
+
+	definition := p.Swagger20().Definitions[modelId]
+	assert.Equal(t, &parser.Schema{Ref: "#/definitions/" + simpleStructureId, Nullable: true}, definition.Properties["parent"], "Pointer field should stay a reference in Swagger 2.0")
+	assert.True(t, definition.Properties["deletedAt"].Nullable, "@Nullable field should be x-nullable in Swagger 2.0")
+	assert.False(t, definition.Properties["deletedAt"].OpenAPINullable, "Swagger 2.0 has no nullable field")
+
+	schema := p.OpenAPI3().Components.Schemas[modelId]
+	assert.Equal(t, &parser.Schema{AllOf: []*parser.Schema{{Ref: "#/components/schemas/" + simpleStructureId}}, OpenAPINullable: true}, schema.Properties["parent"], "Nullable reference should be composed of the model")
+	deletedAt := schema.Properties["deletedAt"]
+	assert.True(t, deletedAt.OpenAPINullable, "@Nullable field should be nullable")
+	assert.False(t, deletedAt.Nullable, "OpenAPI 3.0 should not have the x-nullable extension")
+	assert.Equal(t, "date-time", deletedAt.Format, "Nullable field should keep its type")
+	assert.False(t, schema.Properties["owner"].OpenAPINullable, "@Nullable false should override the pointer")
+	assert.False(t, schema.Properties["name"].OpenAPINullable, "Non pointer field should not be nullable")
+	assert.Contains(t, schema.Required, "deletedAt", "Nullable field should stay required")
+
+	content, err := json.Marshal(deletedAt)
+	assert.Nil(t, err, "Can not marshal schema")
+	assert.Contains(t, string(content), `"nullable":true`, "Nullable not written")
+}
+
+func TestSwagger20CollectionFormat(t *testing.T) {
+	p := parser.NewParser()
+	p.CurrentPackage = ExamplePackageName
+	parseOperation(t, p, `
+// @Title FindOrders
+// @Param ids query []int64 false "Order IDs"
+// @Param tags query []string false "Tags" collectionFormat(multi)
+// @Param codes header []string false "Codes" collectionFormat(tsv)
+// @Router /orders [get]
+`)
+
+	params := p.Swagger20().Paths["/orders"]["get"].Parameters
+	if assert.Len(t, params, 3, "Params not converted") {
+		assert.Equal(t, &parser.Swagger20Parameter{Name: "ids", In: "query", Description: "Order IDs", Type: "array",
+			Items: &parser.Schema{Type: "integer", Format: "int64"}, CollectionFormat: "csv"}, params[0], "Array param not converted")
+		assert.Equal(t, "multi", params[1].CollectionFormat, "Collection format not converted")
+	}
+
+	openAPIParams := p.OpenAPI3().Paths["/orders"]["get"].Parameters
+	if assert.Len(t, openAPIParams, 3, "Params not converted") {
+		explode := false
+		assert.Equal(t, "form", openAPIParams[0].Style, "csv should be the form style")
+		assert.Equal(t, &explode, openAPIParams[0].Explode, "csv should not be exploded")
+		explode = true
+		assert.Equal(t, "form", openAPIParams[1].Style, "multi should be the form style")
+		assert.Equal(t, &explode, openAPIParams[1].Explode, "multi should be exploded")
+		assert.Equal(t, "", openAPIParams[2].Style, "tsv has no style")
+		assert.Equal(t, "tsv", openAPIParams[2].CollectionFormat, "tsv should be kept as an extension")
+	}
+}
+
+func TestReusableParams(t *testing.T) {
+	p := parser.NewParser()
+	p.CurrentPackage = ExamplePackageName
+	assert.Nil(t, p.ParseGeneralAPIInfoFromSrc([]byte(`// @Parameter limit query int false "Page size" default(20)
+// @Parameter X-Request-Id header string false "Request ID"
+package main
+`)), "Can not parse general API info")
+	assert.Len(t, p.ReusableParams, 2, "Params not declared")
+	assert.Equal(t, "header", p.ReusableParams["X-Request-Id"].ParamType, "Params not declared")
+	assert.NotNil(t, p.ParseParameterDefinition(`@Parameter limit query int false "Page size"`), "Params should be declared once")
+	assert.NotNil(t, p.ParseParameterDefinition(`@Parameter order body string true "Order"`), "Body params should not be reusable")
+
+	parseOperation(t, p, `
+// @Title FindOrders
+// @UseParam limit, X-Request-Id
+// @Param status query string false "Status"
+// @Router /orders [get]
+`)
+	op := parser.NewOperation(p, ExamplePackageName)
+	assert.NotNil(t, op.ParseComment("// @UseParam offset"), "Undeclared params should not be usable")
+
+	params := p.TopLevelApis["orders"].Apis[0].Operations[0].Parameters
+	if assert.Len(t, params, 3, "Params not added") {
+		assert.Equal(t, "limit", params[0].Name, "Reused params should be inlined in Swagger 1.2")
+		assert.Equal(t, int64(20), params[0].Default, "Reused params should be inlined in Swagger 1.2")
+	}
+
+	swagger := p.Swagger20()
+	content, err := json.Marshal(swagger.Paths["/orders"]["get"].Parameters)
+	assert.Nil(t, err, "Can not marshal params")
+	assert.JSONEq(t, `[{"$ref": "#/parameters/limit"}, {"$ref": "#/parameters/X-Request-Id"},
+		{"name": "status", "in": "query", "description": "Status", "required": false, "type": "string"}]`, string(content), "Reused params not referenced")
+	assert.Equal(t, "integer", swagger.Parameters["limit"].Type, "Reused params not listed")
+	assert.Equal(t, "header", swagger.Parameters["X-Request-Id"].In, "Reused params not listed")
+
+	openAPI := p.OpenAPI3()
+	content, err = json.Marshal(openAPI.Paths["/orders"]["get"].Parameters[:2])
+	assert.Nil(t, err, "Can not marshal params")
+	assert.JSONEq(t, `[{"$ref": "#/components/parameters/limit"}, {"$ref": "#/components/parameters/X-Request-Id"}]`, string(content), "Reused params not referenced")
+	assert.Equal(t, "query", openAPI.Components.Parameters["limit"].In, "Reused params not listed")
+	assert.Equal(t, "integer", openAPI.Components.Parameters["limit"].Schema.Type, "Reused params not listed")
+}
+
+func TestGlobalResponses(t *testing.T) {
+	p := parser.NewParser()
+	assert.Nil(t, p.ParseGeneralAPIInfoFromSrc([]byte(`// @APIVersion 1.0.0
+// @GlobalResponse 401 {object} sub.SimpleStructure "unauthorized"
+// @GlobalResponse 429 "Too many requests"
+package main
+
+import sub "github.com/RobotsAndPencils/go-swaggerLite/example/subpackage"
+`)), "Can not parse general API info")
+	assert.Equal(t, []parser.GlobalResponse{
+		{Name: "Unauthorized", Code: 401, Comment: `401 {object} github.com/RobotsAndPencils/go-swaggerLite/example/subpackage.SimpleStructure "unauthorized"`},
+		{Name: "TooManyRequests", Code: 429, Comment: `429 "Too many requests"`},
+	}, p.GlobalResponses, "Global responses not parsed")
+	assert.NotNil(t, p.ParseGlobalResponseDefinition(`@GlobalResponse 401 "again"`), "Global response declared twice should fail")
+	assert.NotNil(t, p.ParseGlobalResponseDefinition(`@GlobalResponse unauthorized`), "Global response without a code should fail")
+	assert.NotNil(t, p.ParseGlobalResponseDefinition(`@GlobalResponse 403 {object} SimpleStructure "forbidden"`), "Global response of a model without package should fail")
+	assert.NotNil(t, p.ParseGlobalResponseDefinition(`@GlobalResponse 403 {object} other.SimpleStructure "forbidden"`), "Global response of a package the main file does not import should fail")
+
+	p.ParseTypeDefinitions(ExamplePackageName)
+	p.CurrentPackage = ExamplePackageName
+	parseOperation(t, p, `
+// @Title GetOrder
+// @Success 200 {object} SimpleStructure
+// @Router /orders/{id} [get]
+`)
+	parseOperation(t, p, `
+// @Title ListOrders
+// @Failure 429 "Slow down"
+// @Router /orders [get]
+`)
+	parseOperation(t, p, `
+// @Title ArchiveOrders
+// @Produce xml
+// @Router /archive [get]
+`)
+	errorId := exampleModelPrefix + "subpackage.SimpleStructure"
+	responses := map[string]map[int]parser.ResponseMessage{}
+	for _, op := range p.Operations() {
+		responses[op.Nickname] = map[int]parser.ResponseMessage{}
+		for _, response := range op.ResponseMessages {
+			responses[op.Nickname][response.Code] = response
+		}
+	}
+	assert.Equal(t, parser.ResponseMessage{Code: 401, Message: "unauthorized", ResponseModel: errorId, GlobalRef: "Unauthorized"}, responses["GetOrder"][401], "Global response not added")
+	assert.Equal(t, "TooManyRequests", responses["GetOrder"][429].GlobalRef, "Global response not added")
+	assert.Equal(t, parser.ResponseMessage{Code: 429, Message: "Slow down"}, responses["ListOrders"][429], "Response of the operation should be kept over the global one")
+	assert.Contains(t, p.TopLevelApis["orders"].Models, errorId, "Model of the global response not added")
+
+	spec := p.Swagger20()
+	assert.Equal(t, &parser.Schema{Ref: "#/definitions/" + errorId}, spec.Responses["Unauthorized"].Schema, "Global response not defined")
+	assert.Equal(t, "Too many requests", spec.Responses["TooManyRequests"].Description, "Global response not defined")
+	assert.Contains(t, spec.Definitions, errorId, "Model of the global response not defined")
+	getOrder := spec.Paths["/orders/{id}"]["get"]
+	assert.Equal(t, "#/responses/Unauthorized", getOrder.Responses["401"].Ref, "Operation should reference the global response")
+	assert.Equal(t, "Slow down", spec.Paths["/orders"]["get"].Responses["429"].Description, "Response of the operation should not reference the global one")
+	content, err := json.Marshal(getOrder)
+	assert.Nil(t, err, "Can not marshal operation")
+	assert.Contains(t, string(content), `"401":{"$ref":"#/responses/Unauthorized"}`, "Global response should be written as a reference")
+
+	assert.Equal(t, "#/responses/Unauthorized", spec.Paths["/archive"]["get"].Responses["401"].Ref, "Operation producing XML should reference the global response")
+
+	openAPI := p.OpenAPI3()
+	assert.Equal(t, "#/components/schemas/"+errorId, openAPI.Components.Responses["Unauthorized"].Content[parser.ContentTypeJson].Schema.Ref, "Global response not defined in OpenAPI 3.0")
+	assert.Len(t, openAPI.Components.Responses["Unauthorized"].Content, 1, "Global response should be defined in the content types of the general @Produce, whichever operation comes first")
+	assert.Equal(t, "#/components/responses/Unauthorized", openAPI.Paths["/archive"]["get"].Responses["401"].Ref, "Operation producing XML should reference the global response in OpenAPI 3.0")
+	assert.Equal(t, "#/components/responses/TooManyRequests", openAPI.Paths["/orders/{id}"]["get"].Responses["429"].Ref, "Operation should reference the global response in OpenAPI 3.0")
+	content, err = json.Marshal(openAPI.Paths["/orders/{id}"]["get"])
+	assert.Nil(t, err, "Can not marshal operation")
+	assert.Contains(t, string(content), `"401":{"$ref":"#/components/responses/Unauthorized"}`, "Global response should be written as a reference in OpenAPI 3.0")
+}
+
+func TestVendorExtensions(t *testing.T) {
+	p := parser.NewParser()
+	assert.Nil(t, p.ParseGeneralAPIInfoFromSrc([]byte(`// @APIVersion 1.0.0
+// @x-logo {"url": "https://example.com/logo.png"}
+// @x-audience internal
+package main
+`)), "Can not parse general API info")
+	assert.Equal(t, parser.Extensions{"x-logo": map[string]interface{}{"url": "https://example.com/logo.png"}, "x-audience": "internal"}, p.Listing.Extensions, "General extensions not parsed")
+
+	parseOperation(t, p, `
+// @Title GetOrder
+// @x-internal
+// @x-codegen-request-body-name order
+// @x-roles ["admin", "support"]
+// @Router /orders/{id} [get]
+`)
+	op := p.TopLevelApis["orders"].Apis[0].Operations[0]
+	assert.Equal(t, parser.Extensions{"x-internal": true, "x-codegen-request-body-name": "order", "x-roles": []interface{}{"admin", "support"}}, op.Extensions, "Operation extensions not parsed")
+	assert.NotNil(t, parser.NewOperation(p, "test").ParseComment(`// @x-roles ["admin"`), "Invalid JSON should fail")
+	assert.NotNil(t, parser.NewOperation(p, "test").ParseComment(`// @x- value`), "Extension without a name should fail")
+	for _, builtIn := range []string{"@x-sunset 2025-01-01", "@x-operationId getOrder", "@X-Cacheable 30s", "@x-idempotent", "@x-externalDocs https://example.com"} {
+		assert.NotNil(t, parser.NewOperation(p, "test").ParseComment("// "+builtIn), "Extension named like a field of the operation should fail: %s", builtIn)
+	}
+	assert.NotNil(t, parser.NewParser().ParseGeneralAPIInfoFromSrc([]byte("// @x-externalDocs https://example.com\npackage main\n")), "Extension named like a field of the resource listing should fail")
+
+	content, err := json.Marshal(op)
+	assert.Nil(t, err, "Can not marshal operation")
+	assert.Contains(t, string(content), `,"x-codegen-request-body-name":"order","x-internal":true,"x-roles":["admin","support"]}`, "Operation extensions not written")
+	content, err = json.Marshal(p.Listing)
+	assert.Nil(t, err, "Can not marshal resource listing")
+	assert.Contains(t, string(content), `"x-audience":"internal","x-logo":{"url":"https://example.com/logo.png"}}`, "General extensions not written")
+
+	for format, spec := range map[string]interface{}{"swagger20": p.Swagger20(), "openapi3": p.OpenAPI3()} {
+		content, err := json.Marshal(spec)
+		assert.Nil(t, err, "Can not marshal %s spec", format)
+		var object map[string]interface{}
+		assert.Nil(t, json.Unmarshal(content, &object), "Invalid %s spec", format)
+		assert.Equal(t, "internal", object["x-audience"], "General extensions not written in %s", format)
+		operation := object["paths"].(map[string]interface{})["/orders/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+		assert.Equal(t, true, operation["x-internal"], "Operation extensions not written in %s", format)
+		assert.Equal(t, "order", operation["x-codegen-request-body-name"], "Operation extensions not written in %s", format)
+	}
+
+	content, err = json.Marshal(&parser.Operation{})
+	assert.Nil(t, err, "Can not marshal operation")
+	assert.NotContains(t, string(content), "x-", "Operation without extensions should be written as before")
+}
+
+func TestUnreferencedModels(t *testing.T) {
+	newParser := func() *parser.Parser {
+		p := parser.NewParser()
+		p.ParseTypeDefinitions(ExamplePackageName)
+		p.CurrentPackage = ExamplePackageName
+		parseOperation(t, p, `
+// @Title GetOrder
+// @Produce json,xml
+// @Success 200 {object} StructureWithComposedTypes application/json
+// @Success 200 {object} XmlOrder application/xml
+// @Router /orders/{id} [get]
+`)
+		return p
+	}
+	composedId := exampleModelPrefix + "StructureWithComposedTypes"
+	simpleId := exampleModelPrefix + "SimpleStructure"
+	xmlId := exampleModelPrefix + "XmlOrder"
+
+	p := newParser()
+	definitions := p.Swagger20().Definitions
+	assert.Contains(t, definitions, composedId, "Model of the response should be defined")
+	assert.Contains(t, definitions, simpleId, "Model referenced by the model of the response should be defined")
+	assert.NotContains(t, definitions, xmlId, "Model no operation references should not be defined")
+	assert.Contains(t, p.TopLevelApis["orders"].Models, xmlId, "Models of the resource should be left as they are")
+
+	schemas := p.OpenAPI3().Components.Schemas
+	assert.Contains(t, schemas, xmlId, "Model of the XML response should be defined in OpenAPI 3.0")
+	assert.Contains(t, schemas, simpleId, "Model referenced by the model of the response should be defined in OpenAPI 3.0")
+
+	unreferencedId := exampleModelPrefix + "TreeNode"
+	assert.NotContains(t, definitions, unreferencedId, "Model of a type no operation uses should not be defined")
+	assert.NotContains(t, schemas, unreferencedId, "Model of a type no operation uses should not be defined in OpenAPI 3.0")
+
+	p = newParser()
+	p.IncludeAllModels = true
+	p.LibraryMode = true
+	definitions = p.Swagger20().Definitions
+	assert.Contains(t, definitions, xmlId, "Every model should be defined with IncludeAllModels")
+	assert.Contains(t, definitions, unreferencedId, "Model of every scanned type should be defined with IncludeAllModels")
+	assert.Contains(t, p.OpenAPI3().Components.Schemas, unreferencedId, "Model of every scanned type should be defined with IncludeAllModels in OpenAPI 3.0")
+	for _, warning := range p.Warnings {
+		assert.NotContains(t, warning.Error(), "Can not parse model", "Models of the scanned types should be parsed")
+	}
+}
+
+func TestJsonToYaml(t *testing.T) {
+	yaml, err := parser.JsonToYaml([]byte(`{
+		"swagger": "2.0",
+		"info": {"title": "Orders: the API", "version": "1.0"},
+		"paths": {"/orders/{id}": {"get": {"tags": ["orders"], "responses": {"200": {"description": "OK"}}}}},
+		"definitions": {},
+		"schemes": [],
+		"list": [{"a": 1, "b": true}, [null, "yes"]]
+	}`))
+	assert.Nil(t, err, "Can not convert JSON to YAML")
+	assert.Equal(t, `swagger: "2.0"
+info:
+  title: "Orders: the API"
+  version: "1.0"
+paths:
+  /orders/{id}:
+    get:
+      tags:
+        - orders
+      responses:
+        "200":
+          description: OK
+definitions: {}
+schemes: []
+list:
+  - a: 1
+    b: true
+  - - null
+    - "yes"
+`, string(yaml), "JSON not converted to YAML")
+
+	_, err = parser.JsonToYaml([]byte(`{"swagger": `))
+	assert.NotNil(t, err, "Invalid JSON should fail")
+}
+
+func TestGenerateSpec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spec")
+	if err != nil {
+		t.Fatalf("Can not create spec directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := parser.GenerateOptions{
+		Packages:   []string{ExamplePackageName},
+		MainFile:   exampleMainFile,
+		Format:     parser.FormatSwagger20,
+		OutputPath: filepath.Join(dir, "swagger.json"),
+	}
+	assert.Nil(t, parser.GenerateSpec(opts), "Can not generate Swagger 2.0 spec")
+	data, err := ioutil.ReadFile(opts.OutputPath)
+	assert.Nil(t, err, "Swagger 2.0 spec not written")
+	spec := parser.Swagger20Spec{}
+	assert.Nil(t, json.Unmarshal(data, &spec), "Swagger 2.0 spec is not valid JSON")
+	assert.Equal(t, "Swagger Example API", spec.Info.Title, "Swagger 2.0 spec not written")
+	assert.NotEmpty(t, spec.Paths, "Swagger 2.0 spec not written")
+
+	opts.Format = parser.FormatOpenAPI3
+	opts.Output = parser.OutputYaml
+	opts.OutputPath = filepath.Join(dir, "specs", "openapi.yaml")
+	assert.Nil(t, parser.GenerateSpec(opts), "Can not generate OpenAPI 3.0 spec")
+	data, err = ioutil.ReadFile(opts.OutputPath)
+	assert.Nil(t, err, "OpenAPI 3.0 spec not written")
+	assert.True(t, strings.HasPrefix(string(data), "openapi: \"3.0.3\"\n"), "OpenAPI 3.0 spec not written as YAML")
+
+	opts.Format = parser.FormatSwagger12
+	opts.Output = ""
+	opts.OutputPath = filepath.Join(dir, "apidocs")
+	assert.Nil(t, parser.GenerateSpec(opts), "Can not generate Swagger 1.2 spec")
+	_, err = os.Stat(filepath.Join(opts.OutputPath, parser.ResourceListingFileName))
+	assert.Nil(t, err, "Swagger 1.2 resource listing not written")
+
+	opts.Flatten = true
+	opts.OutputPath = filepath.Join(dir, "apidocs.json")
+	assert.Nil(t, parser.GenerateSpec(opts), "Can not generate flattened Swagger 1.2 spec")
+	data, err = ioutil.ReadFile(opts.OutputPath)
+	assert.Nil(t, err, "Flattened Swagger 1.2 spec not written")
+	api := parser.ApiDeclaration{}
+	assert.Nil(t, json.Unmarshal(data, &api), "Flattened Swagger 1.2 spec is not valid JSON")
+	assert.Equal(t, "/", api.ResourcePath, "Flattened Swagger 1.2 spec should be a single api declaration")
+	assert.NotEmpty(t, api.Apis, "Flattened Swagger 1.2 spec not written")
+
+	for name, invalidOpts := range map[string]parser.GenerateOptions{
+		"no packages":     {OutputPath: dir},
+		"no output path":  {Packages: opts.Packages},
+		"unknown format":  {Packages: opts.Packages, OutputPath: dir, Format: "raml"},
+		"unknown output":  {Packages: opts.Packages, OutputPath: dir, Format: parser.FormatSwagger20, Output: "xml"},
+		"yaml of 1.2":     {Packages: opts.Packages, OutputPath: dir, Output: parser.OutputYaml},
+		"no main file":    {Packages: opts.Packages, OutputPath: dir, MainFile: "missing/main.go"},
+		"missing package": {Packages: []string{"github.com/RobotsAndPencils/missing"}, OutputPath: dir},
+	} {
+		assert.NotNil(t, parser.GenerateSpec(invalidOpts), "Generating the spec with %s should fail", name)
+	}
+}
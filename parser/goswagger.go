@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The blocks of a go-swagger route comment, following its swagger:route or swagger:operation line
+const (
+	goSwaggerSummary     = "summary"     // the first paragraph
+	goSwaggerDescription = "description" // the following paragraphs, up to the first section
+	goSwaggerSpec        = "spec"        // the YAML following "---" in a swagger:operation comment, which is skipped
+)
+
+// goSwaggerSections are the sections of a go-swagger route comment, e.g. "Responses:", by their lowercase name
+var goSwaggerSections = map[string]bool{
+	"consumes":   true,
+	"produces":   true,
+	"schemes":    true,
+	"security":   true,
+	"parameters": true,
+	"responses":  true,
+	"deprecated": true,
+	"extensions": true,
+}
+
+// goSwaggerSectionPattern matches the first line of a section of a go-swagger route comment, e.g. "Deprecated: true"
+var goSwaggerSectionPattern = regexp.MustCompile(`^(\w+):\s*(.*)$`)
+
+// hasGoSwaggerRoute treats any function with a swagger:route or swagger:operation comment as a controller
+func hasGoSwaggerRoute(funcDeclaration *ast.FuncDecl) bool {
+	if funcDeclaration.Doc == nil {
+		return false
+	}
+	for _, comment := range funcDeclaration.Doc.List {
+		if isGoSwaggerRoute(strings.TrimSpace(strings.TrimLeft(comment.Text, "//"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func isGoSwaggerRoute(commentLine string) bool {
+	marker := strings.ToLower(strings.Split(commentLine, " ")[0])
+	return marker == "swagger:route" || marker == "swagger:operation"
+}
+
+// parseGoSwaggerComment parses a line of a go-swagger route comment, and reports whether the line belongs to one.
+// The comment starts with its swagger:route or swagger:operation line, and ends with the first annotation
+//
+//	swagger:route GET /pets/{id} pets getPet
+//
+//	Gets a pet.
+//
+//	The pet is looked up by its id.
+//
+//	Produces:
+//	- application/json
+//
+//	Responses:
+//	  200: petResponse
+//	  404: notFound
+func (operation *Operation) parseGoSwaggerComment(commentLine string) (bool, error) {
+	if isGoSwaggerRoute(commentLine) {
+		operation.goSwaggerBlock = goSwaggerSummary
+		return true, operation.ParseGoSwaggerRouteComment(commentLine)
+	}
+	if operation.goSwaggerBlock == "" {
+		return false, nil
+	}
+	if _, prefix := NormalizeAnnotation(commentLine, operation.parser.AnnotationPrefixes); prefix != "" {
+		operation.goSwaggerBlock = ""
+		return false, nil
+	}
+	if operation.goSwaggerBlock == goSwaggerSpec {
+		return true, nil
+	}
+	if commentLine == "---" {
+		operation.goSwaggerBlock = goSwaggerSpec
+		return true, nil
+	}
+
+	if matches := goSwaggerSectionPattern.FindStringSubmatch(commentLine); matches != nil && goSwaggerSections[strings.ToLower(matches[1])] {
+		operation.goSwaggerBlock = strings.ToLower(matches[1])
+		if operation.goSwaggerBlock == "deprecated" {
+			if deprecated, _ := strconv.ParseBool(matches[2]); deprecated {
+				operation.Deprecated = "true"
+			}
+		}
+		return true, nil
+	}
+
+	switch operation.goSwaggerBlock {
+	case goSwaggerSummary:
+		if commentLine == "" {
+			if operation.Summary != "" {
+				operation.goSwaggerBlock = goSwaggerDescription
+			}
+		} else {
+			operation.Summary = strings.TrimSpace(operation.Summary + " " + commentLine)
+		}
+	case goSwaggerDescription:
+		operation.appendNotesLine(commentLine)
+	case "consumes", "produces":
+		contentType := strings.TrimSpace(strings.TrimPrefix(commentLine, "-"))
+		if contentType == "" {
+			break
+		}
+		if operation.goSwaggerBlock == "consumes" {
+			return true, operation.ParseAcceptComment("@Accept " + contentType)
+		}
+		return true, operation.ParseProduceComment("@Produce " + contentType)
+	case "responses":
+		if commentLine != "" {
+			return true, operation.ParseGoSwaggerResponseComment(commentLine)
+		}
+	}
+	return true, nil
+}
+
+// swagger:route GET /pets/{id} pets getPet
+//
+//	[method] [path] [tags, optional] [operation id]
+func (operation *Operation) ParseGoSwaggerRouteComment(commentLine string) error {
+	fields := strings.Fields(commentLine)
+	if len(fields) < 4 {
+		return fmt.Errorf("Can not parse go-swagger route comment \"%s\", expected method, path and operation id.", commentLine)
+	}
+	if err := operation.ParseRouterComment(fmt.Sprintf("@Router %s [%s]", fields[2], fields[1])); err != nil {
+		return err
+	}
+	operation.OperationId = fields[len(fields)-1]
+	if operation.Nickname == "" {
+		operation.Nickname = operation.OperationId
+	}
+	if tags := fields[3 : len(fields)-1]; len(tags) > 0 {
+		operation.Tags = append(operation.Tags, tags...)
+		operation.NormalizeTags()
+	}
+	return nil
+}
+
+// 200: petResponse
+//
+// The responses of go-swagger are declared apart from their models, by swagger:response, so only their code
+// and name are kept. The default response has no code in Swagger 1.2, and is skipped
+func (operation *Operation) ParseGoSwaggerResponseComment(commentLine string) error {
+	fields := strings.SplitN(commentLine, ":", 2)
+	if len(fields) != 2 {
+		return fmt.Errorf("Can not parse go-swagger response comment \"%s\", expected code: response.", commentLine)
+	}
+	codeText := strings.TrimSpace(fields[0])
+	if codeText == "default" {
+		return nil
+	}
+	code, err := strconv.Atoi(codeText)
+	if err != nil {
+		return fmt.Errorf("Can not parse go-swagger response comment \"%s\", http code must be int.", commentLine)
+	}
+	operation.ResponseMessages = append(operation.ResponseMessages, ResponseMessage{Code: code, Message: strings.TrimSpace(fields[1])})
+	return nil
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSONSchemaDraft202012 is the meta-schema of the documents ExportJSONSchema writes
+const JSONSchemaDraft202012 = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchemaDocument is a standalone JSON schema of a model, with the models it references as its $defs
+type JSONSchemaDocument struct {
+	MetaSchema string `json:"$schema"`
+	Title      string `json:"title,omitempty"`
+	*Schema
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// ExportJSONSchema writes the model of a type as a JSON schema (draft 2020-12) document, e.g. to validate requests
+// outside of Swagger. The type is resolved as for @Success, and its fields are documented the same way, without
+// the keywords JSON Schema does not know, like xml or x-nullable.
+// In LibraryMode a type which can not be found is returned as a *FatalError
+func (parser *Parser) ExportJSONSchema(modelName string, packageName string) (data []byte, err error) {
+	defer recoverFatal(&err)
+	const refPrefix = "#/$defs/"
+
+	currentPackage := parser.CurrentPackage
+	defer func() {
+		parser.CurrentPackage = currentPackage
+	}()
+
+	model := NewModel(parser)
+	err, innerModels := model.parseModel(modelName, packageName, map[string]bool{})
+	if err != nil {
+		return nil, fmt.Errorf("Can not export JSON schema of %s: %v", modelName, err)
+	}
+
+	document := &JSONSchemaDocument{
+		MetaSchema: JSONSchemaDraft202012,
+		Title:      model.Id,
+		Schema:     parser.modelSchema(model, refPrefix).withoutSwaggerKeywords(),
+	}
+	for _, innerModel := range innerModels {
+		if innerModel.Id == model.Id {
+			continue
+		}
+		if document.Defs == nil {
+			document.Defs = make(map[string]*Schema)
+		}
+		if _, ok := document.Defs[innerModel.Id]; !ok {
+			document.Defs[innerModel.Id] = parser.modelSchema(innerModel, refPrefix).withoutSwaggerKeywords()
+		}
+	}
+
+	if data, err = json.MarshalIndent(document, "", "    "); err != nil {
+		return nil, fmt.Errorf("Can not serialise JSON schema of %s: %v", modelName, err)
+	}
+	return data, nil
+}
+
+// withoutSwaggerKeywords drops the keywords of the schema and its inner schemas which only Swagger and OpenAPI know
+func (schema *Schema) withoutSwaggerKeywords() *Schema {
+	schema.XML = nil
+	schema.Nullable = false
+	schema.OpenAPINullable = false
+	schema.Tags = nil
+	schema.EnumVarNames = nil
+	schema.Discriminator = nil
+	schema.Example = nil
+	for _, property := range schema.Properties {
+		property.withoutSwaggerKeywords()
+	}
+	for _, schemas := range [][]*Schema{schema.AllOf, schema.OneOf, schema.AnyOf, {schema.Items, schema.AdditionalProperties}} {
+		for _, inner := range schemas {
+			if inner != nil {
+				inner.withoutSwaggerKeywords()
+			}
+		}
+	}
+	return schema
+}
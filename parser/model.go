@@ -1,11 +1,13 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/ast"
-	"log"
 	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -13,7 +15,29 @@ type Model struct {
 	Id         string                    `json:"id"`
 	Required   []string                  `json:"required,omitempty"`
 	Properties map[string]*ModelProperty `json:"properties"`
-	parser     *Parser
+	Tags       []string                  `json:"x-tags,omitempty"`
+	XML        *XMLObject                `json:"xml,omitempty"` // only set by EnableXML
+	Composition
+	// the property telling the subtypes of the model apart, from @Discriminator, and their ids, from @SubType
+	Discriminator string          `json:"discriminator,omitempty"`
+	SubTypes      []string        `json:"subTypes,omitempty"`
+	Schema        json.RawMessage `json:"-"` // replaces the whole model when set by @Schema
+	parser        *Parser
+	// the required properties from @Required, which replace the ones inferred from the fields
+	requiredOverride []string
+	typeArgs         map[string]string
+	// models referenced by the fields promoted from embedded structs
+	promotedModels []*Model
+	seenPrefixes   annotationPrefixTracker
+	xml            *XMLObject // from the xml tag of an XMLName field
+	xmlEnabled     bool       // see EnableXML
+}
+
+// XMLObject describes how a model or a property is written in XML, from the xml struct tags
+type XMLObject struct {
+	Name      string `json:"name,omitempty"`
+	Attribute bool   `json:"attribute,omitempty"`
+	Wrapped   bool   `json:"wrapped,omitempty"`
 }
 
 func NewModel(p *Parser) *Model {
@@ -22,149 +46,557 @@ func NewModel(p *Parser) *Model {
 	}
 }
 
-// modelName is something like package.subpackage.SomeModel or just "subpackage.SomeModel"
-func (m *Model) ParseModel(modelName string, currentPackage string, knownModelNames map[string]bool) (error, []*Model) {
-	knownModelNames[modelName] = true
-	//log.Printf("Before parse model |%s|, package: |%s|\n", modelName, currentPackage)
+// modelName is something like package.subpackage.SomeModel or just "subpackage.SomeModel".
+// knownModelNames holds the models already parsed or being parsed, by their qualified type names. The fields
+// of their types reference them by id instead of parsing them again, which ends the recursion of self-referential
+// models like `type TreeNode struct { Children []TreeNode }`
+func (m *Model) ParseModel(modelName string, currentPackage string, knownModelNames map[string]bool) (err error, models []*Model) {
+	defer recoverFatal(&err)
+	return m.parseModel(modelName, currentPackage, knownModelNames)
+}
 
-	astTypeSpec, modelPackage := m.parser.FindModelDefinition(modelName, currentPackage)
+func (m *Model) parseModel(modelName string, currentPackage string, knownModelNames map[string]bool) (error, []*Model) {
+	knownModelNames[m.parser.qualifyTypeName(modelName, currentPackage)] = true
+	m.parser.debugf("Parse model %s of package %s\n", modelName, currentPackage)
 
-	modelNameParts := strings.Split(modelName, ".")
-	m.Id = strings.Join(append(strings.Split(modelPackage, "/"), modelNameParts[len(modelNameParts)-1]), ".")
+	baseModelName, typeArgs := SplitGenericModelName(modelName)
+	astTypeSpec, modelPackage := m.parser.findModelDefinition(baseModelName, currentPackage)
+
+	typeParams := TypeParamNames(astTypeSpec)
+	if len(typeParams) != len(typeArgs) {
+		return fmt.Errorf("Generic model %s has %d type parameters, but %d type arguments given", modelName, len(typeParams), len(typeArgs)), nil
+	}
+	if len(typeParams) > 0 {
+		// The type arguments are written in currentPackage, while the fields of the generic type are parsed in modelPackage
+		m.typeArgs = make(map[string]string)
+		for i, typeParam := range typeParams {
+			typeArgs[i] = m.parser.qualifyTypeName(typeArgs[i], currentPackage)
+			m.typeArgs[typeParam] = typeArgs[i]
+		}
+	}
+
+	// the type may be found through an alias, the model is named after the type itself
+	m.Id = m.parser.ModelName(modelPackage, astTypeSpec.Name.Name+genericModelNameSuffix(typeArgs, modelPackage))
+	qualifiedModelName := modelPackage + "." + astTypeSpec.Name.Name
+	if len(typeArgs) > 0 {
+		qualifiedModelName += "[" + strings.Join(typeArgs, ",") + "]"
+	}
+	m.parser.recordModelName(m.Id, qualifiedModelName)
+	knownModelNames[qualifiedModelName] = true
 
-	var innerModelList []*Model
+	if astTypeSpec.Doc != nil {
+		for _, comment := range astTypeSpec.Doc.List {
+			if err := m.ParseComment(astTypeSpec.Name.Name, comment.Text); err != nil {
+				return fmt.Errorf("%v: %v", m.parser.fileSet.Position(comment.Pos()), err), nil
+			}
+		}
+	}
+
+	composedModels, err := m.parseComposedModels(modelPackage, knownModelNames)
+	if err != nil {
+		return err, nil
+	}
+	if len(m.SubTypes) > 0 && m.Discriminator == "" {
+		return fmt.Errorf("Model %s has subtypes, but no @Discriminator", modelName), nil
+	}
+
+	qualifiedTypeName := m.parser.checkRealPackagePath(modelPackage) + "." + astTypeSpec.Name.Name
+	if schema, ok := m.parser.ModelSchemas[qualifiedTypeName]; ok {
+		m.Schema = schema
+		return nil, composedModels
+	}
+
+	// the fields of a type with its own MarshalJSON say nothing about its JSON, so it is left a generic object
+	if m.parser.IsImplementMarshalInterface(qualifiedTypeName) {
+		m.Properties = make(map[string]*ModelProperty)
+		return nil, composedModels
+	}
+
+	innerModelList := composedModels
 	if astStructType, ok := astTypeSpec.Type.(*ast.StructType); ok {
-		m.ParseFieldList(astStructType.Fields.List, modelPackage)
+		m.parseFieldList(astStructType.Fields.List, modelPackage)
+		if err := m.overrideRequired(); err != nil {
+			return err, nil
+		}
+		if err := m.requireDiscriminator(); err != nil {
+			return err, nil
+		}
 		usedTypes := make(map[string]bool)
+		knownTypes := make(map[string]bool)
+
+		// promoted fields already reference the ids of the models parsed along with their embedded struct
+		promotedModelIds := make(map[string]bool)
+		for _, promotedModel := range m.promotedModels {
+			promotedModelIds[promotedModel.Id] = true
+		}
 
 		for _, property := range m.Properties {
 			typeName := property.Type
-			if typeName == "array" {
-				if property.Items.Type != "" {
-					typeName = property.Items.Type
+			if items := property.Innermost(); items != nil {
+				if items.Type != "" {
+					typeName = items.Type
 				} else {
-					typeName = property.Items.Ref
+					typeName = items.Ref
 				}
 			}
-			if IsBasicType(typeName) || m.parser.IsImplementMarshalInterface(typeName) {
+			if IsBasicType(typeName) {
+				continue
+			}
+			if _, exists := promotedModelIds[typeName]; exists {
 				continue
 			}
-			if _, exists := knownModelNames[typeName]; exists {
+			if _, exists := knownModelNames[m.parser.qualifyTypeName(typeName, modelPackage)]; exists {
+				knownTypes[typeName] = true
 				continue
 			}
 
 			usedTypes[typeName] = true
 		}
 
-		//log.Printf("Before parse inner model list: %#v\n (%s)", usedTypes, modelName)
-		innerModelList = make([]*Model, 0, len(usedTypes))
+		for typeName := range knownTypes {
+			m.referenceModel(typeName, m.parser.modelId(typeName, modelPackage))
+		}
+
+		innerModelList = append(innerModelList, m.promotedModels...)
 
 		for typeName, _ := range usedTypes {
 			typeModel := NewModel(m.parser)
-			if err, typeInnerModels := typeModel.ParseModel(typeName, modelPackage, knownModelNames); err != nil {
-				//log.Printf("Parse Inner Model error %#v \n", err)
+			if err, typeInnerModels := typeModel.parseModel(typeName, modelPackage, knownModelNames); err != nil {
 				return err, nil
 			} else {
-				for _, property := range m.Properties {
-					if property.Type == "array" {
-						if property.Items.Ref == typeName {
-							property.Items.Ref = typeModel.Id
-						}
-					} else {
-						if property.Type == typeName {
-							property.Type = typeModel.Id
-						}
-					}
-				}
-				//log.Printf("Inner model %v parsed, parsing %s \n", typeName, modelName)
+				m.referenceModel(typeName, typeModel.Id)
 				if typeModel != nil {
 					innerModelList = append(innerModelList, typeModel)
 				}
 				if typeInnerModels != nil && len(typeInnerModels) > 0 {
 					innerModelList = append(innerModelList, typeInnerModels...)
 				}
-				//log.Printf("innerModelList: %#v\n, typeInnerModels: %#v, usedTypes: %#v \n", innerModelList, typeInnerModels, usedTypes)
 			}
 		}
-		//log.Printf("After parse inner model list: %#v\n (%s)", usedTypes, modelName)
-		// log.Fatalf("Inner model list: %#v\n", innerModelList)
-
 	}
 
-	//log.Printf("ParseModel finished %s \n", modelName)
 	return nil, innerModelList
 }
 
+// overrideRequired replaces the required properties with the ones listed by @Required, after checking they exist
+func (m *Model) overrideRequired() error {
+	if m.requiredOverride == nil {
+		return nil
+	}
+	for _, required := range m.requiredOverride {
+		if _, ok := m.Properties[required]; !ok {
+			return fmt.Errorf("Required property %s of model %s is not one of its properties", required, m.Id)
+		}
+	}
+	m.Required = m.requiredOverride
+	return nil
+}
+
+// requireDiscriminator checks the discriminator is a property of the model, and makes it required,
+// as every instance of a subtype must tell its type
+func (m *Model) requireDiscriminator() error {
+	if m.Discriminator == "" {
+		return nil
+	}
+	if _, ok := m.Properties[m.Discriminator]; !ok {
+		return fmt.Errorf("Discriminator %s of model %s is not one of its properties", m.Discriminator, m.Id)
+	}
+	for _, required := range m.Required {
+		if required == m.Discriminator {
+			return nil
+		}
+	}
+	m.Required = append(m.Required, m.Discriminator)
+	return nil
+}
+
+// referenceModel replaces the type name of the properties of the type with the id of its model
+func (m *Model) referenceModel(typeName string, modelId string) {
+	for _, property := range m.Properties {
+		if items := property.Innermost(); items != nil {
+			if items.Ref == typeName {
+				items.Ref = modelId
+			}
+		} else {
+			if property.Type == typeName {
+				property.Type = modelId
+			}
+		}
+	}
+}
+
+// modelId returns the id ParseModel gives the model of a type, without parsing it
+func (parser *Parser) modelId(modelName string, currentPackage string) string {
+	baseModelName, typeArgs := SplitGenericModelName(modelName)
+	astTypeSpec, modelPackage := parser.findModelDefinition(baseModelName, currentPackage)
+	for i, typeArg := range typeArgs {
+		typeArgs[i] = parser.qualifyTypeName(typeArg, currentPackage)
+	}
+	return parser.ModelName(modelPackage, astTypeSpec.Name.Name+genericModelNameSuffix(typeArgs, modelPackage))
+}
+
+// MarshalJSON writes the @Schema of the model verbatim, if it has one. Only its id is set to the model id,
+// which the Swagger 1.2 references resolve to
+func (m *Model) MarshalJSON() ([]byte, error) {
+	if m.Schema != nil {
+		var schema map[string]interface{}
+		if err := json.Unmarshal(m.Schema, &schema); err != nil {
+			return nil, err
+		}
+		if schema["id"] == m.Id {
+			return m.Schema, nil
+		}
+		schema["id"] = m.Id
+		return json.Marshal(schema)
+	}
+	type model Model
+	return json.Marshal((*model)(m))
+}
+
+// EnableXML emits the xml objects of the model and its properties, which are only of use to the operations producing XML.
+// The model may be shared by operations which do not, see WithXML
+func (m *Model) EnableXML() {
+	m.XML = m.xml
+	m.xmlEnabled = true
+	for _, property := range m.Properties {
+		property.XML = property.xml
+		if property.Type == "array" {
+			property.Items.XML = property.itemsXML
+		}
+	}
+}
+
+// WithXML returns a copy of the model with its xml objects emitted, see EnableXML, and leaves the model as it is.
+// A model without xml struct tags is returned itself
+func (m *Model) WithXML() *Model {
+	if m.xmlEnabled || !m.hasXML() {
+		return m
+	}
+	model := *m
+	model.Properties = make(map[string]*ModelProperty, len(m.Properties))
+	for name, property := range m.Properties {
+		propertyCopy := *property
+		model.Properties[name] = &propertyCopy
+	}
+	model.EnableXML()
+	return &model
+}
+
+// hasXML reports whether the model or one of its properties has an xml object, from the xml struct tags
+func (m *Model) hasXML() bool {
+	if m.xml != nil {
+		return true
+	}
+	for _, property := range m.Properties {
+		if property.xml != nil || property.itemsXML != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseXMLTag parses the xml struct tag of a field into the xml objects of its property and,
+// for a wrapped array like `xml:"lines>line"`, of its items
+func ParseXMLTag(tag string) (*XMLObject, *XMLObject) {
+	tagValues := strings.Split(tag, ",")
+	name := tagValues[0]
+	if name == "-" {
+		return nil, nil
+	}
+	if elements := strings.Split(name, ">"); len(elements) > 1 {
+		return &XMLObject{Name: elements[0], Wrapped: true}, &XMLObject{Name: elements[len(elements)-1]}
+	}
+	xmlObject := &XMLObject{Name: name}
+	for _, option := range tagValues[1:] {
+		if option == "attr" {
+			xmlObject.Attribute = true
+		}
+	}
+	if xmlObject.Name == "" && !xmlObject.Attribute {
+		return nil, nil
+	}
+	return xmlObject, nil
+}
+
+// ParseComment parses a line of the doc comment of the type declaration named typeName
+func (m *Model) ParseComment(typeName string, comment string) error {
+	if m.seenPrefixes == nil {
+		m.seenPrefixes = make(annotationPrefixTracker)
+	}
+	commentLine, attribute := m.parser.parseAnnotation(strings.TrimSpace(strings.TrimLeft(comment, "//")), m.seenPrefixes)
+	switch attribute {
+	case "@modeltag":
+		if err := m.ParseTagComment(typeName, commentLine); err != nil {
+			return err
+		}
+	case "@allof", "@oneof", "@anyof":
+		if err := m.ParseCompositionComment(typeName, commentLine); err != nil {
+			return err
+		}
+	case "@discriminator":
+		if err := m.ParseDiscriminatorComment(commentLine); err != nil {
+			return err
+		}
+	case "@subtype", "@subtypes":
+		if err := m.ParseSubTypeComment(commentLine); err != nil {
+			return err
+		}
+	case "@required":
+		if err := m.ParseRequiredComment(commentLine); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// @Discriminator petType
+func (m *Model) ParseDiscriminatorComment(commentLine string) error {
+	fields := strings.Fields(commentLine)
+	if len(fields) != 2 {
+		return fmt.Errorf("Can not parse discriminator comment \"%s\", expected the name of a property.", commentLine)
+	}
+	m.Discriminator = fields[1]
+	return nil
+}
+
+// @Required id,name
+func (m *Model) ParseRequiredComment(commentLine string) error {
+	fields := strings.Fields(commentLine)
+	if len(fields) < 2 {
+		return fmt.Errorf("Can not parse required comment \"%s\", expected property names.", commentLine)
+	}
+	for _, name := range strings.Split(strings.Join(fields[1:], ""), ",") {
+		if name != "" {
+			m.requiredOverride = append(m.requiredOverride, name)
+		}
+	}
+	return nil
+}
+
+// @SubType Dog
+// @SubTypes Cat, Dog
+func (m *Model) ParseSubTypeComment(commentLine string) error {
+	fields := strings.Fields(commentLine)
+	if len(fields) < 2 {
+		return fmt.Errorf("Can not parse subtype comment \"%s\", expected subtype models.", commentLine)
+	}
+	m.SubTypes = append(m.SubTypes, SplitModelList(strings.Join(fields[1:], ""))...)
+	return nil
+}
+
+// @AllOf Dog Animal,Pet
+func (m *Model) ParseCompositionComment(typeName string, commentLine string) error {
+	fields := strings.Fields(commentLine)
+	if len(fields) < 3 {
+		return fmt.Errorf("Can not parse composition comment \"%s\", expected model name and composed models.", commentLine)
+	}
+	if fields[1] != typeName {
+		return fmt.Errorf("Composition comment \"%s\" is declared on type %s", commentLine, typeName)
+	}
+	modelNames := SplitModelList(strings.Join(fields[2:], ""))
+	switch strings.ToLower(fields[0]) {
+	case "@allof":
+		m.AllOf = append(m.AllOf, modelNames...)
+	case "@oneof":
+		m.OneOf = append(m.OneOf, modelNames...)
+	case "@anyof":
+		m.AnyOf = append(m.AnyOf, modelNames...)
+	}
+	return nil
+}
+
+// ParseComposedModels parses the models listed by the composition and subtype comments of m and replaces their names
+// with the model ids. The models already known, e.g. a base model listing the subtype composed of it, are not parsed again
+func (m *Model) ParseComposedModels(modelPackage string, knownModelNames map[string]bool) (models []*Model, err error) {
+	defer recoverFatal(&err)
+	return m.parseComposedModels(modelPackage, knownModelNames)
+}
+
+func (m *Model) parseComposedModels(modelPackage string, knownModelNames map[string]bool) ([]*Model, error) {
+	var composedModels []*Model
+	for _, modelNames := range []*[]string{&m.AllOf, &m.OneOf, &m.AnyOf, &m.SubTypes} {
+		for i, modelName := range *modelNames {
+			if knownModelNames[m.parser.qualifyTypeName(modelName, modelPackage)] {
+				(*modelNames)[i] = m.parser.modelId(modelName, modelPackage)
+				continue
+			}
+			composedModel := NewModel(m.parser)
+			err, innerModels := composedModel.parseModel(modelName, modelPackage, knownModelNames)
+			if err != nil {
+				return nil, err
+			}
+			(*modelNames)[i] = composedModel.Id
+			composedModels = append(composedModels, composedModel)
+			composedModels = append(composedModels, innerModels...)
+		}
+	}
+	return composedModels, nil
+}
+
+// @ModelTag User identity,account
+func (m *Model) ParseTagComment(typeName string, commentLine string) error {
+	fields := strings.Fields(commentLine[len("@ModelTag"):])
+	if len(fields) < 2 {
+		return fmt.Errorf("Can not parse model tag comment \"%s\", expected model name and tags.", commentLine)
+	}
+	if fields[0] != typeName {
+		return fmt.Errorf("Model tag comment \"%s\" is declared on type %s", commentLine, typeName)
+	}
+	for _, tag := range strings.Split(strings.Join(fields[1:], ","), ",") {
+		if tag = strings.TrimSpace(tag); tag == "" {
+			continue
+		}
+		isExists := false
+		for _, existTag := range m.Tags {
+			if existTag == tag {
+				isExists = true
+				break
+			}
+		}
+		if !isExists {
+			m.Tags = append(m.Tags, tag)
+		}
+	}
+	return nil
+}
+
 func (m *Model) ParseFieldList(fieldList []*ast.Field, modelPackage string) {
+	defer m.parser.collectFatal()
+	m.parseFieldList(fieldList, modelPackage)
+}
+
+func (m *Model) parseFieldList(fieldList []*ast.Field, modelPackage string) {
 	if fieldList == nil {
 		return
 	}
-	//log.Printf("ParseFieldList\n")
 
 	m.Properties = make(map[string]*ModelProperty)
 	for _, field := range fieldList {
-		m.ParseModelProperty(field, modelPackage)
+		if !isFlattened(field) {
+			m.parseModelProperty(field, modelPackage)
+		}
+	}
+	// Fields promoted from embedded structs never override the fields declared directly
+	for _, field := range fieldList {
+		if isFlattened(field) {
+			m.parseModelProperty(field, modelPackage)
+		}
 	}
 }
 
+// isFlattened reports whether the field is embedded without a name in its json tag. Like encoding/json, only
+// the fields of such an embedded struct are promoted, one with a name, e.g. `json:"base"`, is a nested object
+func isFlattened(field *ast.Field) bool {
+	return len(field.Names) == 0 && jsonTagName(field) == ""
+}
+
+// jsonTagName returns the name given to the field by its json tag, or "" if it has none
+func jsonTagName(field *ast.Field) string {
+	if field.Tag == nil {
+		return ""
+	}
+	tagName := strings.Split(reflect.StructTag(strings.Trim(field.Tag.Value, "`")).Get("json"), ",")[0]
+	if tagName == "required" || tagName == "omitempty" {
+		return ""
+	}
+	return tagName
+}
+
 func (m *Model) ParseModelProperty(field *ast.Field, modelPackage string) {
+	defer m.parser.collectFatal()
+	m.parseModelProperty(field, modelPackage)
+}
+
+func (m *Model) parseModelProperty(field *ast.Field, modelPackage string) {
 	var name string
 	var innerModel *Model
 
 	property := NewModelProperty()
 
-	typeAsString := property.GetTypeAsString(field.Type)
-	//log.Printf("Get type as string %s \n", typeAsString)
+	typeAsString := property.GetTypeAsString(m.substituteTypeParams(field.Type))
+	_, property.Nullable = field.Type.(*ast.StarExpr)
+
+	// Like encoding/xml, an XMLName field names the element of the model rather than being a property
+	if typeAsString == "xml.Name" && len(field.Names) > 0 && field.Names[0].Name == "XMLName" {
+		if field.Tag != nil {
+			m.xml, _ = ParseXMLTag(reflect.StructTag(strings.Trim(field.Tag.Value, "`")).Get("xml"))
+		}
+		return
+	}
 
 	// Sometimes reflection reports an object as "&{foo Bar}" rather than just "foo.Bar"
 	// The next 2 lines of code normalize them to foo.Bar
 	reInternalRepresentation := regexp.MustCompile("&\\{(\\w*) (\\w*)\\}")
 	typeAsString = string(reInternalRepresentation.ReplaceAll([]byte(typeAsString), []byte("$1.$2")))
+	typeAsString = m.parser.resolveType(typeAsString, modelPackage)
 
-	if strings.HasPrefix(typeAsString, "[]") {
+	if mapping, ok := m.parser.typeMapping(typeAsString, modelPackage); ok {
+		property.Type = mapping.Type
+		property.Format = mapping.Format
+	} else if strings.HasPrefix(typeAsString, "[]") {
 		property.Type = "array"
 		property.SetItemType(typeAsString[2:])
-	} else if typeAsString == "time.Time" {
-		property.Type = "Time"
+	} else if valueType, ok := SplitMapType(typeAsString); ok {
+		property.Type = "object"
+		property.AdditionalProperties = &ModelPropertyItems{}
+		property.AdditionalProperties.setType(valueType)
+	} else if underlyingType, enumValues, enumVarNames := m.parser.findEnumValues(typeAsString, modelPackage); enumValues != nil {
+		property.Type = underlyingType
+		property.Enum = enumValues
+		property.EnumVarNames = enumVarNames
 	} else {
 		property.Type = typeAsString
 	}
 
-	if len(field.Names) == 0 {
+	if items := property.Innermost(); items != nil && items.Ref != "" {
+		if mapping, ok := m.parser.typeMapping(items.Ref, modelPackage); ok {
+			items.Ref = ""
+			items.Type = mapping.Type
+			items.Format = mapping.Format
+		}
+	}
 
-		if astSelectorExpr, ok := field.Type.(*ast.SelectorExpr); ok {
-			packageName := modelPackage
-			if astTypeIdent, ok := astSelectorExpr.X.(*ast.Ident); ok {
-				packageName = astTypeIdent.Name
-			}
+	if len(field.Names) == 0 {
+		// Embedded type, possibly a pointer and/or from another package. Like encoding/json,
+		// the fields of an embedded struct are flattened into this model, unless its json tag names it
+		typeNameParts := strings.Split(strings.Split(typeAsString, "[")[0], ".")
+		name = typeNameParts[len(typeNameParts)-1]
 
-			name = packageName + "." + strings.TrimPrefix(astSelectorExpr.Sel.Name, "*")
-		} else if astTypeIdent, ok := field.Type.(*ast.Ident); ok {
-			name = astTypeIdent.Name
-		} else if astStarExpr, ok := field.Type.(*ast.StarExpr); ok {
-			if astIdent, ok := astStarExpr.X.(*ast.Ident); ok {
-				name = astIdent.Name
+		if !IsBasicType(typeAsString) && isFlattened(field) {
+			innerModel = NewModel(m.parser)
+			knownModelNames := map[string]bool{}
+			if err, innerModels := innerModel.parseModel(typeAsString, modelPackage, knownModelNames); err != nil {
+				m.parser.warnf("Can not parse embedded type %s, package: %s, got error: %v\n", typeAsString, modelPackage, err)
+				return
+			} else if innerModel.Properties != nil {
+				promotedFields := make(map[string]bool)
+				for innerFieldName, innerField := range innerModel.Properties {
+					if _, exists := m.Properties[innerFieldName]; exists {
+						continue
+					}
+					m.Properties[innerFieldName] = innerField
+					promotedFields[innerFieldName] = true
+				}
+				// the fields of a nil embedded pointer are omitted altogether
+				for _, required := range innerModel.Required {
+					if promotedFields[required] && !property.Nullable {
+						m.Required = append(m.Required, required)
+					}
+				}
+				m.promotedModels = append(m.promotedModels, innerModels...)
+				return
 			}
-		} else {
-			log.Fatalf("Something goes wrong: %#v", field.Type)
-		}
-		innerModel = NewModel(m.parser)
-		//log.Printf("Try to parse embeded type %s \n", name)
-		//log.Fatalf("DEBUG: field: %#v\n, selector.X: %#v\n selector.Sel: %#v\n", field, astSelectorExpr.X, astSelectorExpr.Sel)
-		knownModelNames := map[string]bool{}
-		innerModel.ParseModel(name, modelPackage, knownModelNames)
-
-		for innerFieldName, innerField := range innerModel.Properties {
-			m.Properties[innerFieldName] = innerField
 		}
-
-		//log.Fatalf("Here %#v\n", field.Type)
-		return
+		// Embedded non-struct types, and the ones named by their json tag, are documented as a field
+		// named after the type or the tag
 	} else {
 		name = field.Names[0].Name
 	}
 
-	//log.Printf("ParseModelProperty: %s, CurrentPackage %s, type: %s \n", name, modelPackage, property.Type)
+	property.Description = FieldDescription(field)
+	// Like encoding/json, a field is always written unless it is omitted when empty, or a nil pointer
+	isRequired := !property.Nullable
+	m.parseFieldAnnotations(field, property)
 	//Analyse struct fields annotations
 	if field.Tag != nil {
 		structTag := reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
@@ -176,40 +608,314 @@ func (m *Model) ParseModelProperty(field *ast.Field, modelPackage string) {
 			tagText = tag
 		}
 
+		// The first value is the field name as encoding/json sees it, the rest are options.
+		// A leading "required" or "omitempty" is treated as an option, as in `json:"required,omitempty"`
 		tagValues := strings.Split(tagText, ",")
-		var isRequired = false
 
-		for _, v := range tagValues {
-			if v != "" && v != "required" && v != "omitempty" {
-				name = v
+		// We will not document at all any fields with a json tag of "-"
+		if tagText == "-" {
+			return
+		}
+		if tagName := tagValues[0]; tagName != "" && tagName != "required" && tagName != "omitempty" {
+			name = tagName
+		}
+		for i, v := range tagValues {
+			if v == "omitempty" {
+				isRequired = false
+			}
+			// The ",string" option encodes numbers and booleans as JSON strings
+			if v == "string" && i > 0 && IsBasicType(property.Type) && property.Type != "string" {
+				property.Format = property.Type
+				property.Type = "string"
 			}
+		}
+		// A required option or tag overrides the above, e.g. for a pointer field which is never nil
+		for _, v := range tagValues {
 			if v == "required" {
 				isRequired = true
 			}
-			// We will not document at all any fields with a json tag of "-"
-			if v == "-" {
-				return
-			}
 		}
-		if required := structTag.Get("required"); required != "" || isRequired {
-			m.Required = append(m.Required, name)
+		if required := structTag.Get("required"); required != "" {
+			isRequired = required != "false"
 		}
 		if desc := structTag.Get("description"); desc != "" {
 			property.Description = desc
 		}
+		if exampleTag := structTag.Get("example"); exampleTag != "" {
+			if example, err := ParseExample(exampleTag, property.Type); err != nil {
+				m.parser.warnf("Can not parse example of field %s, package: %s, got error: %v\n", name, modelPackage, err)
+			} else {
+				property.Example = example
+			}
+		}
+		if xmlTag := structTag.Get("xml"); xmlTag != "" {
+			property.xml, property.itemsXML = ParseXMLTag(xmlTag)
+		}
+	}
+	if _, exists := m.Properties[name]; exists && len(field.Names) == 0 {
+		return
 	}
 	m.Properties[name] = property
+	if isRequired {
+		m.Required = append(m.Required, name)
+	}
+}
+
+// FieldDescription returns the doc comment above a struct field, or else its line comment, with the lines
+// joined into one. A description tag overrides it
+func FieldDescription(field *ast.Field) string {
+	comment := field.Doc
+	if comment == nil {
+		comment = field.Comment
+	}
+	if comment == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(comment.Text()), " ")
+}
+
+// parseFieldAnnotations applies the annotations of the doc or line comment of a field to its property, and leaves
+// them out of its description. @Nullable overrides whether the field is nullable, which pointer fields are, e.g.
+//
+//	// DeletedAt is only set once the order is deleted
+//	// @Nullable
+//	DeletedAt time.Time
+//
+// It does not make the field optional, and @Nullable false makes a pointer field which is never nil not nullable
+func (m *Model) parseFieldAnnotations(field *ast.Field, property *ModelProperty) {
+	comment := field.Doc
+	if comment == nil {
+		comment = field.Comment
+	}
+	if comment == nil {
+		return
+	}
+	descriptionLines := make([]string, 0)
+	hasAnnotations := false
+	for _, fieldComment := range comment.List {
+		for _, commentLine := range strings.Split(commentText(fieldComment), "\n") {
+			annotation, attribute := m.parser.parseAnnotation(strings.TrimSpace(commentLine), nil)
+			if attribute != "@nullable" {
+				descriptionLines = append(descriptionLines, commentLine)
+				continue
+			}
+			hasAnnotations = true
+			nullable := true
+			if value := strings.TrimSpace(annotation[len("@Nullable"):]); value != "" {
+				var err error
+				if nullable, err = strconv.ParseBool(value); err != nil {
+					m.parser.warnf("%v: Can not parse nullable comment \"%s\" of a field of model %s, expected true or false\n", m.parser.fileSet.Position(fieldComment.Pos()), annotation, m.Id)
+					continue
+				}
+			}
+			property.Nullable = nullable
+		}
+	}
+	if hasAnnotations {
+		property.Description = strings.Join(strings.Fields(strings.Join(descriptionLines, " ")), " ")
+	}
+}
+
+// substituteTypeParams replaces the type parameters of an instantiated generic model with its type arguments in a
+// field type expression. Only identifiers are replaced, so that the type of a package named like a type parameter,
+// e.g. pkg.T, is kept
+func (m *Model) substituteTypeParams(fieldType ast.Expr) ast.Expr {
+	if len(m.typeArgs) == 0 {
+		return fieldType
+	}
+	switch astType := fieldType.(type) {
+	case *ast.Ident:
+		if typeArg, ok := m.typeArgs[astType.Name]; ok {
+			return ast.NewIdent(typeArg)
+		}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: astType.Len, Elt: m.substituteTypeParams(astType.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: m.substituteTypeParams(astType.Key), Value: m.substituteTypeParams(astType.Value)}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: m.substituteTypeParams(astType.X)}
+	case *ast.ParenExpr:
+		return &ast.ParenExpr{X: m.substituteTypeParams(astType.X)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: astType.X, Index: m.substituteTypeParams(astType.Index)}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, 0, len(astType.Indices))
+		for _, index := range astType.Indices {
+			indices = append(indices, m.substituteTypeParams(index))
+		}
+		return &ast.IndexListExpr{X: astType.X, Indices: indices}
+	}
+	return fieldType
+}
+
+// TypeParamNames returns the names of the type parameters of a generic type declaration, in order
+func TypeParamNames(typeSpec *ast.TypeSpec) []string {
+	var names []string
+	if typeSpec == nil || typeSpec.TypeParams == nil {
+		return names
+	}
+	for _, field := range typeSpec.TypeParams.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+// SplitGenericModelName splits an instantiated generic model name like "Page[User]" into "Page" and its type arguments
+func SplitGenericModelName(modelName string) (string, []string) {
+	start := strings.Index(modelName, "[")
+	if start == -1 || !strings.HasSuffix(modelName, "]") {
+		return modelName, nil
+	}
+	return modelName[:start], SplitModelList(modelName[start+1 : len(modelName)-1])
+}
+
+// SplitModelList splits a comma separated list of model names, keeping the commas
+// between the type arguments of a generic model, e.g. "Cat, Page[User,Role]"
+func SplitModelList(modelList string) []string {
+	var modelNames []string
+	depth := 0
+	nameStart := 0
+	for i := 0; i < len(modelList); i++ {
+		switch modelList[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				modelNames = append(modelNames, strings.TrimSpace(modelList[nameStart:i]))
+				nameStart = i + 1
+			}
+		}
+	}
+	return append(modelNames, strings.TrimSpace(modelList[nameStart:]))
+}
+
+// genericModelNameSuffix builds the name suffix of a concrete model, e.g. "User" for Page[User]
+func genericModelNameSuffix(typeArgs []string, modelPackage string) string {
+	reNonWord := regexp.MustCompile(`\W`)
+	suffix := ""
+	for _, typeArg := range typeArgs {
+		suffix += reNonWord.ReplaceAllString(typeArgName(typeArg, modelPackage), "")
+	}
+	return suffix
+}
+
+// typeArgName names a qualified type argument for the name of a concrete model. Types from another package
+// than the generic type are prefixed with their package name, e.g. "UsersUser" for users.User
+func typeArgName(typeArg string, modelPackage string) string {
+	if strings.HasPrefix(typeArg, "[]") {
+		return "Array" + typeArgName(typeArg[2:], modelPackage)
+	}
+	if valueType, ok := SplitMapType(typeArg); ok {
+		return "Map" + typeArgName(valueType, modelPackage)
+	}
+	baseName, typeArgs := SplitGenericModelName(typeArg)
+	name := baseName
+	if dot := strings.LastIndex(baseName, "."); dot != -1 {
+		name = baseName[dot+1:]
+		if typePackage := baseName[:dot]; typePackage != modelPackage {
+			packageName := typePackage[strings.LastIndex(typePackage, "/")+1:]
+			name = strings.ToUpper(packageName[:1]) + packageName[1:] + name
+		}
+	}
+	for _, innerTypeArg := range typeArgs {
+		name += typeArgName(innerTypeArg, modelPackage)
+	}
+	return name
 }
 
 type ModelProperty struct {
-	Type        string             `json:"type"`
-	Description string             `json:"description"`
-	Items       ModelPropertyItems `json:"items,omitempty"`
-	Format      string             `json:"format"`
+	Type                 string              `json:"type"`
+	Description          string              `json:"description"`
+	Items                ModelPropertyItems  `json:"items,omitempty"`
+	AdditionalProperties *ModelPropertyItems `json:"additionalProperties,omitempty"` // only set for maps
+	Format               string              `json:"format"`
+	Enum                 []string            `json:"enum,omitempty"`
+	Example              interface{}         `json:"example,omitempty"`
+	EnumVarNames         []string            `json:"x-enum-varnames,omitempty"` // names of the constants of Enum
+	Nullable             bool                `json:"x-nullable,omitempty"`      // pointer fields, which encode nil as null, or from @Nullable
+	XML                  *XMLObject          `json:"xml,omitempty"`             // only set by EnableXML
+	xml                  *XMLObject
+	itemsXML             *XMLObject
+}
+
+// MarshalJSON writes the enum values in the type of the property, e.g. those of an integer enum as numbers
+func (p *ModelProperty) MarshalJSON() ([]byte, error) {
+	type modelProperty ModelProperty
+	return json.Marshal(struct {
+		*modelProperty
+		Enum []interface{} `json:"enum,omitempty"`
+	}{(*modelProperty)(p), enumValues(p.Enum, p.Type)})
 }
+
 type ModelPropertyItems struct {
-	Ref  string `json:"$ref,omitempty"`
-	Type string `json:"type,omitempty"`
+	Ref                  string              `json:"$ref,omitempty"`
+	Type                 string              `json:"type,omitempty"`
+	Format               string              `json:"format,omitempty"`
+	Items                *ModelPropertyItems `json:"items,omitempty"`                // only set when Type is "array"
+	AdditionalProperties *ModelPropertyItems `json:"additionalProperties,omitempty"` // only set for maps
+	XML                  *XMLObject          `json:"xml,omitempty"`
+}
+
+// Innermost returns the items of the most deeply nested array or map value of the property,
+// e.g. the User items of [][]User or map[string][]User, or nil if the property is neither
+func (p *ModelProperty) Innermost() *ModelPropertyItems {
+	if p.Type == "array" {
+		return p.Items.Innermost()
+	}
+	if p.AdditionalProperties != nil {
+		return p.AdditionalProperties.Innermost()
+	}
+	return nil
+}
+
+// Innermost returns the items of the most deeply nested array or map value, e.g. the User items of [][]User
+func (items *ModelPropertyItems) Innermost() *ModelPropertyItems {
+	if items.Type == "array" && items.Items != nil {
+		return items.Items.Innermost()
+	}
+	if items.AdditionalProperties != nil {
+		return items.AdditionalProperties.Innermost()
+	}
+	return items
+}
+
+func (items *ModelPropertyItems) setType(itemType string) {
+	if strings.HasPrefix(itemType, "[]") {
+		items.Type = "array"
+		items.Items = &ModelPropertyItems{}
+		items.Items.setType(itemType[2:])
+	} else if valueType, ok := SplitMapType(itemType); ok {
+		items.Type = "object"
+		items.AdditionalProperties = &ModelPropertyItems{}
+		items.AdditionalProperties.setType(valueType)
+	} else if IsBasicType(itemType) {
+		items.Type = itemType
+	} else {
+		items.Ref = itemType
+	}
+}
+
+// SplitMapType returns the value type of a map type name, e.g. "[]User" for "map[string][]User"
+func SplitMapType(typeName string) (string, bool) {
+	if !strings.HasPrefix(typeName, "map[") {
+		return "", false
+	}
+	depth := 0
+	for i := len("map"); i < len(typeName); i++ {
+		switch typeName[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				return typeName[i+1:], true
+			}
+		}
+	}
+	return "", false
 }
 
 func NewModelProperty() *ModelProperty {
@@ -238,7 +944,71 @@ var basicTypes = map[string]bool{
 	"rune":       true,
 	"uintptr":    true,
 	"error":      true,
-	"Time":       true,
+	// Swagger primitive types, which well known types are mapped to
+	"integer": true,
+	"number":  true,
+	"boolean": true,
+	"object":  true,
+}
+
+// TypeMapping is the Swagger type and format a Go type is documented as
+type TypeMapping struct {
+	Type   string
+	Format string
+}
+
+// DefaultTypeMappings returns the well known types which are documented as Swagger primitives
+// rather than as models, by the import path of their package and their name, see typeMapping
+func DefaultTypeMappings() map[string]TypeMapping {
+	return map[string]TypeMapping{
+		"time.Time":                {Type: "string", Format: "date-time"},
+		"time.Duration":            {Type: "integer", Format: "int64"},
+		"encoding/json.RawMessage": {Type: "object"},
+		"[]byte":                   {Type: "string", Format: "byte"},
+		"uuid.UUID":                {Type: "string", Format: "uuid"},
+	}
+}
+
+// typeMapping returns the mapping of a type name written in currentPackage. The package of the type is resolved
+// through the imports of currentPackage, so that an aliased import, e.g. t.Time for t "time", or a dot import,
+// e.g. Time for . "time", finds the mapping. The mapping is looked up by the import path of the package and the
+// type name, e.g. "encoding/json.RawMessage", or else by the conventional name of the package, e.g. "uuid.UUID"
+// for any uuid package
+func (parser *Parser) typeMapping(typeName string, currentPackage string) (TypeMapping, bool) {
+	if mapping, ok := parser.TypeMappings[typeName]; ok && !isNamedType(typeName) {
+		return mapping, true
+	}
+	var imports map[string]string
+	if currentPackage != "" {
+		imports = parser.PackageImports[parser.checkRealPackagePath(currentPackage)]
+	}
+
+	importPaths := make([]string, 0)
+	name := typeName
+	if dot := strings.LastIndex(typeName, "."); dot != -1 {
+		name = typeName[dot+1:]
+		if importPath, ok := imports[typeName[:dot]]; ok && !strings.Contains(typeName, "/") {
+			importPaths = append(importPaths, importPath)
+		} else {
+			importPaths = append(importPaths, typeName[:dot])
+		}
+	} else {
+		for importName, importPath := range imports {
+			if strings.HasPrefix(importName, ".") {
+				importPaths = append(importPaths, importPath)
+			}
+		}
+		sort.Strings(importPaths)
+	}
+	for _, importPath := range importPaths {
+		if mapping, ok := parser.TypeMappings[importPath+"."+name]; ok {
+			return mapping, true
+		}
+		if mapping, ok := parser.TypeMappings[ImportName(importPath)+"."+name]; ok {
+			return mapping, true
+		}
+	}
+	return TypeMapping{}, false
 }
 
 func IsBasicType(typeName string) bool {
@@ -248,35 +1018,39 @@ func IsBasicType(typeName string) bool {
 
 func (p *ModelProperty) SetItemType(itemType string) {
 	p.Items = ModelPropertyItems{}
-	if IsBasicType(itemType) {
-		p.Items.Type = itemType
-	} else {
-		p.Items.Ref = itemType
-	}
+	p.Items.setType(itemType)
 }
+
+// GetTypeAsString resolves a field type expression to its type name, e.g. "[]pkg.User" for *[]*pkg.User.
+// Pointers are dereferenced, slices and arrays become "[]", maps keep their "map[Key]Value" form,
+// and anonymous structs, interfaces, channels and functions are treated as "interface".
 func (p *ModelProperty) GetTypeAsString(fieldType interface{}) string {
 	var realType string
-	if astArrayType, ok := fieldType.(*ast.ArrayType); ok {
-		//		log.Printf("arrayType: %#v\n", astArrayType)
-		realType = fmt.Sprintf("[]%v", p.GetTypeAsString(astArrayType.Elt))
-	} else if astMapType, ok := fieldType.(*ast.MapType); ok {
-		//		log.Printf("arrayType: %#v\n", astArrayType)
-		realType = fmt.Sprintf("[]%v", p.GetTypeAsString(astMapType.Value))
-	} else if _, ok := fieldType.(*ast.InterfaceType); ok {
-		realType = "interface"
-	} else {
-		if astStarExpr, ok := fieldType.(*ast.StarExpr); ok {
-			realType = fmt.Sprint(astStarExpr.X)
-			//			log.Printf("Get type as string (star expression)! %#v, type: %s\n", astStarExpr.X, fmt.Sprint(astStarExpr.X))
-		} else if astSelectorExpr, ok := fieldType.(*ast.SelectorExpr); ok {
-			packageNameIdent, _ := astSelectorExpr.X.(*ast.Ident)
-			realType = packageNameIdent.Name + "." + astSelectorExpr.Sel.Name
-
-			//			log.Printf("Get type as string(selector expression)! X: %#v , Sel: %#v, type %s\n", astSelectorExpr.X, astSelectorExpr.Sel, realType)
-		} else {
-			//			log.Printf("Get type as string(no star expression)! %#v , type: %s\n", fieldType, fmt.Sprint(fieldType))
-			realType = fmt.Sprint(fieldType)
+	switch astType := fieldType.(type) {
+	case *ast.ArrayType:
+		realType = fmt.Sprintf("[]%v", p.GetTypeAsString(astType.Elt))
+	case *ast.MapType:
+		realType = fmt.Sprintf("map[%v]%v", p.GetTypeAsString(astType.Key), p.GetTypeAsString(astType.Value))
+	case *ast.StarExpr:
+		realType = p.GetTypeAsString(astType.X)
+	case *ast.ParenExpr:
+		realType = p.GetTypeAsString(astType.X)
+	case *ast.SelectorExpr:
+		realType = p.GetTypeAsString(astType.X) + "." + astType.Sel.Name
+	case *ast.IndexExpr:
+		realType = fmt.Sprintf("%v[%v]", p.GetTypeAsString(astType.X), p.GetTypeAsString(astType.Index))
+	case *ast.IndexListExpr:
+		typeArgs := make([]string, 0, len(astType.Indices))
+		for _, index := range astType.Indices {
+			typeArgs = append(typeArgs, p.GetTypeAsString(index))
 		}
+		realType = fmt.Sprintf("%v[%v]", p.GetTypeAsString(astType.X), strings.Join(typeArgs, ","))
+	case *ast.Ident:
+		realType = astType.Name
+	case *ast.InterfaceType, *ast.StructType, *ast.ChanType, *ast.FuncType:
+		realType = "interface"
+	default:
+		realType = fmt.Sprint(fieldType)
 	}
 	return realType
 }
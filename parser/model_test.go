@@ -1,7 +1,11 @@
 package parser_test
 
 import (
+	"encoding/json"
 	"go/ast"
+	goparser "go/parser"
+	"path"
+	"sort"
 	"strings"
 	"testing"
 
@@ -64,6 +68,22 @@ func (suite *ModelSuite) TestInterfaceType() {
 	assert.Len(suite.T(), m.Properties, 0, "Can not parse InterfaceType definition")
 }
 
+func (suite *ModelSuite) TestStructureWithInterfaces() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("Drawing", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse Drawing definition")
+	if assert.Len(suite.T(), innerModels, 1, "Interfaces should not be models") {
+		assert.True(suite.T(), strings.HasSuffix(innerModels[0].Id, ".SimpleStructure"), "Interfaces should not be models")
+	}
+
+	assert.Equal(suite.T(), "object", m.Properties["Anything"].Type, "interface{} should be a free-form object")
+	assert.Equal(suite.T(), "object", m.Properties["Attributes"].AdditionalProperties.Type, "any should be a free-form object")
+	assert.Equal(suite.T(), "object", m.Properties["Main"].Type, "Named interface should be a free-form object")
+	assert.Equal(suite.T(), "object", m.Properties["Shapes"].Items.Type, "Named interface should be a free-form object")
+	assert.Empty(suite.T(), m.Properties["Shapes"].Items.Ref, "Named interface should not be referenced")
+	assert.Equal(suite.T(), "object", m.Properties["Layers"].AdditionalProperties.Items.Type, "Named interface should be a free-form object")
+}
+
 func (suite *ModelSuite) TestSimpleAlias() {
 	m := parser.NewModel(suite.parser)
 	err, innerModels := m.ParseModel("SimpleAlias", ExamplePackageName, suite.knownModelNames)
@@ -82,7 +102,7 @@ func (suite *ModelSuite) TestSimpleStructure() {
 	assert.Len(suite.T(), innerModels, 0, "Can not parse SimpleStructure definition")
 
 	assert.True(suite.T(), strings.HasSuffix(m.Id, "SimpleStructure"), "Can not parse SimpleStructuredefinition")
-	assert.Len(suite.T(), m.Required, 0, "Can not parse SimpleStructure definition")
+	assert.Equal(suite.T(), []string{"Id", "Name"}, m.Required, "Fields without omitempty should be required")
 	assert.Len(suite.T(), m.Properties, 2, "Can not parse SimpleStructure definition")
 }
 
@@ -93,7 +113,7 @@ func (suite *ModelSuite) TestSimpleStructureWithAnnotations() {
 	assert.Len(suite.T(), innerModels, 0, "Can not parse SimpleStructureWithAnnotations definition")
 
 	assert.True(suite.T(), strings.HasSuffix(m.Id, "SimpleStructureWithAnnotations"), "Can not parse SimpleStructureWithAnnotations")
-	assert.Len(suite.T(), m.Required, 1, "Can not parse SimpleStructureWithAnnotations definition(%#v)", m.Properties)
+	assert.Equal(suite.T(), []string{"id", "Name"}, m.Required, "Can not parse SimpleStructureWithAnnotations definition(%#v)", m.Properties)
 	assert.Len(suite.T(), m.Properties, 2, "Can not parse SimpleStructureWithAnnotations definition")
 
 	assert.Equal(suite.T(), m.Properties["id"].Type, "int", "Can not parse SimpleStructureWithAnnotations definition")
@@ -107,12 +127,12 @@ func (suite *ModelSuite) TestStructureWithSlice() {
 	assert.Len(suite.T(), innerModels, 0, "Can not parse StructureWithSlice definition")
 
 	assert.True(suite.T(), strings.HasSuffix(m.Id, "StructureWithSlice"), "Can not parse StructureWithSlice")
-	assert.Len(suite.T(), m.Required, 0, "Can not parse StructureWithSlice definition(%#v)", m.Properties)
+	assert.Equal(suite.T(), []string{"Id", "Name"}, m.Required, "Can not parse StructureWithSlice definition(%#v)", m.Properties)
 	assert.Len(suite.T(), m.Properties, 2, "Can not parse StructureWithSlice definition")
 
 	assert.Equal(suite.T(), m.Properties["Id"].Type, "int", "Can not parse StructureWithSlice definition")
-	assert.Equal(suite.T(), m.Properties["Name"].Type, "array", "Can not parse StructureWithSlice definition")
-	assert.Equal(suite.T(), m.Properties["Name"].Items.Type, "byte", "Can not parse StructureWithSlice definition")
+	assert.Equal(suite.T(), m.Properties["Name"].Type, "string", "Can not parse StructureWithSlice definition")
+	assert.Equal(suite.T(), m.Properties["Name"].Format, "byte", "Can not parse StructureWithSlice definition")
 }
 
 func (suite *ModelSuite) TestStructureWithEmbededStructure() {
@@ -123,12 +143,12 @@ func (suite *ModelSuite) TestStructureWithEmbededStructure() {
 	assert.Len(suite.T(), innerModels, 0, "Can not parse StructureWithEmbededStructure definition (%#v)", innerModels)
 
 	assert.True(suite.T(), strings.HasSuffix(m.Id, "StructureWithEmbededStructure"), "Can not parse StructureWithEmbededStructure")
-	assert.Len(suite.T(), m.Required, 0, "Can not parse StructureWithEmbededStructure definition(%#v)", m.Properties)
+	assert.Equal(suite.T(), []string{"Id", "Name"}, m.Required, "Required promoted fields not parsed (%#v)", m.Properties)
 	assert.Len(suite.T(), m.Properties, 2, "Can not parse StructureWithEmbededStructure definition")
 
 	assert.Equal(suite.T(), m.Properties["Id"].Type, "int", "Can not parse StructureWithEmbededStructure definition")
-	assert.Equal(suite.T(), m.Properties["Name"].Type, "array", "Can not parse StructureWithEmbededStructure definition")
-	assert.Equal(suite.T(), m.Properties["Name"].Items.Type, "byte", "Can not parse StructureWithEmbededStructure definition")
+	assert.Equal(suite.T(), m.Properties["Name"].Type, "string", "Can not parse StructureWithEmbededStructure definition")
+	assert.Equal(suite.T(), m.Properties["Name"].Format, "byte", "Can not parse StructureWithEmbededStructure definition")
 }
 
 func (suite *ModelSuite) TestStructureWithEmbededPointer() {
@@ -139,17 +159,708 @@ func (suite *ModelSuite) TestStructureWithEmbededPointer() {
 	assert.Len(suite.T(), innerModels, 0, "Can not parse StructureWithEmbededPointer definition (%#v)", innerModels)
 
 	assert.True(suite.T(), strings.HasSuffix(m.Id, "StructureWithEmbededPointer"), "Can not parse StructureWithEmbededPointer")
-	assert.Len(suite.T(), m.Required, 0, "Can not parse StructureWithEmbededPointer definition(%#v)", m.Properties)
+	assert.Len(suite.T(), m.Required, 0, "Fields promoted from an embedded pointer should not be required (%#v)", m.Properties)
 	assert.Len(suite.T(), m.Properties, 2, "Can not parse StructureWithEmbededPointer definition")
 
 	assert.Equal(suite.T(), m.Properties["Id"].Type, "int", "Can not parse StructureWithEmbededPointer definition")
-	assert.Equal(suite.T(), m.Properties["Name"].Type, "array", "Can not parse StructureWithEmbededPointer definition")
-	assert.Equal(suite.T(), m.Properties["Name"].Items.Type, "byte", "Can not parse StructureWithEmbededPointer definition")
+	assert.Equal(suite.T(), m.Properties["Name"].Type, "string", "Can not parse StructureWithEmbededPointer definition")
+	assert.Equal(suite.T(), m.Properties["Name"].Format, "byte", "Can not parse StructureWithEmbededPointer definition")
+}
+
+func (suite *ModelSuite) TestGenericStructure() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("Page[SimpleStructure]", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse Page[SimpleStructure] definition")
+	assert.Len(suite.T(), innerModels, 1, "Can not parse Page[SimpleStructure] definition (%#v)", innerModels)
+
+	assert.True(suite.T(), strings.HasSuffix(m.Id, ".PageSimpleStructure"), "Can not parse Page[SimpleStructure] definition")
+	assert.Len(suite.T(), m.Properties, 2, "Can not parse Page[SimpleStructure] definition")
+
+	assert.Equal(suite.T(), m.Properties["Total"].Type, "int", "Can not parse Page[SimpleStructure] definition")
+	assert.Equal(suite.T(), m.Properties["Items"].Type, "array", "Can not parse Page[SimpleStructure] definition")
+	assert.Equal(suite.T(), m.Properties["Items"].Items.Ref, innerModels[0].Id, "Can not parse Page[SimpleStructure] definition")
+	assert.True(suite.T(), strings.HasSuffix(innerModels[0].Id, ".SimpleStructure"), "Can not parse Page[SimpleStructure] definition")
+}
+
+func (suite *ModelSuite) TestGenericStructureWithQualifiedTypeNamedLikeTypeParam() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("Labeled[string]", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse Labeled[string] definition")
+
+	assert.Equal(suite.T(), "string", m.Properties["Value"].Type, "Type parameter should be replaced by the type argument")
+	if assert.Len(suite.T(), innerModels, 1, "Can not parse Labeled[string] definition (%#v)", innerModels) {
+		assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.subpackage.SimpleStructure", innerModels[0].Id, "Qualified type should not be replaced by the type argument")
+		assert.Equal(suite.T(), innerModels[0].Id, m.Properties["Label"].Type, "Qualified type should not be replaced by the type argument")
+	}
+}
+
+func (suite *ModelSuite) TestGenericStructureWithoutTypeArguments() {
+	m := parser.NewModel(suite.parser)
+	err, _ := m.ParseModel("Page", ExamplePackageName, map[string]bool{})
+	assert.NotNil(suite.T(), err, "Generic model without type arguments should not be parsed")
+}
+
+func (suite *ModelSuite) TestCrossPackageGenericStructure() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithCrossPackageGeneric", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithCrossPackageGeneric definition")
+
+	modelsById := make(map[string]*parser.Model)
+	for _, innerModel := range innerModels {
+		modelsById[innerModel.Id] = innerModel
+	}
+	assert.Len(suite.T(), modelsById, 4, "Can not parse StructureWithCrossPackageGeneric definition (%#v)", innerModels)
+
+	userPageId := "github.com.RobotsAndPencils.go-swaggerLite.example.pagination.PageUsersUser"
+	assert.Equal(suite.T(), userPageId, m.Properties["Users"].Type, "Can not parse generic from another package")
+	if userPage, ok := modelsById[userPageId]; assert.True(suite.T(), ok, "Concrete model not parsed") {
+		assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.users.User", userPage.Properties["Items"].Items.Ref, "Type argument not resolved in its own package")
+	}
+
+	structPageId := "github.com.RobotsAndPencils.go-swaggerLite.example.pagination.PageExampleSimpleStructure"
+	assert.Equal(suite.T(), structPageId, m.Properties["Structs"].Type, "Can not parse generic from another package")
+	if structPage, ok := modelsById[structPageId]; assert.True(suite.T(), ok, "Concrete model not parsed") {
+		assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleStructure", structPage.Properties["Items"].Items.Ref, "Type argument not resolved in its own package")
+	}
+}
+
+func (suite *ModelSuite) TestModelNamer() {
+	p := parser.NewParser()
+	p.ModelNamer = func(pkg string, typeName string) string {
+		return path.Base(pkg) + "." + typeName
+	}
+	p.ParseTypeDefinitions(ExamplePackageName)
+
+	m := parser.NewModel(p)
+	err, innerModels := m.ParseModel("StructureWithCrossPackageGeneric", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithCrossPackageGeneric definition")
+	assert.Equal(suite.T(), "example.StructureWithCrossPackageGeneric", m.Id, "Model not named by the model namer")
+	assert.Equal(suite.T(), "pagination.PageUsersUser", m.Properties["Users"].Type, "Reference not named by the model namer")
+
+	modelsById := make(map[string]*parser.Model)
+	for _, innerModel := range innerModels {
+		modelsById[innerModel.Id] = innerModel
+	}
+	if userPage, ok := modelsById["pagination.PageUsersUser"]; assert.True(suite.T(), ok, "Inner model not named by the model namer (%#v)", modelsById) {
+		assert.Equal(suite.T(), "users.User", userPage.Properties["Items"].Items.Ref, "Reference not named by the model namer")
+	}
+	assert.Contains(suite.T(), modelsById, "example.SimpleStructure", "Inner model not named by the model namer")
+
+	assert.Equal(suite.T(), "github.com.user.api.User", parser.DefaultModelNamer("github.com/user/api", "User"), "Default model name changed")
+}
+
+func (suite *ModelSuite) TestModelNameCollisions() {
+	p := parser.NewParser()
+	p.ParseTypeDefinitions(ExamplePackageName)
+	for _, modelName := range []string{"SimpleStructure", "subpackage.SimpleStructure", "SimpleStructure"} {
+		err, _ := parser.NewModel(p).ParseModel(modelName, ExamplePackageName, map[string]bool{})
+		assert.Nil(suite.T(), err, "Can not parse %s definition", modelName)
+	}
+	assert.Empty(suite.T(), p.Validate(), "Models of types of the same name in two packages should be named apart")
+
+	p2 := parser.NewParser()
+	p2.ModelNamer = func(pkg string, typeName string) string {
+		return typeName
+	}
+	p2.ParseTypeDefinitions(ExamplePackageName)
+	for _, modelName := range []string{"SimpleStructure", "subpackage.SimpleStructure"} {
+		err, _ := parser.NewModel(p2).ParseModel(modelName, ExamplePackageName, map[string]bool{})
+		assert.Nil(suite.T(), err, "Can not parse %s definition", modelName)
+	}
+	errs := p2.Validate()
+	if assert.Len(suite.T(), errs, 1, "Model name collision not reported") {
+		expected := "Model name SimpleStructure is given to several types: " +
+			ExamplePackageName + ".SimpleStructure, " + ExamplePackageName + "/subpackage.SimpleStructure"
+		assert.Equal(suite.T(), expected, errs[0].Error(), "Model name collision not reported")
+	}
+}
+
+func (suite *ModelSuite) TestStructureWithPointers() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithPointers", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithPointers definition")
+
+	assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.users.User", m.Properties["owner"].Type, "Pointer to a struct should reference the pointee model")
+	assert.Len(suite.T(), innerModels, 1, "Pointee model not parsed")
+	assert.Equal(suite.T(), "int", m.Properties["count"].Type, "Pointer to a basic type should be documented as the pointee type")
+	assert.Equal(suite.T(), "array", m.Properties["names"].Type, "Pointer to a slice should be documented as the pointee type")
+	assert.Equal(suite.T(), "string", m.Properties["names"].Items.Type, "Pointer to a slice should be documented as the pointee type")
+
+	for _, name := range []string{"owner", "count", "names", "note"} {
+		assert.True(suite.T(), m.Properties[name].Nullable, "Pointer field %s should be nullable", name)
+	}
+	assert.False(suite.T(), m.Properties["id"].Nullable, "Non pointer field should not be nullable")
+	assert.Equal(suite.T(), []string{"id", "note"}, m.Required, "Only explicitly required fields should be required")
+}
+
+func (suite *ModelSuite) TestStructureWithNullableFields() {
+	m := parser.NewModel(suite.parser)
+	err, _ := m.ParseModel("StructureWithNullableFields", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithNullableFields definition")
+
+	assert.True(suite.T(), m.Properties["deletedAt"].Nullable, "@Nullable field should be nullable")
+	assert.Equal(suite.T(), "DeletedAt is only set once the structure is deleted", m.Properties["deletedAt"].Description, "@Nullable should be left out of the description")
+	assert.False(suite.T(), m.Properties["owner"].Nullable, "@Nullable false should override the pointer")
+	assert.Equal(suite.T(), "", m.Properties["owner"].Description, "@Nullable should be left out of the description")
+	assert.True(suite.T(), m.Properties["parent"].Nullable, "Pointer field should be nullable")
+	assert.False(suite.T(), m.Properties["name"].Nullable, "Non pointer field should not be nullable")
+	assert.Equal(suite.T(), []string{"deletedAt", "name"}, m.Required, "@Nullable should not change which fields are required")
+}
+
+func (suite *ModelSuite) TestStructureWithOptionalFields() {
+	m := parser.NewModel(suite.parser)
+	err, _ := m.ParseModel("StructureWithOptionalFields", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithOptionalFields definition")
+
+	assert.NotContains(suite.T(), m.Properties, "Secret", "Fields with a json tag of \"-\" should not be documented")
+	assert.Equal(suite.T(), []string{"id"}, m.Required, "Fields with omitempty, pointers and required:\"false\" should be optional")
+}
+
+func (suite *ModelSuite) TestRequiredComment() {
+	m := parser.NewModel(suite.parser)
+	err, _ := m.ParseModel("StructureWithRequiredComment", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithRequiredComment definition")
+	assert.Equal(suite.T(), []string{"id", "name"}, m.Required, "@Required should override the required fields")
+
+	assert.NotNil(suite.T(), m.ParseComment("StructureWithRequiredComment", "// @Required"), "Required comment without properties should fail")
+}
+
+func (suite *ModelSuite) TestXmlStructure() {
+	m := parser.NewModel(suite.parser)
+	err, _ := m.ParseModel("XmlOrder", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse XmlOrder definition")
+	assert.Len(suite.T(), m.Properties, 4, "XMLName field should not be a property")
+	assert.Nil(suite.T(), m.XML, "XML objects should only be set for operations producing XML")
+	assert.Nil(suite.T(), m.Properties["id"].XML, "XML objects should only be set for operations producing XML")
+
+	m.EnableXML()
+	assert.Equal(suite.T(), &parser.XMLObject{Name: "order"}, m.XML, "Model XML name not parsed")
+	assert.Equal(suite.T(), &parser.XMLObject{Name: "id", Attribute: true}, m.Properties["id"].XML, "XML attribute not parsed")
+	assert.Equal(suite.T(), &parser.XMLObject{Name: "customer"}, m.Properties["customer"].XML, "XML name not parsed")
+	assert.Equal(suite.T(), &parser.XMLObject{Name: "lines", Wrapped: true}, m.Properties["lines"].XML, "Wrapped XML array not parsed")
+	assert.Equal(suite.T(), &parser.XMLObject{Name: "line"}, m.Properties["lines"].Items.XML, "Wrapped XML array items not parsed")
+	assert.Nil(suite.T(), m.Properties["note"].XML, "Field left out of XML should have no XML object")
+
+	marshaled, err := json.Marshal(m)
+	assert.Nil(suite.T(), err, "Can not marshal XmlOrder model")
+	assert.Contains(suite.T(), string(marshaled), `"xml":{"name":"id","attribute":true}`, "XML object not emitted")
+}
+
+func (suite *ModelSuite) TestExportJSONSchema() {
+	data, err := suite.parser.ExportJSONSchema("StructureWithAliases", ExamplePackageName)
+	assert.Nil(suite.T(), err, "Can not export JSON schema")
+
+	document := struct {
+		MetaSchema string                      `json:"$schema"`
+		Title      string                      `json:"title"`
+		Type       string                      `json:"type"`
+		Properties map[string]*parser.Schema   `json:"properties"`
+		Defs       map[string]*json.RawMessage `json:"$defs"`
+	}{}
+	assert.Nil(suite.T(), json.Unmarshal(data, &document), "JSON schema is not valid JSON")
+	assert.Equal(suite.T(), "https://json-schema.org/draft/2020-12/schema", document.MetaSchema, "Meta-schema not set")
+	assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.StructureWithAliases", document.Title, "Title should be the model id")
+	assert.Equal(suite.T(), "object", document.Type, "Model should be the root schema")
+	assert.Equal(suite.T(), &parser.Schema{Type: "integer", Format: "int64"}, document.Properties["Id"], "Property not exported")
+
+	simpleStructureId := "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleStructure"
+	assert.Equal(suite.T(), "#/$defs/"+simpleStructureId, document.Properties["Simple"].Ref, "Referenced model should be in $defs")
+	defIds := []string{}
+	for id := range document.Defs {
+		defIds = append(defIds, id)
+	}
+	assert.ElementsMatch(suite.T(), []string{
+		simpleStructureId,
+		"github.com.RobotsAndPencils.go-swaggerLite.example.users.User",
+		"github.com.RobotsAndPencils.go-swaggerLite.example.SimpleAlias",
+	}, defIds, "Referenced models should be in $defs")
+
+	data, err = suite.parser.ExportJSONSchema("StructureWithNullableFields", ExamplePackageName)
+	assert.Nil(suite.T(), err, "Can not export JSON schema")
+	assert.Contains(suite.T(), string(data), `"$defs"`, "Referenced models should be in $defs")
+	assert.NotContains(suite.T(), string(data), `"definitions"`, "Referenced models should be in $defs")
+	assert.NotContains(suite.T(), string(data), `"x-nullable"`, "Swagger keywords should not be exported")
+
+	p := parser.NewParser()
+	p.LibraryMode = true
+	_, err = p.ExportJSONSchema("MissingStructure", ExamplePackageName)
+	assert.NotNil(suite.T(), err, "Exporting a missing type should fail")
+}
+
+func (suite *ModelSuite) TestStructureWithComments() {
+	m := parser.NewModel(suite.parser)
+	err, _ := m.ParseModel("StructureWithComments", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithComments definition")
+
+	for name, description := range map[string]string{
+		"id":     "Id identifies the structure, it never changes",
+		"name":   "Name of the structure",
+		"Notes":  "Notes about the structure",
+		"Tagged": "From the tag",
+		"Plain":  "",
+	} {
+		assert.Equal(suite.T(), description, m.Properties[name].Description, "Description of %s not parsed from its comments", name)
+	}
+}
+
+func (suite *ModelSuite) TestStructureWithExamples() {
+	m := parser.NewModel(suite.parser)
+	err, _ := m.ParseModel("StructureWithExamples", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithExamples definition")
+
+	for name, example := range map[string]interface{}{
+		"id":      int64(42),
+		"name":    "Bob",
+		"price":   9.99,
+		"active":  true,
+		"tags":    []interface{}{"new", "sale"},
+		"labels":  map[string]interface{}{"color": "red"},
+		"invalid": nil,
+	} {
+		assert.Equal(suite.T(), example, m.Properties[name].Example, "Example of %s not parsed from its tag", name)
+	}
+}
+
+func (suite *ModelSuite) TestSelfReferentialStructure() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("TreeNode", ExamplePackageName, suite.knownModelNames)
+	assert.Nil(suite.T(), err, "Can not parse TreeNode definition")
+
+	treeNodeId := "github.com.RobotsAndPencils.go-swaggerLite.example.TreeNode"
+	assert.Equal(suite.T(), treeNodeId, m.Properties["Parent"].Type, "Self reference should reference the model")
+	assert.Equal(suite.T(), treeNodeId, m.Properties["Children"].Items.Ref, "Self reference should reference the model")
+	assert.Empty(suite.T(), innerModels, "Self-referential model should not be parsed again")
+}
+
+func (suite *ModelSuite) TestMutuallyRecursiveStructures() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("Department", ExamplePackageName, suite.knownModelNames)
+	assert.Nil(suite.T(), err, "Can not parse Department definition")
+
+	departmentId := "github.com.RobotsAndPencils.go-swaggerLite.example.Department"
+	employeeId := "github.com.RobotsAndPencils.go-swaggerLite.example.Employee"
+	assert.Equal(suite.T(), employeeId, m.Properties["Employees"].Items.Ref, "Inner model not referenced")
+	if assert.Len(suite.T(), innerModels, 1, "Recursive models should be parsed once") {
+		employee := innerModels[0]
+		assert.Equal(suite.T(), employeeId, employee.Id, "Inner model not parsed")
+		assert.Equal(suite.T(), departmentId, employee.Properties["Department"].Type, "Reference back to the outer model should reference it")
+		assert.Equal(suite.T(), employeeId, employee.Properties["Manager"].Type, "Self reference should reference the model")
+	}
 }
 
-//TODO:
-//embeded structures from other packages
-//arrays of arrays
+func (suite *ModelSuite) TestStructureWithAliases() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithAliases", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithAliases definition")
+
+	simpleStructureId := "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleStructure"
+	userId := "github.com.RobotsAndPencils.go-swaggerLite.example.users.User"
+	assert.Equal(suite.T(), "int64", m.Properties["Id"].Type, "Alias of a basic type should be the basic type")
+	assert.Equal(suite.T(), "int64", m.Properties["Ids"].Items.Type, "Alias of a basic type should be the basic type")
+	assert.Equal(suite.T(), simpleStructureId, m.Properties["Simple"].Type, "Alias in the same package should reference the aliased model")
+	assert.Equal(suite.T(), userId, m.Properties["Owner"].Type, "Alias in another package should reference the aliased model")
+	assert.Equal(suite.T(), "string", m.Properties["Code"].Type, "Alias of a basic type in another package should be the basic type")
+	assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleAlias", m.Properties["Alias"].Type, "Defined type should be a model of its own")
+
+	innerModelIds := []string{}
+	for _, innerModel := range innerModels {
+		innerModelIds = append(innerModelIds, innerModel.Id)
+	}
+	assert.ElementsMatch(suite.T(), []string{simpleStructureId, userId, "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleAlias"}, innerModelIds, "Aliases should not be models of their own")
+
+	// a model referenced through an alias is the aliased model
+	aliasModel := parser.NewModel(suite.parser)
+	err, _ = aliasModel.ParseModel("SimpleStructureAlias", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse SimpleStructureAlias definition")
+	assert.Equal(suite.T(), simpleStructureId, aliasModel.Id, "Alias should be the aliased model")
+	assert.Len(suite.T(), aliasModel.Properties, 2, "Alias should be the aliased model")
+
+	crossPackageModel := parser.NewModel(suite.parser)
+	err, _ = crossPackageModel.ParseModel("subpackage.User", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse subpackage.User definition")
+	assert.Equal(suite.T(), userId, crossPackageModel.Id, "Alias in another package should be the aliased model")
+}
+
+func (suite *ModelSuite) TestStructureWithIntEnums() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithIntEnums", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithIntEnums definition")
+	assert.Len(suite.T(), innerModels, 0, "Enum types should not be parsed as models (%#v)", innerModels)
+
+	for field, expected := range map[string][2][]string{
+		"Color":    {{"0", "1", "2"}, {"Red", "Green", "Blue"}},
+		"Priority": {{"1", "5", "10"}, {"PriorityLow", "PriorityNormal", "PriorityHigh"}},
+		"Weight":   {{"10", "20"}, {"WeightLight", "WeightHeavy"}},
+	} {
+		assert.Equal(suite.T(), "int", m.Properties[field].Type, "Enum type %s not resolved to its underlying type", field)
+		assert.Equal(suite.T(), expected[0], m.Properties[field].Enum, "Enum values of %s not parsed", field)
+		assert.Equal(suite.T(), expected[1], m.Properties[field].EnumVarNames, "Enum constant names of %s not parsed", field)
+	}
+
+	json, _ := json.Marshal(m)
+	assert.Contains(suite.T(), string(json), `"enum":[1,5,10]`, "Integer enum values should be written as numbers")
+}
+
+func (suite *ModelSuite) TestEvalConstExpr() {
+	for source, expected := range map[string]string{
+		`"active"`:        "active",
+		"42":              "42",
+		"0x10":            "16",
+		"iota":            "3",
+		"1 << iota":       "8",
+		"(iota + 1) * 10": "40",
+		"-iota":           "-3",
+		"Color(iota - 1)": "2",
+	} {
+		expr, err := goparser.ParseExpr(source)
+		if err != nil {
+			suite.T().Fatalf("Can not parse expression %s: %v", source, err)
+		}
+		value, ok := parser.EvalConstExpr(expr, 3)
+		assert.True(suite.T(), ok, "Can not evaluate %s", source)
+		assert.Equal(suite.T(), expected, value, "Can not evaluate %s", source)
+	}
+
+	expr, _ := goparser.ParseExpr("OtherConstant + 1")
+	_, ok := parser.EvalConstExpr(expr, 0)
+	assert.False(suite.T(), ok, "Expression referencing other constants can not be evaluated")
+
+	for _, source := range []string{"1 / iota", "10 % iota"} {
+		expr, _ := goparser.ParseExpr(source)
+		_, ok := parser.EvalConstExpr(expr, 0)
+		assert.False(suite.T(), ok, "Division by zero can not be evaluated: %s", source)
+	}
+}
+
+func (suite *ModelSuite) TestSplitGenericModelName() {
+	name, typeArgs := parser.SplitGenericModelName("pkg.Pair[string,Page[pkg.User]]")
+	assert.Equal(suite.T(), "pkg.Pair", name, "Can not split generic model name")
+	assert.Equal(suite.T(), []string{"string", "Page[pkg.User]"}, typeArgs, "Can not split generic model name")
+
+	name2, typeArgs2 := parser.SplitGenericModelName("pkg.User")
+	assert.Equal(suite.T(), "pkg.User", name2, "Can not split non-generic model name")
+	assert.Len(suite.T(), typeArgs2, 0, "Can not split non-generic model name")
+}
+
+func (suite *ModelSuite) TestStructureWithComposedTypes() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithComposedTypes", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithComposedTypes definition")
+	assert.Len(suite.T(), innerModels, 1, "Can not parse StructureWithComposedTypes definition (%#v)", innerModels)
+	assert.Len(suite.T(), m.Properties, 8, "Can not parse StructureWithComposedTypes definition")
+
+	simpleStructureId := innerModels[0].Id
+	assert.True(suite.T(), strings.HasSuffix(simpleStructureId, ".SimpleStructure"), "Can not parse StructureWithComposedTypes definition")
+
+	pointerToSliceOfMaps := m.Properties["PointerToSliceOfMaps"]
+	assert.Equal(suite.T(), "array", pointerToSliceOfMaps.Type, "Can not parse pointer to slice of maps")
+	assert.Equal(suite.T(), "object", pointerToSliceOfMaps.Items.Type, "Can not parse pointer to slice of maps")
+	assert.Equal(suite.T(), simpleStructureId, pointerToSliceOfMaps.Items.AdditionalProperties.Ref, "Can not parse pointer to slice of maps")
+
+	sliceOfSlices := m.Properties["SliceOfSlices"]
+	assert.Equal(suite.T(), "array", sliceOfSlices.Type, "Can not parse slice of slices")
+	assert.Equal(suite.T(), "array", sliceOfSlices.Items.Type, "Can not parse slice of slices")
+	assert.Equal(suite.T(), "int", sliceOfSlices.Items.Items.Type, "Can not parse slice of slices")
+
+	sliceOfPointers := m.Properties["SliceOfPointers"]
+	assert.Equal(suite.T(), "array", sliceOfPointers.Type, "Can not parse slice of pointers")
+	assert.Equal(suite.T(), simpleStructureId, sliceOfPointers.Items.Ref, "Can not parse slice of pointers")
+
+	assert.Equal(suite.T(), "int", m.Properties["PointerToPointer"].Type, "Can not parse pointer to pointer")
+
+	mapOfSlices := m.Properties["MapOfSlices"]
+	assert.Equal(suite.T(), "object", mapOfSlices.Type, "Can not parse map of slices")
+	assert.Equal(suite.T(), "array", mapOfSlices.AdditionalProperties.Type, "Can not parse map of slices")
+	assert.Equal(suite.T(), "string", mapOfSlices.AdditionalProperties.Items.Type, "Can not parse map of slices")
+
+	mapOfMaps := m.Properties["MapOfMaps"]
+	assert.Equal(suite.T(), "object", mapOfMaps.Type, "Can not parse map of maps")
+	assert.Equal(suite.T(), "object", mapOfMaps.AdditionalProperties.Type, "Can not parse map of maps")
+	assert.Equal(suite.T(), simpleStructureId, mapOfMaps.AdditionalProperties.AdditionalProperties.Ref, "Can not parse map of maps")
+
+	json, _ := json.Marshal(m.Properties["MapOfInts"])
+	assert.Contains(suite.T(), string(json), `"type":"object"`, "Can not serialize map")
+	assert.Contains(suite.T(), string(json), `"additionalProperties":{"type":"int"}`, "Can not serialize map")
+
+	assert.Equal(suite.T(), "object", m.Properties["AnonymousStructure"].Type, "Can not parse anonymous structure")
+}
+
+func (suite *ModelSuite) TestSplitMapType() {
+	valueType, ok := parser.SplitMapType("map[string][]User")
+	assert.True(suite.T(), ok, "Can not split map type")
+	assert.Equal(suite.T(), "[]User", valueType, "Can not split map type")
+
+	valueType, ok = parser.SplitMapType("map[[2]int]map[string]int")
+	assert.True(suite.T(), ok, "Can not split map type with array key")
+	assert.Equal(suite.T(), "map[string]int", valueType, "Can not split map type with array key")
+
+	_, ok = parser.SplitMapType("[]User")
+	assert.False(suite.T(), ok, "Slice is not a map")
+}
+
+func (suite *ModelSuite) TestStructureWithJsonTags() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithJsonTags", ExamplePackageName, suite.knownModelNames)
+	assert.Nil(suite.T(), err, "Can not parse StructureWithJsonTags definition")
+	assert.Len(suite.T(), innerModels, 0, "Can not parse StructureWithJsonTags definition")
+
+	assert.Len(suite.T(), m.Properties, 6, "Can not parse StructureWithJsonTags definition (%#v)", m.Properties)
+	assert.Equal(suite.T(), []string{"user_name", "-", "age", "id"}, m.Required, "Fields with omitempty should not be required")
+
+	assert.Contains(suite.T(), m.Properties, "user_name", "Renamed field not parsed")
+	assert.NotContains(suite.T(), m.Properties, "UserName", "Renamed field should use its json name")
+	assert.NotContains(suite.T(), m.Properties, "Password", "Skipped field should not be documented")
+	assert.Contains(suite.T(), m.Properties, "nickname", "Omitempty field not parsed")
+	assert.Contains(suite.T(), m.Properties, "Email", "Field without json name should keep its Go name")
+	assert.Contains(suite.T(), m.Properties, "-", "Field tagged \"-,\" should be named \"-\"")
+	assert.Contains(suite.T(), m.Properties, "age", "Json options should not be used as the field name")
+
+	assert.Equal(suite.T(), "string", m.Properties["id"].Type, "Field with string option should be a string")
+	assert.Equal(suite.T(), "int64", m.Properties["id"].Format, "Field with string option should keep its type as format")
+	assert.Equal(suite.T(), "string", m.Properties["user_name"].Type, "Can not parse StructureWithJsonTags definition")
+	assert.Equal(suite.T(), "", m.Properties["user_name"].Format, "Can not parse StructureWithJsonTags definition")
+}
+
+func (suite *ModelSuite) TestTaggedStructure() {
+	m := parser.NewModel(suite.parser)
+	err, _ := m.ParseModel("TaggedStructure", ExamplePackageName, suite.knownModelNames)
+	assert.Nil(suite.T(), err, "Can not parse TaggedStructure definition")
+	assert.Equal(suite.T(), []string{"identity", "account", "audit"}, m.Tags, "Can not parse model tags")
+
+	json, _ := json.Marshal(m)
+	assert.Contains(suite.T(), string(json), `"x-tags":["identity","account","audit"]`, "Model tags not serialized")
+}
+
+func (suite *ModelSuite) TestParseTagComment() {
+	m := parser.NewModel(suite.parser)
+	assert.NotNil(suite.T(), m.ParseTagComment("User", "@ModelTag Account identity"), "Model tag for other type should not be accepted")
+	assert.NotNil(suite.T(), m.ParseTagComment("User", "@ModelTag User"), "Model tag without tags should not be accepted")
+	assert.Len(suite.T(), m.Tags, 0, "Invalid model tags should not be added")
+}
+
+func (suite *ModelSuite) TestStructureWithEmbededBaseModel() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithEmbededBaseModel", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithEmbededBaseModel definition")
+	assert.Len(suite.T(), innerModels, 1, "Models referenced by promoted fields not parsed (%#v)", innerModels)
+	assert.True(suite.T(), strings.HasSuffix(innerModels[0].Id, ".SimpleStructure"), "Models referenced by promoted fields not parsed")
+
+	assert.Len(suite.T(), m.Properties, 3, "Can not parse StructureWithEmbededBaseModel definition (%#v)", m.Properties)
+	assert.Equal(suite.T(), "int", m.Properties["Id"].Type, "Promoted field not parsed")
+	assert.Equal(suite.T(), innerModels[0].Id, m.Properties["owner"].Type, "Promoted field not parsed")
+	assert.Equal(suite.T(), "string", m.Properties["Name"].Type, "Field not parsed")
+	assert.Equal(suite.T(), []string{"Name", "Id", "owner"}, m.Required, "Required promoted field not parsed")
+}
+
+func (suite *ModelSuite) TestStructureWithNamedEmbededStructures() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithNamedEmbededStructures", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithNamedEmbededStructures definition")
+
+	baseModelId := "github.com.RobotsAndPencils.go-swaggerLite.example.BaseModel"
+	simpleStructureId := "github.com.RobotsAndPencils.go-swaggerLite.example.subpackage.SimpleStructure"
+	modelIds := make([]string, 0, len(innerModels))
+	for _, innerModel := range innerModels {
+		modelIds = append(modelIds, innerModel.Id)
+	}
+	assert.Contains(suite.T(), modelIds, baseModelId, "Model of the named embedded struct not parsed")
+	assert.Contains(suite.T(), modelIds, simpleStructureId, "Model of the named embedded struct not parsed")
+
+	assert.Len(suite.T(), m.Properties, 3, "Embedded structs named by their json tag should not be flattened (%#v)", m.Properties)
+	assert.Equal(suite.T(), baseModelId, m.Properties["base"].Type, "Named embedded struct should reference its model")
+	assert.Equal(suite.T(), simpleStructureId, m.Properties["simple"].Type, "Named embedded pointer should reference its model")
+	assert.Equal(suite.T(), "string", m.Properties["Name"].Type, "Field not parsed")
+	assert.NotContains(suite.T(), m.Properties, "Id", "Embedded struct tagged \"-\" should be skipped")
+	assert.Equal(suite.T(), []string{"base", "Name"}, m.Required, "Named embedded struct should be required unless omitted when empty")
+}
+
+func (suite *ModelSuite) TestStructureWithEmbededSubpackageStructure() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithEmbededSubpackageStructure", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithEmbededSubpackageStructure definition")
+	assert.Len(suite.T(), innerModels, 0, "Can not parse StructureWithEmbededSubpackageStructure definition (%#v)", innerModels)
+
+	assert.Len(suite.T(), m.Properties, 3, "Can not parse StructureWithEmbededSubpackageStructure definition (%#v)", m.Properties)
+	assert.Equal(suite.T(), "int", m.Properties["Id"].Type, "Promoted field not parsed")
+	assert.Equal(suite.T(), "string", m.Properties["Name"].Type, "Promoted field not parsed")
+	assert.Equal(suite.T(), "string", m.Properties["Extra"].Type, "Field not parsed")
+}
+
+func (suite *ModelSuite) TestStructureWithEmbededSubpackagePointer() {
+	m := parser.NewModel(suite.parser)
+	err, _ := m.ParseModel("StructureWithEmbededSubpackagePointer", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithEmbededSubpackagePointer definition")
+
+	assert.Len(suite.T(), m.Properties, 2, "Can not parse StructureWithEmbededSubpackagePointer definition (%#v)", m.Properties)
+	assert.Equal(suite.T(), "int", m.Properties["Id"].Type, "Promoted field not parsed")
+	assert.Equal(suite.T(), "array", m.Properties["Name"].Type, "Promoted field should not override declared field")
+	assert.Equal(suite.T(), "string", m.Properties["Name"].Items.Type, "Promoted field should not override declared field")
+}
+
+func (suite *ModelSuite) TestStructureWithEnum() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithEnum", ExamplePackageName, suite.knownModelNames)
+	assert.Nil(suite.T(), err, "Can not parse StructureWithEnum definition")
+	assert.Len(suite.T(), innerModels, 0, "Enum type should not be parsed as a model (%#v)", innerModels)
+
+	assert.Equal(suite.T(), "string", m.Properties["Status"].Type, "Enum type not resolved to its underlying type")
+	assert.Equal(suite.T(), []string{"active", "inactive", "pending"}, m.Properties["Status"].Enum, "Enum values not parsed")
+	assert.Equal(suite.T(), []string{"OrderStatusActive", "OrderStatusInactive", "OrderStatusPending"}, m.Properties["Status"].EnumVarNames, "Enum constant names not parsed")
+}
+
+func (suite *ModelSuite) TestStructureWithMarshaler() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithMoney", ExamplePackageName, suite.knownModelNames)
+	assert.Nil(suite.T(), err, "Can not parse StructureWithMoney definition")
+	assert.Len(suite.T(), innerModels, 1, "Money model not parsed (%#v)", innerModels)
+
+	money := innerModels[0]
+	assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.Money", money.Id, "Money model has wrong id")
+	assert.Equal(suite.T(), money.Id, m.Properties["Price"].Type, "Price does not reference the Money model")
+	assert.Empty(suite.T(), money.Properties, "Fields of a type with MarshalJSON should not be introspected")
+
+	json, _ := json.Marshal(money)
+	assert.Equal(suite.T(), `{"id":"github.com.RobotsAndPencils.go-swaggerLite.example.Money","properties":{}}`, string(json), "Money should be a generic object")
+}
+
+func (suite *ModelSuite) TestComposedStructure() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("Dog", ExamplePackageName, suite.knownModelNames)
+	assert.Nil(suite.T(), err, "Can not parse Dog definition")
+	assert.Len(suite.T(), innerModels, 1, "Composed model not parsed (%#v)", innerModels)
+	assert.Equal(suite.T(), []string{"github.com.RobotsAndPencils.go-swaggerLite.example.Animal"}, m.AllOf, "Composition not parsed")
+	assert.Len(suite.T(), m.Properties, 1, "Composed properties should not be copied")
+
+	json, _ := json.Marshal(m)
+	assert.Contains(suite.T(), string(json), `"allOf":["github.com.RobotsAndPencils.go-swaggerLite.example.Animal"]`, "Composition not serialized")
+}
+
+func (suite *ModelSuite) TestParseCompositionComment() {
+	m := parser.NewModel(suite.parser)
+	assert.Nil(suite.T(), m.ParseComment("Pet", "// @OneOf Pet Cat, Page[Cat,Dog]"), "Can not parse composition comment")
+	assert.Equal(suite.T(), []string{"Cat", "Page[Cat,Dog]"}, m.OneOf, "Can not parse composition comment")
+	assert.NotNil(suite.T(), m.ParseComment("Pet", "// @AnyOf Animal Cat"), "Composition of another type should fail")
+	assert.NotNil(suite.T(), m.ParseComment("Pet", "// @AllOf Pet"), "Composition without models should fail")
+}
+
+func (suite *ModelSuite) TestDiscriminator() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("Pet", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse Pet definition")
+
+	petId := "github.com.RobotsAndPencils.go-swaggerLite.example.Pet"
+	catId := "github.com.RobotsAndPencils.go-swaggerLite.example.PetCat"
+	dogId := "github.com.RobotsAndPencils.go-swaggerLite.example.PetDog"
+	assert.Equal(suite.T(), "petType", m.Discriminator, "Discriminator not parsed")
+	assert.Equal(suite.T(), []string{catId, dogId}, m.SubTypes, "Subtypes not parsed")
+	assert.Equal(suite.T(), []string{"name", "petType"}, m.Required, "Discriminator should be required")
+	innerModelIds := []string{}
+	for _, innerModel := range innerModels {
+		innerModelIds = append(innerModelIds, innerModel.Id)
+	}
+	assert.Equal(suite.T(), []string{catId, dogId}, innerModelIds, "Subtypes should be parsed along with the model")
+	assert.Equal(suite.T(), []string{petId}, innerModels[1].AllOf, "Subtype composed of the model should reference it")
+
+	json, _ := json.Marshal(m)
+	assert.Contains(suite.T(), string(json), `"discriminator":"petType","subTypes":["`+catId+`","`+dogId+`"]`, "Discriminator not serialized")
+
+	assert.Nil(suite.T(), m.ParseComment("Pet", "// @SubTypes Cat, Dog"), "Can not parse subtypes comment")
+	assert.Equal(suite.T(), []string{catId, dogId, "Cat", "Dog"}, m.SubTypes, "Can not parse subtypes comment")
+	assert.NotNil(suite.T(), m.ParseComment("Pet", "// @Discriminator"), "Discriminator comment without property should fail")
+	assert.NotNil(suite.T(), m.ParseComment("Pet", "// @SubType"), "Subtype comment without models should fail")
+}
+
+func (suite *ModelSuite) TestStructureWithSchema() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithTimestamp", ExamplePackageName, suite.knownModelNames)
+	assert.Nil(suite.T(), err, "Can not parse StructureWithTimestamp definition")
+	assert.Len(suite.T(), innerModels, 1, "Timestamp model not parsed (%#v)", innerModels)
+
+	timestamp := innerModels[0]
+	assert.Empty(suite.T(), timestamp.Properties, "Fields of a type with @Schema should not be introspected")
+
+	json, _ := json.Marshal(timestamp)
+	assert.JSONEq(suite.T(), `{"id":"`+timestamp.Id+`","type":"string","format":"date-time"}`, string(json), "Schema should be used verbatim, with the model id")
+}
+
+func (suite *ModelSuite) TestParseSchemaComment() {
+	schema, err := parser.ParseSchemaComment(`@Schema {"type":"string"}`)
+	assert.Nil(suite.T(), err, "Can not parse schema comment")
+	assert.Equal(suite.T(), `{"type":"string"}`, string(schema), "Can not parse schema comment")
+
+	_, err = parser.ParseSchemaComment(`@Schema {"type":"string"`)
+	assert.NotNil(suite.T(), err, "Malformed schema should fail")
+	_, err = parser.ParseSchemaComment(`@Schema "string"`)
+	assert.NotNil(suite.T(), err, "Schema which is not an object should fail")
+}
+
+func (suite *ModelSuite) TestStructureWithStdlibTypes() {
+	m := parser.NewModel(suite.parser)
+	err, innerModels := m.ParseModel("StructureWithStdlibTypes", ExamplePackageName, suite.knownModelNames)
+	assert.Nil(suite.T(), err, "Can not parse StructureWithStdlibTypes definition")
+	assert.Len(suite.T(), innerModels, 0, "Well known types should not be parsed as models (%#v)", innerModels)
+
+	expected := map[string]parser.TypeMapping{
+		"CreatedAt": {Type: "string", Format: "date-time"},
+		"Timeout":   {Type: "integer", Format: "int64"},
+		"Payload":   {Type: "object"},
+		"Checksum":  {Type: "string", Format: "byte"},
+		"Uid":       {Type: "string", Format: "uuid"},
+	}
+	for name, mapping := range expected {
+		assert.Equal(suite.T(), mapping.Type, m.Properties[name].Type, "Wrong type of %s", name)
+		assert.Equal(suite.T(), mapping.Format, m.Properties[name].Format, "Wrong format of %s", name)
+	}
+
+	assert.Equal(suite.T(), "array", m.Properties["History"].Type, "Wrong type of History")
+	assert.Equal(suite.T(), parser.ModelPropertyItems{Type: "string", Format: "date-time"}, m.Properties["History"].Items, "Wrong items of History")
+}
+
+func (suite *ModelSuite) TestOverriddenTypeMapping() {
+	p := parser.NewParser()
+	p.TypeMappings["time.Time"] = parser.TypeMapping{Type: "integer", Format: "int64"}
+	p.ParseTypeDefinitions(ExamplePackageName)
+
+	m := parser.NewModel(p)
+	err, _ := m.ParseModel("StructureWithStdlibTypes", ExamplePackageName, map[string]bool{})
+	assert.Nil(suite.T(), err, "Can not parse StructureWithStdlibTypes definition")
+	assert.Equal(suite.T(), "integer", m.Properties["CreatedAt"].Type, "Type mapping not overridden")
+	assert.Equal(suite.T(), "int64", m.Properties["CreatedAt"].Format, "Type mapping not overridden")
+}
+
+func (suite *ModelSuite) TestBlankImportedModel() {
+	for packageAbsolutePath, imports := range suite.parser.PackageImports {
+		if strings.HasSuffix(packageAbsolutePath, ExamplePackageName) {
+			assert.NotContains(suite.T(), imports, "sideeffect", "Blank import should not have a local name")
+			assert.NotContains(suite.T(), imports, "_", "Blank import should not have a local name")
+		}
+	}
+
+	m := parser.NewModel(suite.parser)
+	err, _ := m.ParseModel(ExamplePackageName+"/sideeffect.Widget", ExamplePackageName, suite.knownModelNames)
+	assert.Nil(suite.T(), err, "Can not parse model from blank imported package")
+	assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.sideeffect.Widget", m.Id, "Can not parse model from blank imported package")
+	assert.Len(suite.T(), m.Properties, 2, "Can not parse model from blank imported package")
+}
+
+func (suite *ModelSuite) TestImportGraph() {
+	graph := suite.parser.ImportGraph()
+	assert.Len(suite.T(), graph, len(suite.parser.PackageImports), "Every parsed package should be in the import graph")
+
+	found := false
+	for pkgRealPath, importedPackages := range graph {
+		if strings.HasSuffix(pkgRealPath, ExamplePackageName) {
+			found = true
+			importsSubpackage := false
+			for _, importedPackage := range importedPackages {
+				importsSubpackage = importsSubpackage || strings.HasSuffix(importedPackage, ExamplePackageName+"/subpackage")
+			}
+			assert.True(suite.T(), importsSubpackage, "Wrong imports of example package: %#v", importedPackages)
+			assert.True(suite.T(), sort.StringsAreSorted(importedPackages), "Imports of example package are not sorted")
+		}
+	}
+	assert.True(suite.T(), found, "Example package not in the import graph")
+}
 
 func TestModelSuite(t *testing.T) {
 	suite.Run(t, &ModelSuite{})
@@ -0,0 +1,443 @@
+package parser
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// OpenAPI3Version is the default version of the specs built by OpenAPI3
+const OpenAPI3Version = "3.0.3"
+
+// https://spec.openapis.org/oas/v3.0.3#openapi-object
+type OpenAPI3Spec struct {
+	OpenAPI      string                                   `json:"openapi"`
+	Info         SpecInfo                                 `json:"info"`
+	Servers      []OpenAPI3Server                         `json:"servers,omitempty"`
+	Paths        map[string]map[string]*OpenAPI3Operation `json:"paths"`
+	Components   OpenAPI3Components                       `json:"components"`
+	Tags         []Tag                                    `json:"tags,omitempty"`
+	ExternalDocs *ExternalDocs                            `json:"externalDocs,omitempty"`
+	Extensions   Extensions                               `json:"-"`
+}
+
+func (spec *OpenAPI3Spec) MarshalJSON() ([]byte, error) {
+	type openAPI3Spec OpenAPI3Spec
+	object, err := json.Marshal((*openAPI3Spec)(spec))
+	if err != nil {
+		return nil, err
+	}
+	return marshalWithExtensions(object, spec.Extensions)
+}
+
+type OpenAPI3Server struct {
+	Url string `json:"url"`
+}
+
+type OpenAPI3Components struct {
+	Schemas         map[string]*Schema            `json:"schemas,omitempty"`
+	Parameters      map[string]*OpenAPI3Parameter `json:"parameters,omitempty"`
+	Responses       map[string]*OpenAPI3Response  `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme    `json:"securitySchemes,omitempty"`
+}
+
+// https://spec.openapis.org/oas/v3.0.3#operation-object
+type OpenAPI3Operation struct {
+	Tags         []string                     `json:"tags,omitempty"`
+	Summary      string                       `json:"summary,omitempty"`
+	Description  string                       `json:"description,omitempty"`
+	OperationId  string                       `json:"operationId,omitempty"`
+	Parameters   []*OpenAPI3Parameter         `json:"parameters,omitempty"`
+	RequestBody  *OpenAPI3RequestBody         `json:"requestBody,omitempty"`
+	Responses    map[string]*OpenAPI3Response `json:"responses"`
+	Deprecated   bool                         `json:"deprecated,omitempty"`
+	Security     []map[string][]string        `json:"security,omitempty"`
+	Sunset       string                       `json:"x-sunset,omitempty"`
+	Idempotent   bool                         `json:"x-idempotent,omitempty"`
+	Cacheable    string                       `json:"x-cacheable,omitempty"`
+	ExternalDocs *ExternalDocs                `json:"externalDocs,omitempty"`
+	Extensions   Extensions                   `json:"-"`
+}
+
+func (operation *OpenAPI3Operation) MarshalJSON() ([]byte, error) {
+	type openAPI3Operation OpenAPI3Operation
+	object, err := json.Marshal((*openAPI3Operation)(operation))
+	if err != nil {
+		return nil, err
+	}
+	return marshalWithExtensions(object, operation.Extensions)
+}
+
+// https://spec.openapis.org/oas/v3.0.3#parameter-object
+type OpenAPI3Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path, query or header
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	// tsv arrays have no style, their collection format is kept as in Swagger 2.0
+	CollectionFormat string `json:"x-collectionFormat,omitempty"`
+	// of a reused param, which is written as the reference alone
+	Ref string `json:"-"`
+}
+
+func (param *OpenAPI3Parameter) MarshalJSON() ([]byte, error) {
+	if param.Ref != "" {
+		return json.Marshal(map[string]string{"$ref": param.Ref})
+	}
+	type parameter OpenAPI3Parameter
+	return json.Marshal((*parameter)(param))
+}
+
+// https://spec.openapis.org/oas/v3.0.3#request-body-object
+type OpenAPI3RequestBody struct {
+	Description string                        `json:"description,omitempty"`
+	Required    bool                          `json:"required,omitempty"`
+	Content     map[string]*OpenAPI3MediaType `json:"content"`
+}
+
+// https://spec.openapis.org/oas/v3.0.3#discriminator-object
+type OpenAPI3Discriminator struct {
+	PropertyName string `json:"propertyName"`
+}
+
+type OpenAPI3MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// https://spec.openapis.org/oas/v3.0.3#response-object
+type OpenAPI3Response struct {
+	Description string                        `json:"description"`
+	Headers     map[string]*OpenAPI3Header    `json:"headers,omitempty"`
+	Content     map[string]*OpenAPI3MediaType `json:"content,omitempty"`
+	Ref         string                        `json:"-"` // of a global response, which is written as the reference alone
+}
+
+func (response *OpenAPI3Response) MarshalJSON() ([]byte, error) {
+	if response.Ref != "" {
+		return json.Marshal(map[string]string{"$ref": response.Ref})
+	}
+	type openAPI3Response OpenAPI3Response
+	return json.Marshal((*openAPI3Response)(response))
+}
+
+type OpenAPI3Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// OpenAPI3 builds a single OpenAPI 3.0 spec of the parsed API, like Swagger20. Body and form params
+// become the request body of their operation, in each of the content types the operation consumes
+func (parser *Parser) OpenAPI3() *OpenAPI3Spec {
+	parser.SortApiDescriptions()
+	const refPrefix = "#/components/schemas/"
+
+	spec := &OpenAPI3Spec{
+		OpenAPI: parser.SpecVersion(FormatOpenAPI3),
+		Info:    parser.specInfo(),
+		Paths:   make(map[string]map[string]*OpenAPI3Operation),
+		Components: OpenAPI3Components{
+			Schemas: parser.specSchemas(refPrefix),
+		},
+		Tags:         parser.Listing.Tags,
+		ExternalDocs: parser.Listing.ExternalDocs,
+		Extensions:   parser.Listing.Extensions,
+	}
+	spec.Servers = parser.specServers()
+	for _, schema := range spec.Components.Schemas {
+		schema.setOpenAPIDiscriminator()
+		schema.setOpenAPINullable()
+	}
+	for name, definition := range parser.Listing.Authorizations {
+		if spec.Components.SecuritySchemes == nil {
+			spec.Components.SecuritySchemes = make(map[string]*SecurityScheme)
+		}
+		scheme := &SecurityScheme{Type: definition.Type, In: definition.PassAs, Name: definition.Keyname}
+		if definition.Type == "basicAuth" {
+			scheme.Type = "http"
+			scheme.Scheme = "basic"
+		}
+		spec.Components.SecuritySchemes[name] = scheme
+	}
+	for name, param := range parser.ReusableParams {
+		if spec.Components.Parameters == nil {
+			spec.Components.Parameters = make(map[string]*OpenAPI3Parameter)
+		}
+		spec.Components.Parameters[name] = openAPI3Parameter(param, refPrefix)
+	}
+
+	// the global responses the operations have are defined once, in the content types of the general @Produce
+	globalProduces := parser.Produces
+	if len(globalProduces) == 0 {
+		globalProduces = []string{ContentTypeJson}
+	}
+	for _, globalResponse := range parser.GlobalResponses {
+		if global := parser.globalResponseOperations[globalResponse.Name]; global != nil {
+			if spec.Components.Responses == nil {
+				spec.Components.Responses = make(map[string]*OpenAPI3Response)
+			}
+			spec.Components.Responses[globalResponse.Name] = global.openAPI3Response(global.ResponseMessages[0], globalProduces, refPrefix)
+		}
+	}
+
+	for _, op := range parser.Operations() {
+		operation := &OpenAPI3Operation{
+			Tags:         op.Tags,
+			Summary:      op.Summary,
+			Description:  op.Notes,
+			OperationId:  op.specOperationId(),
+			Responses:    make(map[string]*OpenAPI3Response),
+			Deprecated:   op.Deprecated != "",
+			Security:     op.specSecurity(),
+			Sunset:       op.Sunset,
+			Idempotent:   op.Idempotent,
+			Cacheable:    op.Cacheable,
+			ExternalDocs: op.ExternalDocs,
+			Extensions:   op.Extensions,
+		}
+		consumes := op.Consumes
+		if len(consumes) == 0 {
+			consumes = []string{ContentTypeJson}
+		}
+		produces := op.Produces
+		if len(produces) == 0 {
+			produces = []string{ContentTypeJson}
+		}
+
+		var formSchema *Schema
+		for _, param := range op.Parameters {
+			schema := param.schema(refPrefix)
+			switch param.ParamType {
+			case "body":
+				operation.RequestBody = &OpenAPI3RequestBody{
+					Description: param.Description,
+					Required:    param.Required,
+					Content:     mediaTypes(consumes, schema),
+				}
+			case "form":
+				if formSchema == nil {
+					formSchema = &Schema{Type: "object", Properties: make(map[string]*Schema)}
+				}
+				schema.Description = param.Description
+				formSchema.Properties[param.Name] = schema
+				if param.Required {
+					formSchema.Required = append(formSchema.Required, param.Name)
+				}
+			default:
+				if param.Ref != "" {
+					operation.Parameters = append(operation.Parameters, &OpenAPI3Parameter{Ref: "#/components/parameters/" + param.Ref})
+					break
+				}
+				operation.Parameters = append(operation.Parameters, openAPI3Parameter(param, refPrefix))
+			}
+		}
+		if formSchema != nil && operation.RequestBody == nil {
+			formTypes := make([]string, 0)
+			for _, contentType := range consumes {
+				if contentType == ContentTypeForm || contentType == ContentTypeMultipart {
+					formTypes = append(formTypes, contentType)
+				}
+			}
+			if len(formTypes) == 0 {
+				formTypes = append(formTypes, ContentTypeForm)
+			}
+			operation.RequestBody = &OpenAPI3RequestBody{
+				Required: len(formSchema.Required) > 0,
+				Content:  mediaTypes(formTypes, formSchema),
+			}
+		}
+
+		for _, response := range op.ResponseMessages {
+			if _, ok := spec.Components.Responses[response.GlobalRef]; ok && response.GlobalRef != "" {
+				operation.Responses[strconv.Itoa(response.Code)] = &OpenAPI3Response{Ref: "#/components/responses/" + response.GlobalRef}
+				continue
+			}
+			operation.Responses[strconv.Itoa(response.Code)] = op.openAPI3Response(response, produces, refPrefix)
+		}
+
+		if _, ok := spec.Paths[op.Path]; !ok {
+			spec.Paths[op.Path] = make(map[string]*OpenAPI3Operation)
+		}
+		spec.Paths[op.Path][strings.ToLower(op.HttpMethod)] = operation
+	}
+	spec.Components.Schemas = parser.referencedSchemas(spec.Components.Schemas, refPrefix, spec.operationSchemas())
+	return spec
+}
+
+// operationSchemas returns the schemas of the params, request bodies and responses of the spec, which reference its
+// component schemas
+func (spec *OpenAPI3Spec) operationSchemas() []*Schema {
+	schemas := make([]*Schema, 0)
+	addContent := func(content map[string]*OpenAPI3MediaType) {
+		for _, mediaType := range content {
+			schemas = append(schemas, mediaType.Schema)
+		}
+	}
+	addResponse := func(response *OpenAPI3Response) {
+		addContent(response.Content)
+		for _, header := range response.Headers {
+			schemas = append(schemas, header.Schema)
+		}
+	}
+	for _, operations := range spec.Paths {
+		for _, operation := range operations {
+			for _, param := range operation.Parameters {
+				schemas = append(schemas, param.Schema)
+			}
+			if operation.RequestBody != nil {
+				addContent(operation.RequestBody.Content)
+			}
+			for _, response := range operation.Responses {
+				addResponse(response)
+			}
+		}
+	}
+	for _, param := range spec.Components.Parameters {
+		schemas = append(schemas, param.Schema)
+	}
+	for _, response := range spec.Components.Responses {
+		addResponse(response)
+	}
+	return schemas
+}
+
+// openAPI3Response is the response in each of the content types the operation produces, or in the one of the
+// response, and in the ones of its variants
+func (operation *Operation) openAPI3Response(response ResponseMessage, produces []string, refPrefix string) *OpenAPI3Response {
+	openAPIResponse := &OpenAPI3Response{Description: response.description()}
+	if schema := operation.responseSchema(response, refPrefix); schema != nil {
+		contentTypes := produces
+		if response.ContentType != "" {
+			contentTypes = []string{response.ContentType}
+		}
+		openAPIResponse.Content = mediaTypes(contentTypes, schema)
+	}
+	for _, variant := range response.Variants {
+		if schema := operation.responseSchema(variant, refPrefix); schema != nil {
+			if openAPIResponse.Content == nil {
+				openAPIResponse.Content = make(map[string]*OpenAPI3MediaType)
+			}
+			openAPIResponse.Content[variant.ContentType] = &OpenAPI3MediaType{Schema: schema}
+		}
+	}
+	for name, header := range response.Headers {
+		if openAPIResponse.Headers == nil {
+			openAPIResponse.Headers = make(map[string]*OpenAPI3Header)
+		}
+		openAPIResponse.Headers[name] = &OpenAPI3Header{
+			Description: header.Description,
+			Schema:      typeSchema(header.Type, refPrefix),
+		}
+	}
+	return openAPIResponse
+}
+
+func openAPI3Parameter(param Parameter, refPrefix string) *OpenAPI3Parameter {
+	openAPIParam := &OpenAPI3Parameter{
+		Name:        param.Name,
+		In:          param.ParamType,
+		Description: param.Description,
+		Required:    param.Required || param.ParamType == "path",
+		Schema:      param.schema(refPrefix),
+	}
+	openAPIParam.setStyle(param.CollectionFormat)
+	return openAPIParam
+}
+
+// specServers are the URLs of the API, one per scheme when the BasePath gives a host, see specLocation
+func (parser *Parser) specServers() []OpenAPI3Server {
+	host, basePath, schemes := parser.specLocation()
+	if host == "" {
+		if basePath == "" {
+			return nil
+		}
+		return []OpenAPI3Server{{Url: basePath}}
+	}
+	if len(schemes) == 0 {
+		return []OpenAPI3Server{{Url: "//" + host + basePath}}
+	}
+	servers := make([]OpenAPI3Server, 0, len(schemes))
+	for _, scheme := range schemes {
+		servers = append(servers, OpenAPI3Server{Url: scheme + "://" + host + basePath})
+	}
+	return servers
+}
+
+// setOpenAPINullable writes the x-nullable extension of Swagger 2.0 as the nullable field of OpenAPI 3.0, in the
+// schema and the schemas it contains. The siblings of a $ref are ignored, so a nullable reference is composed of
+// the referenced schema instead
+func (schema *Schema) setOpenAPINullable() {
+	if schema.Nullable {
+		schema.Nullable = false
+		schema.OpenAPINullable = true
+		if schema.Ref != "" {
+			schema.AllOf = []*Schema{{Ref: schema.Ref}}
+			schema.Ref = ""
+		}
+	}
+	for _, property := range schema.Properties {
+		property.setOpenAPINullable()
+	}
+	for _, schemas := range [][]*Schema{schema.AllOf, schema.OneOf, schema.AnyOf, {schema.Items, schema.AdditionalProperties}} {
+		for _, inner := range schemas {
+			if inner != nil {
+				inner.setOpenAPINullable()
+			}
+		}
+	}
+}
+
+// setOpenAPIDiscriminator writes the discriminator property name of Swagger 2.0 as the discriminator object of
+// OpenAPI 3.0, in the schema and the schemas it contains, e.g. the own schema of a composed model
+func (schema *Schema) setOpenAPIDiscriminator() {
+	if propertyName, ok := schema.Discriminator.(string); ok {
+		schema.Discriminator = &OpenAPI3Discriminator{PropertyName: propertyName}
+	}
+	for _, property := range schema.Properties {
+		property.setOpenAPIDiscriminator()
+	}
+	for _, schemas := range [][]*Schema{schema.AllOf, schema.OneOf, schema.AnyOf, {schema.Items, schema.AdditionalProperties}} {
+		for _, inner := range schemas {
+			if inner != nil {
+				inner.setOpenAPIDiscriminator()
+			}
+		}
+	}
+}
+
+// setStyle sets the style of an array parameter from its Swagger 2.0 collection format
+func (param *OpenAPI3Parameter) setStyle(collectionFormat string) {
+	explode := false
+	switch collectionFormat {
+	case "csv":
+		if param.In == "query" {
+			param.Style = "form"
+		} else {
+			param.Style = "simple"
+		}
+	case "ssv":
+		param.Style = "spaceDelimited"
+	case "pipes":
+		param.Style = "pipeDelimited"
+	case "multi":
+		param.Style = "form"
+		explode = true
+	case "tsv":
+		param.CollectionFormat = collectionFormat
+		return
+	default:
+		return
+	}
+	param.Explode = &explode
+}
+
+// mediaTypes returns the same schema for each of the content types
+func mediaTypes(contentTypes []string, schema *Schema) map[string]*OpenAPI3MediaType {
+	content := make(map[string]*OpenAPI3MediaType)
+	for _, contentType := range contentTypes {
+		content[contentType] = &OpenAPI3MediaType{Schema: schema}
+	}
+	return content
+}
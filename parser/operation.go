@@ -1,36 +1,95 @@
 package parser
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	//"go/ast"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// SunsetDateFormat is the layout expected for the optional date of a @Deprecated annotation
+const SunsetDateFormat = "2006-01-02"
+
 type Operation struct {
-	HttpMethod       string            `json:"httpMethod"`
-	Nickname         string            `json:"nickname"`
-	Type             string            `json:"type"`
-	Items            OperationItems    `json:"items,omitempty"`
-	Summary          string            `json:"summary,omitempty"`
-	Notes            string            `json:"notes,omitempty"`
-	Parameters       []Parameter       `json:"parameters,omitempty"`
-	ResponseMessages []ResponseMessage `json:"responseMessages,omitempty"`
-	Consumes         []string          `json:"-"`
-	Produces         []string          `json:"produces,omitempty"`
-	Authorizations   []Authorization   `json:"authorizations,omitempty"`
-	Protocols        []Protocol        `json:"protocols,omitempty"`
-	Path             string            `json:"-"`
-	ForceResource    string            `json:"-"`
-	parser           *Parser
-	Models           []*Model `json:"-"`
-	packageName      string
+	HttpMethod string `json:"httpMethod"`
+	Nickname   string `json:"nickname"`
+	// the operationId of Swagger 2.0 and OpenAPI 3.0, from @ID, or else the name of the handler
+	OperationId string         `json:"x-operationId,omitempty"`
+	Type        string         `json:"type"`
+	Items       OperationItems `json:"items,omitempty"`
+	Composition
+	Summary          string                          `json:"summary,omitempty"`
+	Notes            string                          `json:"notes,omitempty"`
+	Parameters       []Parameter                     `json:"parameters,omitempty"`
+	ResponseMessages []ResponseMessage               `json:"responseMessages,omitempty"`
+	Consumes         []string                        `json:"consumes,omitempty"`
+	Produces         []string                        `json:"produces,omitempty"`
+	Authorizations   map[string][]AuthorizationScope `json:"authorizations,omitempty"`
+	Protocols        []Protocol                      `json:"protocols,omitempty"`
+	Deprecated       string                          `json:"deprecated,omitempty"`
+	Sunset           string                          `json:"x-sunset,omitempty"`
+	Idempotent       bool                            `json:"x-idempotent,omitempty"`
+	Cacheable        string                          `json:"x-cacheable,omitempty"`
+	Tags             []string                        `json:"tags,omitempty"`
+	ExternalDocs     *ExternalDocs                   `json:"x-externalDocs,omitempty"`
+	Extensions       Extensions                      `json:"-"` // written as fields of the operation, see MarshalJSON
+	Path             string                          `json:"-"`
+	Handler          string                          `json:"-"` // name of the function the operation is parsed from, if known
+	Receiver         string                          `json:"-"` // name of the type the handler is a method of, e.g. "OrderController"
+	Routes           []Route                         `json:"-"` // of every @Router, the first one is also Path and HttpMethod
+	ForceResource    string                          `json:"-"`
+	// of the resource in the resource listing, from @Resource, unless it has a @SubApi description
+	ResourceDescription string `json:"-"`
+	parser              *Parser
+	Models              []*Model `json:"-"`
+	packageName         string
+	fileName            string // the file the operation is parsed from, if known
+	line                int    // the line of the handler in the file
+	// headers declared before the response they belong to
+	responseHeaders map[int]map[string]ResponseHeader
+	seenPrefixes    annotationPrefixTracker
+	// until @Produce is given, the produced types follow the accepted ones
+	producesDeclared bool
+	// the lines following @Description, until the next annotation, are its notes
+	inDescription bool
+	// blank lines of the @Description block, added to the notes once a line follows them
+	pendingBlankLines int
+	// the block of the go-swagger route comment being parsed, see parseGoSwaggerComment
+	goSwaggerBlock string
+	// the query params of the @Router paths, which a param declared after them replaces, see ParseRouterComment
+	routeQueryParams map[string]bool
+	// the RoutePrefix the Path is mounted under, see addOperation
+	routePrefix string
+}
+
+// MarshalJSON writes the vendor extensions of the operation as its fields
+func (operation *Operation) MarshalJSON() ([]byte, error) {
+	type operationFields Operation
+	object, err := json.Marshal((*operationFields)(operation))
+	if err != nil {
+		return nil, err
+	}
+	return marshalWithExtensions(object, operation.Extensions)
+}
+
+// Route is a path and http method an operation is registered at
+type Route struct {
+	Path       string
+	HttpMethod string
 }
+
 type OperationItems struct {
-	Ref  string `json:"$ref,omitempty"`
-	Type string `json:"type,omitempty"`
+	Ref    string `json:"$ref,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
 }
 
 func NewOperation(p *Parser, packageName string) *Operation {
@@ -45,47 +104,133 @@ func (operation *Operation) SetItemsType(itemsType string) {
 	operation.Items = OperationItems{}
 	if IsBasicType(itemsType) {
 		operation.Items.Type = itemsType
+		operation.Items.Format = ParamFormat(itemsType)
 	} else {
 		operation.Items.Ref = itemsType
 	}
 }
 
-func (operation *Operation) ParseComment(comment string) error {
-	commentLine := strings.TrimSpace(strings.TrimLeft(comment, "//"))
-	attribute := strings.ToLower(strings.Split(commentLine, " ")[0])
+func (operation *Operation) ParseComment(comment string) (err error) {
+	defer recoverFatal(&err)
+	return operation.parseComment(comment)
+}
+
+func (operation *Operation) parseComment(comment string) error {
+	if operation.seenPrefixes == nil {
+		operation.seenPrefixes = make(annotationPrefixTracker)
+	}
+	trimmedComment := strings.TrimSpace(strings.TrimLeft(comment, "//"))
+	if operation.parser.GoSwaggerAnnotations {
+		if isGoSwagger, err := operation.parseGoSwaggerComment(trimmedComment); isGoSwagger || err != nil {
+			return err
+		}
+	}
+	if operation.inDescription {
+		if _, prefix := NormalizeAnnotation(trimmedComment, operation.parser.AnnotationPrefixes); prefix == "" {
+			operation.appendNotesLine(trimmedComment)
+			return nil
+		}
+		operation.inDescription = false
+	}
+	commentLine, attribute := operation.parser.parseAnnotation(trimmedComment, operation.seenPrefixes)
 	switch attribute {
-	case "@router":
+	case "@router", "@route":
 		if err := operation.ParseRouterComment(commentLine); err != nil {
 			return err
 		}
 	case "@resource":
-		resource := strings.TrimSpace(commentLine[len("@Resource"):])
-		if resource[0:1] == "/" {
-			resource = resource[1:]
+		if err := operation.ParseResourceComment(commentLine); err != nil {
+			return err
 		}
-		operation.ForceResource = resource
 	case "@title":
 		operation.Nickname = strings.TrimSpace(commentLine[len("@Title"):])
 	case "@description":
 		operation.Summary = strings.TrimSpace(commentLine[len("@Description"):])
+		operation.inDescription = true
+	case "@descriptionfile":
+		if err := operation.ParseDescriptionFileComment(commentLine); err != nil {
+			return err
+		}
 	case "@success":
 		sourceString := strings.TrimSpace(commentLine[len("@Success"):])
-		if err := operation.ParseResponseComment(sourceString); err != nil {
+		if err := operation.parseResponseComment(sourceString); err != nil {
 			return err
 		}
 	case "@param":
-		if err := operation.ParseParamComment(commentLine); err != nil {
+		if err := operation.parseParamComment(commentLine); err != nil {
+			return err
+		}
+	case "@params":
+		if err := operation.parseParamsComment(commentLine); err != nil {
+			return err
+		}
+	case "@useparam":
+		if err := operation.ParseUseParamComment(commentLine); err != nil {
 			return err
 		}
 	case "@failure":
 		sourceString := strings.TrimSpace(commentLine[len("@Failure"):])
-		if err := operation.ParseResponseComment(sourceString); err != nil {
+		if err := operation.parseResponseComment(sourceString); err != nil {
 			return err
 		}
 	case "@accept":
 		if err := operation.ParseAcceptComment(commentLine); err != nil {
 			return err
 		}
+	case "@produce", "@produces":
+		if err := operation.ParseProduceComment(commentLine); err != nil {
+			return err
+		}
+	case "@header":
+		if err := operation.ParseHeaderComment(commentLine); err != nil {
+			return err
+		}
+	case "@batchitem":
+		if err := operation.ParseBatchItemComment(commentLine); err != nil {
+			return err
+		}
+	case "@security":
+		if err := operation.ParseSecurityComment(commentLine); err != nil {
+			return err
+		}
+	case "@deprecated":
+		if err := operation.ParseDeprecatedComment(commentLine); err != nil {
+			return err
+		}
+	case "@tags":
+		if err := operation.ParseTagsComment(commentLine); err != nil {
+			return err
+		}
+	case "@id":
+		if err := operation.ParseIdComment(commentLine); err != nil {
+			return err
+		}
+	case "@externaldocs":
+		externalDocs, err := ParseExternalDocsComment(commentLine)
+		if err != nil {
+			return err
+		}
+		operation.ExternalDocs = externalDocs
+	case "@idempotent":
+		operation.Idempotent = true
+	case "@cacheable":
+		if err := operation.ParseCacheableComment(commentLine); err != nil {
+			return err
+		}
+	default:
+		if strings.HasPrefix(attribute, "@x-") {
+			if err := operation.Extensions.add(commentLine); err != nil {
+				return err
+			}
+		} else if shorthand, ok := operation.parser.ParamShorthands[attribute]; ok {
+			paramString, err := shorthand(strings.TrimSpace(commentLine[len(attribute):]))
+			if err != nil {
+				return fmt.Errorf("Can not parse %s comment \"%s\": %v", attribute, commentLine, err)
+			}
+			if err := operation.parseParamComment("@Param " + paramString); err != nil {
+				return err
+			}
+		}
 	}
 
 	operation.Models = operation.getUniqueModels()
@@ -93,6 +238,52 @@ func (operation *Operation) ParseComment(comment string) error {
 	return nil
 }
 
+// appendNotesLine adds a line of a multi-line @Description block to the notes. Blank lines are kept between
+// paragraphs, but not before the first line or after the last one
+//
+//	@Description Get an order
+//	The order is looked up by its number.
+//
+//	Cancelled orders are returned as well.
+func (operation *Operation) appendNotesLine(line string) {
+	if line == "" {
+		if operation.Notes != "" {
+			operation.pendingBlankLines++
+		}
+		return
+	}
+	if operation.Notes != "" {
+		operation.Notes += strings.Repeat("\n", operation.pendingBlankLines+1)
+	}
+	operation.pendingBlankLines = 0
+	operation.Notes += line
+}
+
+// @DescriptionFile ./docs/create_user.md
+//
+// Loads the notes of the operation from a file, e.g. markdown too long for the comment, relative to the directory
+// of the file the operation is parsed from. The notes are replaced by the content of the file. A relative path fails
+// for an operation which is not parsed from a file, e.g. one made by NewOperation
+func (operation *Operation) ParseDescriptionFileComment(commentLine string) error {
+	descriptionFile := strings.TrimSpace(commentLine[len("@DescriptionFile"):])
+	if descriptionFile == "" {
+		return fmt.Errorf("Can not parse description file comment \"%s\", expected the path of a file.", commentLine)
+	}
+	if !filepath.IsAbs(descriptionFile) {
+		if operation.fileName == "" {
+			return fmt.Errorf("Can not parse description file comment \"%s\", the operation is not parsed from a file the path is relative to.", commentLine)
+		}
+		descriptionFile = filepath.Join(filepath.Dir(operation.fileName), descriptionFile)
+	}
+	description, err := ioutil.ReadFile(descriptionFile)
+	if err != nil {
+		return fmt.Errorf("Can not parse description file comment \"%s\", can not read %s: %v.", commentLine, descriptionFile, err)
+	}
+	operation.Notes = strings.TrimSpace(string(description))
+	operation.pendingBlankLines = 0
+	return nil
+}
+
 func (operation *Operation) getUniqueModels() []*Model {
 
 	uniqueModels := make([]*Model, 0, len(operation.Models))
@@ -112,74 +303,856 @@ func (operation *Operation) getUniqueModels() []*Model {
 // Parse params return []string of param properties
 // @Param	queryText		form	      string	  true		        "The email for login"
 // 			[param name]    [param type] [data type]  [is mandatory?]   [Comment]
-func (operation *Operation) ParseParamComment(commentLine string) error {
+func (operation *Operation) ParseParamComment(commentLine string) (err error) {
+	defer recoverFatal(&err)
+	return operation.parseParamComment(commentLine)
+}
+
+func (operation *Operation) parseParamComment(commentLine string) error {
 	swaggerParameter := Parameter{}
 	paramString := strings.TrimSpace(commentLine[len("@Param "):])
 
-	re := regexp.MustCompile(`([-\w]+)[\s]+([\w]+)[\s]+([\w.]+)[\s]+([\w]+)[\s]+"([^"]+)"`)
+	re := regexp.MustCompile(`([-\w]+)[\s]+([\w]+)[\s]+(?:(\{\w+\})[\s]+)?([-\w./,\[\]]+)[\s]+([\w]+)[\s]+"([^"]+)"`)
 
-	if matches := re.FindStringSubmatch(paramString); len(matches) != 6 {
+	if matches := re.FindStringSubmatch(paramString); len(matches) != 7 {
 		return fmt.Errorf("Can not parse param comment \"%s\", skipped.", paramString)
 	} else {
-		//TODO: if type is not simple, then add to Models[]
 		swaggerParameter.Name = matches[1]
 		swaggerParameter.ParamType = matches[2]
-		swaggerParameter.Type = matches[3]
-		swaggerParameter.DataType = matches[3]
-		requiredText := strings.ToLower(matches[4])
+		requiredText := strings.ToLower(matches[5])
 		swaggerParameter.Required = (requiredText == "true" || requiredText == "required")
-		swaggerParameter.Description = matches[5]
+		swaggerParameter.Description = matches[6]
+
+		if matches[3] != "" {
+			if swaggerParameter.ParamType != "body" {
+				return fmt.Errorf("Can not parse param comment \"%s\", only body params can be composed.", paramString)
+			}
+			composition, err := operation.parseComposition(matches[3], matches[4])
+			if err != nil {
+				return err
+			}
+			swaggerParameter.Composition = composition
+		} else if swaggerParameter.ParamType == "body" {
+			if err := operation.parseBodyParamType(&swaggerParameter, matches[4]); err != nil {
+				return err
+			}
+		} else if elementType := strings.TrimPrefix(matches[4], "[]"); elementType != matches[4] {
+			if !IsBasicType(elementType) {
+				// an array of a named primitive type, e.g. []models.OrderStatus
+				element := Parameter{}
+				if strings.HasPrefix(elementType, "[]") || operation.parseNamedParamType(&element, elementType, operation.parser.CurrentPackage) != nil {
+					return fmt.Errorf("Can not parse param comment \"%s\", only body params can be arrays of models or arrays.", paramString)
+				}
+				elementType = element.Type
+				swaggerParameter.Enum = element.Enum
+			}
+			swaggerParameter.Type = "array"
+			swaggerParameter.DataType = "array"
+			swaggerParameter.Items = &OperationItems{Type: elementType}
+			swaggerParameter.AllowMultiple = true
+		} else if !IsBasicType(matches[4]) {
+			if err := operation.parseNamedParamType(&swaggerParameter, matches[4], operation.parser.CurrentPackage); err != nil {
+				return fmt.Errorf("Can not parse param comment \"%s\", %v.", paramString, err)
+			}
+		} else {
+			swaggerParameter.Type = matches[4]
+			swaggerParameter.DataType = matches[4]
+		}
 
-		operation.Parameters = append(operation.Parameters, swaggerParameter)
+		clauses := paramString[strings.Index(paramString, matches[0])+len(matches[0]):]
+		if err := swaggerParameter.ParseClauses(clauses); err != nil {
+			return fmt.Errorf("Can not parse param comment \"%s\": %v", paramString, err)
+		}
+		if swaggerParameter.Type == "array" && swaggerParameter.ParamType != "body" && swaggerParameter.CollectionFormat == "" {
+			swaggerParameter.CollectionFormat = "csv"
+		}
+		if swaggerParameter.Format == "" {
+			swaggerParameter.Format = ParamFormat(swaggerParameter.Type)
+		}
+
+		operation.addParameter(swaggerParameter)
 	}
 
 	return nil
 }
 
+// addParameter adds a param to the operation, or replaces the query param of the same name of a @Router path
+func (operation *Operation) addParameter(param Parameter) {
+	if param.ParamType == "query" && operation.routeQueryParams[param.Name] {
+		delete(operation.routeQueryParams, param.Name)
+		for i := range operation.Parameters {
+			if existing := operation.Parameters[i]; existing.ParamType == "query" && existing.Name == param.Name {
+				operation.Parameters[i] = param
+				return
+			}
+		}
+	}
+	operation.Parameters = append(operation.Parameters, param)
+}
+
+// ParseBodyParamType sets the type of a body param, which references a model, e.g. "models.User",
+// or is an array of them or of a basic type, e.g. "[]models.User"
+func (operation *Operation) ParseBodyParamType(param *Parameter, typeName string) (err error) {
+	defer recoverFatal(&err)
+	return operation.parseBodyParamType(param, typeName)
+}
+
+func (operation *Operation) parseBodyParamType(param *Parameter, typeName string) error {
+	typeName = operation.parser.resolveType(typeName, operation.parser.CurrentPackage)
+	elementType := strings.TrimPrefix(typeName, "[]")
+	if IsBasicType(elementType) {
+		if elementType == typeName {
+			param.Type = typeName
+			param.DataType = typeName
+			return nil
+		}
+		param.Type = "array"
+		param.DataType = "array"
+		param.Items = &OperationItems{Type: elementType}
+		return nil
+	}
+
+	model := NewModel(operation.parser)
+	knownModelNames := map[string]bool{}
+	err, innerModels := model.parseModel(elementType, operation.parser.CurrentPackage, knownModelNames)
+	if err != nil {
+		return err
+	}
+	operation.Models = append(operation.Models, model)
+	operation.Models = append(operation.Models, innerModels...)
+
+	if elementType == typeName {
+		param.Type = model.Id
+		param.DataType = model.Id
+	} else {
+		param.Type = "array"
+		param.DataType = "array"
+		param.Items = &OperationItems{Ref: model.Id}
+	}
+	return nil
+}
+
+// ParseNamedParamType sets the type of a path, query, header or form param declared with a named type to the
+// primitive the type is declared as, e.g. string for "models.UUID" declared as type UUID string, with the enum
+// of its constants. The types of TypeMappings, e.g. uuid.UUID, are documented as they are mapped
+func (operation *Operation) ParseNamedParamType(param *Parameter, typeName string) (err error) {
+	defer recoverFatal(&err)
+	return operation.parseNamedParamType(param, typeName, operation.parser.CurrentPackage)
+}
+
+func (operation *Operation) parseNamedParamType(param *Parameter, typeName string, currentPackage string) error {
+	if mapping, ok := operation.parser.typeMapping(typeName, currentPackage); ok {
+		if mapping.Type == "object" || mapping.Type == "array" {
+			return fmt.Errorf("%s is documented as %s, not a primitive", typeName, mapping.Type)
+		}
+		param.Type = mapping.Type
+		param.DataType = mapping.Type
+		param.Format = mapping.Format
+		return nil
+	}
+
+	typeSpec, modelPackage := operation.parser.lookupTypeSpec(typeName, currentPackage)
+	if typeSpec == nil {
+		return fmt.Errorf("can not find the definition of %s", typeName)
+	}
+	if underlyingType, enumValues, _ := operation.parser.findEnumValues(typeName, currentPackage); underlyingType != "" {
+		param.Type = underlyingType
+		param.DataType = underlyingType
+		param.Enum = enumValues
+		return nil
+	}
+	underlyingType := NewModelProperty().GetTypeAsString(typeSpec.Type)
+	switch {
+	case IsBasicType(underlyingType) && !strings.Contains(underlyingType, "interface"):
+		param.Type = underlyingType
+		param.DataType = underlyingType
+		return nil
+	case strings.HasPrefix(underlyingType, "[]") || strings.HasPrefix(underlyingType, "map[") || IsBasicType(underlyingType):
+		return fmt.Errorf("%s is not a primitive type", typeName)
+	}
+	// a named type declared with another one, e.g. type OrderID ids.ID
+	return operation.parseNamedParamType(param, underlyingType, modelPackage)
+}
+
+// ParamShorthand expands the arguments of a shorthand annotation into the arguments of a @Param annotation,
+// e.g. `id int64 "User ID"` of @PathID into `id path int64 true "User ID"`
+type ParamShorthand func(args string) (string, error)
+
+// DefaultParamShorthands returns the built-in shorthands, by the lowercase name of their annotation
+//
+//	@PathID id int64 "User ID"
+//	@QueryPage
+//	@QueryLimit "Users per page"
+func DefaultParamShorthands() map[string]ParamShorthand {
+	return map[string]ParamShorthand{
+		"@pathid": func(args string) (string, error) {
+			re := regexp.MustCompile(`^([-\w]+)[\s]+([\w]+)(.*)`)
+			matches := re.FindStringSubmatch(args)
+			if matches == nil {
+				return "", errors.New("expected name and type of the path parameter")
+			}
+			return fmt.Sprintf("%s path %s true %s", matches[1], matches[2], shorthandDescription(matches[3], "ID")), nil
+		},
+		"@querypage": func(args string) (string, error) {
+			return "page query int false " + shorthandDescription(args, "Page number"), nil
+		},
+		"@querylimit": func(args string) (string, error) {
+			return "limit query int false " + shorthandDescription(args, "Maximum number of items"), nil
+		},
+	}
+}
+
+// shorthandDescription returns the quoted description and clauses of a shorthand annotation,
+// with the default description if it has none
+func shorthandDescription(rest string, defaultDescription string) string {
+	if rest = strings.TrimSpace(rest); strings.HasPrefix(rest, "\"") {
+		return rest
+	}
+	return strings.TrimSpace(fmt.Sprintf("\"%s\" %s", defaultDescription, rest))
+}
+
+// Parse the optional clauses following the param description
+// Enums(active, inactive, pending) default(active) format(uuid) example(pending)
+// minimum(1) maximum(100) minLength(3) maxLength(50) pattern(^[a-z]+$) collectionFormat(multi)
+func (parameter *Parameter) ParseClauses(clauses string) error {
+	// the value may have a level of nested parentheses, e.g. pattern(^(get|set)[A-Z]\w*$)
+	re := regexp.MustCompile(`(\w+)\(((?:[^()]|\([^()]*\))*)\)`)
+	for _, clause := range re.FindAllStringSubmatch(clauses, -1) {
+		switch strings.ToLower(clause[1]) {
+		case "enums":
+			for _, value := range strings.Split(clause[2], ",") {
+				if value = strings.TrimSpace(value); value != "" {
+					parameter.Enum = append(parameter.Enum, value)
+				}
+			}
+		case "default":
+			defaultValue, err := CoerceLiteral(strings.TrimSpace(clause[2]), parameter.DataType)
+			if err != nil {
+				return fmt.Errorf("invalid default %s: %v", clause[0], err)
+			}
+			parameter.Default = defaultValue
+		case "example":
+			example, err := ParseExample(clause[2], parameter.DataType)
+			if err != nil {
+				return fmt.Errorf("invalid example %s: %v", clause[0], err)
+			}
+			parameter.Example = example
+		case "format":
+			format := strings.TrimSpace(clause[2])
+			if !regexp.MustCompile(`^[-\w]+$`).MatchString(format) {
+				return fmt.Errorf("invalid format %s", clause[0])
+			}
+			parameter.Format = format
+		case "minimum", "maximum":
+			if !IsNumericType(parameter.Type) {
+				return fmt.Errorf("%s only applies to numeric types, not %s", clause[0], parameter.Type)
+			}
+			bound, err := strconv.ParseFloat(strings.TrimSpace(clause[2]), 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", clause[0], err)
+			}
+			if strings.ToLower(clause[1]) == "minimum" {
+				parameter.Minimum = &bound
+			} else {
+				parameter.Maximum = &bound
+			}
+		case "minlength", "maxlength":
+			if parameter.Type != "string" {
+				return fmt.Errorf("%s only applies to strings, not %s", clause[0], parameter.Type)
+			}
+			length, err := strconv.Atoi(strings.TrimSpace(clause[2]))
+			if err != nil || length < 0 {
+				return fmt.Errorf("invalid %s, expected a non-negative integer", clause[0])
+			}
+			if strings.ToLower(clause[1]) == "minlength" {
+				parameter.MinLength = &length
+			} else {
+				parameter.MaxLength = &length
+			}
+		case "pattern":
+			if parameter.Type != "string" {
+				return fmt.Errorf("%s only applies to strings, not %s", clause[0], parameter.Type)
+			}
+			if _, err := regexp.Compile(clause[2]); err != nil {
+				return fmt.Errorf("invalid %s: %v", clause[0], err)
+			}
+			parameter.Pattern = clause[2]
+		case "collectionformat":
+			if parameter.Type != "array" || parameter.ParamType == "body" {
+				return fmt.Errorf("%s only applies to arrays which are not body params", clause[0])
+			}
+			switch collectionFormat := strings.TrimSpace(clause[2]); collectionFormat {
+			case "csv", "ssv", "tsv", "pipes":
+				parameter.CollectionFormat = collectionFormat
+			case "multi":
+				if parameter.ParamType != "query" && parameter.ParamType != "form" {
+					return fmt.Errorf("%s only applies to query and form params", clause[0])
+				}
+				parameter.CollectionFormat = collectionFormat
+			default:
+				return fmt.Errorf("invalid %s, expected csv, ssv, tsv, pipes or multi", clause[0])
+			}
+		default:
+			return fmt.Errorf("unknown clause %s", clause[0])
+		}
+	}
+	if parameter.Minimum != nil && parameter.Maximum != nil && *parameter.Minimum > *parameter.Maximum {
+		return fmt.Errorf("minimum %v is greater than maximum %v", *parameter.Minimum, *parameter.Maximum)
+	}
+	if parameter.MinLength != nil && parameter.MaxLength != nil && *parameter.MinLength > *parameter.MaxLength {
+		return fmt.Errorf("minLength %d is greater than maxLength %d", *parameter.MinLength, *parameter.MaxLength)
+	}
+	return nil
+}
+
+// IsNumericType reports whether typeName is a Go or Swagger numeric type, e.g. "int64" or "number"
+func IsNumericType(typeName string) bool {
+	switch typeName {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "byte",
+		"float32", "float64", "integer", "number":
+		return true
+	}
+	return false
+}
+
+// ParamFormat returns the format inferred from the Go type of a param, e.g. "int64" of an int64,
+// or an empty string if the type does not imply one
+func ParamFormat(typeName string) string {
+	switch typeName {
+	case "int64", "uint64":
+		return "int64"
+	case "int32", "uint32":
+		return "int32"
+	}
+	return ""
+}
+
+// ParseExample converts the example of a param or field to a value of its type, like CoerceLiteral.
+// An example starting with "[" or "{" is a JSON array or object, e.g. [1, 2] or {"name": "Bob"}
+func ParseExample(literal string, typeName string) (interface{}, error) {
+	literal = strings.TrimSpace(literal)
+	if literal == "" {
+		return nil, errors.New("empty example")
+	}
+	if strings.HasPrefix(literal, "[") || strings.HasPrefix(literal, "{") {
+		var example interface{}
+		if err := json.Unmarshal([]byte(literal), &example); err != nil {
+			return nil, err
+		}
+		return example, nil
+	}
+	return CoerceLiteral(literal, typeName)
+}
+
+// CoerceLiteral converts the literal of an annotation to a value of the Go type typeName, e.g. 20 for "20" of an int.
+// Literals of other than numeric and bool types are kept as strings, without their quotes.
+func CoerceLiteral(literal string, typeName string) (interface{}, error) {
+	switch typeName {
+	case "int", "int8", "int16", "int32", "int64", "integer":
+		return strconv.ParseInt(literal, 10, 64)
+	case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+		return strconv.ParseUint(literal, 10, 64)
+	case "float32", "float64", "number":
+		return strconv.ParseFloat(literal, 64)
+	case "bool", "boolean":
+		return strconv.ParseBool(literal)
+	}
+	if unquoted, err := strconv.Unquote(literal); err == nil {
+		return unquoted, nil
+	}
+	return literal, nil
+}
+
+// Parse a field of the sub-requests of a batch operation. The fields build the OrderBatchItem model,
+// and the operation accepts an array of them as its body
+// @BatchItem	OrderBatchItem	method		string		true		"HTTP method of the sub-request"
+//
+//	[model name]	[field name]	[data type]	[is mandatory?]	[Comment]
+func (operation *Operation) ParseBatchItemComment(commentLine string) error {
+	batchItemString := strings.TrimSpace(commentLine[len("@BatchItem"):])
+
+	re := regexp.MustCompile(`^([\w]+)[\s]+([-\w]+)[\s]+([\w.\[\]]+)[\s]+([\w]+)[\s]+"([^"]+)"`)
+	matches := re.FindStringSubmatch(batchItemString)
+	if len(matches) != 6 {
+		return fmt.Errorf("Can not parse batch item comment \"%s\", skipped.", batchItemString)
+	}
+	modelName, fieldName, dataType := matches[1], matches[2], matches[3]
+	// the model is named after the package of the operation, like the models of the types declared there
+	modelId := operation.parser.ModelName(operation.packageName, modelName)
+
+	property := NewModelProperty()
+	if strings.HasPrefix(dataType, "[]") {
+		property.Type = "array"
+		property.SetItemType(dataType[2:])
+	} else {
+		property.Type = dataType
+	}
+	if !IsBasicType(property.Type) && (property.Type != "array" || property.Items.Innermost().Ref != "") {
+		return fmt.Errorf("Can not parse batch item comment \"%s\", only basic types are supported.", batchItemString)
+	}
+	property.Description = matches[5]
+
+	var batchItem *Model
+	for _, model := range operation.Models {
+		if model.Id == modelId {
+			batchItem = model
+			break
+		}
+	}
+	if batchItem == nil {
+		batchItem = NewModel(operation.parser)
+		batchItem.Id = modelId
+		batchItem.Properties = make(map[string]*ModelProperty)
+		operation.Models = append(operation.Models, batchItem)
+
+		operation.Parameters = append(operation.Parameters, Parameter{
+			ParamType:   "body",
+			Name:        "body",
+			Description: "Batch of " + modelName,
+			DataType:    "array",
+			Type:        "array",
+			Required:    true,
+			Items:       &OperationItems{Ref: modelId},
+		})
+	}
+
+	requiredText := strings.ToLower(matches[4])
+	if requiredText == "true" || requiredText == "required" {
+		batchItem.Required = append(batchItem.Required, fieldName)
+	}
+	batchItem.Properties[fieldName] = property
+	return nil
+}
+
 // @Accept  json
 func (operation *Operation) ParseAcceptComment(commentLine string) error {
-	accepts := strings.Split(strings.TrimSpace(strings.TrimSpace(commentLine[len("@Accept"):])), ",")
-	for _, a := range accepts {
-		switch a {
+	contentTypes, err := ParseContentTypes(commentLine[len("@Accept"):])
+	if err != nil {
+		return fmt.Errorf("Can not parse accept comment \"%s\", %v.", commentLine, err)
+	}
+	operation.Consumes = appendContentTypes(operation.Consumes, contentTypes)
+	if !operation.producesDeclared {
+		operation.Produces = appendContentTypes(operation.Produces, contentTypes)
+	}
+	return nil
+}
+
+// @Produce json,xml
+func (operation *Operation) ParseProduceComment(commentLine string) error {
+	attribute := strings.Fields(commentLine)[0]
+	contentTypes, err := ParseContentTypes(commentLine[len(attribute):])
+	if err != nil {
+		return fmt.Errorf("Can not parse produce comment \"%s\", %v.", commentLine, err)
+	}
+	if !operation.producesDeclared {
+		operation.Produces = nil
+		operation.producesDeclared = true
+	}
+	operation.Produces = appendContentTypes(operation.Produces, contentTypes)
+	return nil
+}
+
+// SetDefaultContentTypes makes an operation without @Accept or @Produce consume and produce the content types
+// of the general @Accept and @Produce, or else JSON. A general @Produce wins over the accepted types of the operation
+func (operation *Operation) SetDefaultContentTypes() {
+	if len(operation.Consumes) == 0 {
+		operation.Consumes = appendContentTypes(nil, operation.parser.Consumes)
+	}
+	if !operation.producesDeclared && len(operation.parser.Produces) > 0 {
+		operation.Produces = appendContentTypes(nil, operation.parser.Produces)
+	}
+	if len(operation.Consumes) == 0 {
+		operation.Consumes = []string{ContentTypeJson}
+	}
+	if len(operation.Produces) == 0 {
+		operation.Produces = []string{ContentTypeJson}
+	}
+}
+
+// ParseContentTypes parses a comma separated list of media types, or of their shorthands like "json"
+func ParseContentTypes(list string) ([]string, error) {
+	contentTypes := make([]string, 0)
+	for _, contentType := range strings.Split(list, ",") {
+		contentType = strings.TrimSpace(contentType)
+		switch strings.ToLower(contentType) {
+		case "":
+			continue
 		case "json", "application/json":
-			operation.Consumes = append(operation.Consumes, ContentTypeJson)
-			operation.Produces = append(operation.Produces, ContentTypeJson)
-		case "xml", "text/xml":
-			operation.Consumes = append(operation.Consumes, ContentTypeXml)
-			operation.Produces = append(operation.Produces, ContentTypeXml)
+			contentType = ContentTypeJson
+		case "xml", "text/xml", "application/xml":
+			contentType = ContentTypeXml
 		case "plain", "text/plain":
-			operation.Consumes = append(operation.Consumes, ContentTypePlain)
-			operation.Produces = append(operation.Produces, ContentTypePlain)
+			contentType = ContentTypePlain
 		case "html", "text/html":
-			operation.Consumes = append(operation.Consumes, ContentTypeHtml)
-			operation.Produces = append(operation.Produces, ContentTypeHtml)
+			contentType = ContentTypeHtml
+		case "form", "x-www-form-urlencoded", "application/x-www-form-urlencoded":
+			contentType = ContentTypeForm
+		case "multipart", "mpfd", "multipart/form-data":
+			contentType = ContentTypeMultipart
+		case "octet-stream", "binary", "application/octet-stream":
+			contentType = ContentTypeOctetStream
+		default:
+			if !strings.Contains(contentType, "/") {
+				return nil, fmt.Errorf("unknown content type \"%s\"", contentType)
+			}
+		}
+		contentTypes = append(contentTypes, contentType)
+	}
+	if len(contentTypes) == 0 {
+		return nil, errors.New("no content type given")
+	}
+	return contentTypes, nil
+}
+
+// ParseExternalDocsComment parses an @ExternalDocs annotation, of the API or of an operation
+// @ExternalDocs https://example.com/docs "More info"
+func ParseExternalDocsComment(commentLine string) (*ExternalDocs, error) {
+	re := regexp.MustCompile(`^\S+[\s]+(\S+)(?:[\s]+"([^"]*)")?[\s]*$`)
+	matches := re.FindStringSubmatch(commentLine)
+	if matches == nil {
+		return nil, fmt.Errorf("Can not parse external docs comment \"%s\", expected a URL and an optional quoted description.", commentLine)
+	}
+	if docsUrl, err := url.Parse(matches[1]); err != nil || !docsUrl.IsAbs() || docsUrl.Host == "" {
+		return nil, fmt.Errorf("Can not parse external docs comment \"%s\", %s is not an absolute URL.", commentLine, matches[1])
+	}
+	return &ExternalDocs{Url: matches[1], Description: matches[2]}, nil
+}
+
+func appendContentTypes(contentTypes []string, newContentTypes []string) []string {
+	for _, newContentType := range newContentTypes {
+		exists := false
+		for _, contentType := range contentTypes {
+			if contentType == newContentType {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			contentTypes = append(contentTypes, newContentType)
+		}
+	}
+	return contentTypes
+}
+
+// @UseParam limit,offset
+//
+// Adds params declared with @Parameter in the general API info, see ParseParameterDefinition
+func (operation *Operation) ParseUseParamComment(commentLine string) error {
+	names := strings.Split(commentLine[len("@UseParam"):], ",")
+	if strings.TrimSpace(strings.Join(names, "")) == "" {
+		return fmt.Errorf("Can not parse use param comment \"%s\", expected the names of the params.", commentLine)
+	}
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		param, ok := operation.parser.ReusableParams[name]
+		if !ok {
+			return fmt.Errorf("Can not parse use param comment \"%s\", %s is not declared with @Parameter.", commentLine, name)
 		}
+		param.Ref = name
+		operation.addParameter(param)
 	}
 	return nil
 }
 
+// @Security oauth write:orders,read:orders
+func (operation *Operation) ParseSecurityComment(commentLine string) error {
+	fields := strings.Fields(commentLine[len("@Security"):])
+	if len(fields) == 0 || len(fields) > 2 {
+		return fmt.Errorf("Can not parse security comment \"%s\", skipped.", commentLine)
+	}
+
+	scopes := make([]AuthorizationScope, 0)
+	if len(fields) == 2 {
+		for _, scope := range strings.Split(fields[1], ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				scopes = append(scopes, AuthorizationScope{Scope: scope})
+			}
+		}
+	}
+
+	if operation.Authorizations == nil {
+		operation.Authorizations = make(map[string][]AuthorizationScope)
+	}
+	operation.Authorizations[fields[0]] = scopes
+	return nil
+}
+
+// @Deprecated 2025-12-31
+func (operation *Operation) ParseDeprecatedComment(commentLine string) error {
+	sunset := strings.TrimSpace(commentLine[len("@Deprecated"):])
+	if sunset != "" {
+		if _, err := time.Parse(SunsetDateFormat, sunset); err != nil {
+			return fmt.Errorf("Can not parse deprecated comment \"%s\", sunset date must be in YYYY-MM-DD format.", commentLine)
+		}
+		operation.Sunset = sunset
+	}
+	operation.Deprecated = "true"
+	return nil
+}
+
+// @ID createUser
+func (operation *Operation) ParseIdComment(commentLine string) error {
+	fields := strings.Fields(commentLine[len("@ID"):])
+	if len(fields) != 1 {
+		return fmt.Errorf("Can not parse id comment \"%s\", expected a single operation id.", commentLine)
+	}
+	operation.OperationId = fields[0]
+	return nil
+}
+
+// specOperationId is the operationId of the operation in Swagger 2.0 and OpenAPI 3.0. An operation
+// without a handler, e.g. one added with AddOperation, is identified by its nickname
+func (operation *Operation) specOperationId() string {
+	if operation.OperationId != "" {
+		return operation.OperationId
+	}
+	return operation.Nickname
+}
+
+// @Cacheable 5m
+func (operation *Operation) ParseCacheableComment(commentLine string) error {
+	ttl := strings.TrimSpace(commentLine[len("@Cacheable"):])
+	if duration, err := time.ParseDuration(ttl); err != nil || duration <= 0 {
+		return fmt.Errorf("Can not parse cacheable comment \"%s\", ttl must be a positive duration, e.g. 30s or 5m.", commentLine)
+	}
+	operation.Cacheable = ttl
+	return nil
+}
+
+// @Resource /payment Payment management API
+//
+// The description of the resource is optional, a @SubApi one takes precedence over it
+func (operation *Operation) ParseResourceComment(commentLine string) error {
+	fields := strings.Fields(commentLine[len("@Resource"):])
+	if len(fields) == 0 || strings.Trim(fields[0], "/") == "" {
+		return fmt.Errorf("Can not parse resource comment \"%s\", expected the name of a resource.", commentLine)
+	}
+	operation.ForceResource = strings.TrimPrefix(fields[0], "/")
+	operation.ResourceDescription = strings.Join(fields[1:], " ")
+	return nil
+}
+
 // @Router /customer/get-wishlist/{wishlist_id} [get]
+// @Router /users?active={active}&sort={sort} [get]
+//
+// The query params of the path are added as optional string query params, unless they are declared already. A
+// param declared after the @Router replaces the one of the same name. The path of the operation is the part before
+// the query
 func (operation *Operation) ParseRouterComment(commentLine string) error {
-	sourceString := strings.TrimSpace(commentLine[len("@Router"):])
+	// @Route is accepted as an alias of @Router
+	sourceString := strings.TrimSpace(commentLine[len(strings.Split(commentLine, " ")[0]):])
 
-	re := regexp.MustCompile(`([\w\.\/\-{}]+)[^\[]+\[([^\]]+)`)
-	var matches []string
+	methodStart := strings.Index(sourceString, "[")
+	if methodStart == -1 {
+		return operation.routerError(commentLine, "missing http method, expected @Router path [method]")
+	}
+	methodEnd := strings.Index(sourceString[methodStart:], "]")
+	if methodEnd == -1 {
+		return operation.routerError(commentLine, "unclosed http method")
+	}
+	routePath := strings.TrimSpace(sourceString[:methodStart])
+	query := ""
+	if queryStart := strings.Index(routePath, "?"); queryStart != -1 {
+		routePath, query = routePath[:queryStart], routePath[queryStart+1:]
+	}
+	if routePath == "" {
+		return operation.routerError(commentLine, "missing path")
+	}
+	queryParams, err := parseRouteQuery(query)
+	if err != nil {
+		return operation.routerError(commentLine, err.Error())
+	}
+	// the path is written in the syntax of the router, it is checked as AddOperation will rewrite it
+	if err := ValidateRoutePath(operation.parser.normalizePath(routePath)); err != nil {
+		return operation.routerError(commentLine, err.Error())
+	}
+
+	method := sourceString[methodStart+1 : methodStart+methodEnd]
+	httpMethod := strings.ToUpper(strings.TrimSpace(method))
+	if !IsHttpMethod(httpMethod) {
+		return operation.routerError(commentLine, fmt.Sprintf("unknown http method %s", method))
+	}
+
+	// further @Router lines register the operation at aliases of its path
+	if len(operation.Routes) == 0 {
+		operation.Path = routePath
+		operation.HttpMethod = httpMethod
+	}
+	operation.Routes = append(operation.Routes, Route{Path: routePath, HttpMethod: httpMethod})
+	for _, name := range queryParams {
+		operation.addRouteQueryParam(name)
+	}
+	return nil
+}
 
-	if matches = re.FindStringSubmatch(sourceString); len(matches) != 3 {
-		return fmt.Errorf("Can not parse router comment \"%s\", skipped.", commentLine)
+// routeQueryNamePattern matches the name of a param of the query of a @Router path, and routeQueryValuePattern its
+// optional placeholder value
+var (
+	routeQueryNamePattern  = regexp.MustCompile(`^[\w\.\-\[\]]+$`)
+	routeQueryValuePattern = regexp.MustCompile(`^(\{[\w\.\-]+\})?$`)
+)
+
+// parseRouteQuery returns the names of the params of the query of a @Router path, e.g. active and sort for
+// active={active}&sort={sort}. The value of a param is optional, and must be a placeholder in braces if given
+func parseRouteQuery(query string) ([]string, error) {
+	names := make([]string, 0)
+	if query == "" {
+		return names, nil
+	}
+	for _, pair := range strings.Split(query, "&") {
+		name, value := pair, ""
+		if valueStart := strings.Index(pair, "="); valueStart != -1 {
+			name, value = pair[:valueStart], pair[valueStart+1:]
+		}
+		if !routeQueryNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid query param \"%s\"", pair)
+		}
+		if !routeQueryValuePattern.MatchString(value) {
+			return nil, fmt.Errorf("value of query param %s must be a placeholder, e.g. {%s}", name, name)
+		}
+		names = append(names, name)
 	}
+	return names, nil
+}
 
-	operation.Path = matches[1]
-	operation.HttpMethod = strings.ToUpper(matches[2])
+// addRouteQueryParam adds a query param of a @Router path, unless a query param of the same name is declared already
+func (operation *Operation) addRouteQueryParam(name string) {
+	for _, param := range operation.Parameters {
+		if param.ParamType == "query" && param.Name == name {
+			return
+		}
+	}
+	if operation.routeQueryParams == nil {
+		operation.routeQueryParams = make(map[string]bool)
+	}
+	operation.routeQueryParams[name] = true
+	operation.Parameters = append(operation.Parameters, Parameter{
+		ParamType: "query",
+		Name:      name,
+		Type:      "string",
+		DataType:  "string",
+	})
+}
+
+// ValidateRoutePath checks the characters of a route path, and that its path parameters are enclosed in balanced braces
+func ValidateRoutePath(routePath string) error {
+	if match, _ := regexp.MatchString(`^[\w\.\/\-{}]+$`, routePath); !match {
+		return fmt.Errorf("invalid characters in path %s", routePath)
+	}
+	paramStart := -1
+	for i, char := range routePath {
+		switch char {
+		case '{':
+			if paramStart != -1 {
+				return fmt.Errorf("unbalanced braces in path %s", routePath)
+			}
+			paramStart = i
+		case '}':
+			if paramStart == -1 {
+				return fmt.Errorf("unbalanced braces in path %s", routePath)
+			}
+			if i == paramStart+1 {
+				return fmt.Errorf("unnamed path parameter in path %s", routePath)
+			}
+			paramStart = -1
+		}
+	}
+	if paramStart != -1 {
+		return fmt.Errorf("unbalanced braces in path %s", routePath)
+	}
 	return nil
 }
 
+// location is the file and line of the handler of the operation, or "" if it is not parsed from a file
+func (operation *Operation) location() string {
+	if operation.fileName == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", operation.fileName, operation.line)
+}
+
+// handlerName names the handler of the operation in diagnostics
+func (operation *Operation) handlerName() string {
+	if operation.Handler == "" {
+		return "(unknown)"
+	}
+	return operation.Handler
+}
+
+func (operation *Operation) routerError(commentLine string, reason string) error {
+	if operation.Handler != "" {
+		return fmt.Errorf("Can not parse router comment \"%s\" of %s, %s.", commentLine, operation.Handler, reason)
+	}
+	return fmt.Errorf("Can not parse router comment \"%s\", %s.", commentLine, reason)
+}
+
+// Aliases returns the operation followed by a copy of it for each further route, which shares
+// its parameters, responses and models
+func (operation *Operation) Aliases() []*Operation {
+	aliases := []*Operation{operation}
+	for i := 1; i < len(operation.Routes); i++ {
+		alias := *operation
+		alias.Path = operation.Routes[i].Path
+		alias.routePrefix = ""
+		alias.HttpMethod = operation.Routes[i].HttpMethod
+		// the operation ids of a spec are unique, the aliases are numbered after the operation, e.g. getUser2
+		if operation.OperationId != "" {
+			alias.OperationId = operation.OperationId + strconv.Itoa(i+1)
+		}
+		aliases = append(aliases, &alias)
+	}
+	return aliases
+}
+
+// Swagger 1.2 expects http methods in upper case, however they are written in the annotation
+var httpMethods = map[string]bool{
+	"GET":     true,
+	"POST":    true,
+	"PUT":     true,
+	"PATCH":   true,
+	"DELETE":  true,
+	"HEAD":    true,
+	"OPTIONS": true,
+}
+
+func IsHttpMethod(httpMethod string) bool {
+	_, ok := httpMethods[httpMethod]
+	return ok
+}
+
+var (
+	// messageOnlyResponsePattern matches a response without a model, e.g. `404 "Order not found"`
+	messageOnlyResponsePattern = regexp.MustCompile(`^(\d+)(?:\s+"(.*)")?$`)
+	// responseMediaTypePattern matches the media type given between the model and the message of a response. Only
+	// the registered top-level types are accepted, so that words such as "and/or" are not taken for one
+	responseMediaTypePattern = regexp.MustCompile(`^(application|audio|font|image|message|model|multipart|text|video)/[\w\.\+\-]+$`)
+)
+
 // @Success 200 {object} model.OrderRow "Error message, if code != 200"
-func (operation *Operation) ParseResponseComment(commentLine string) error {
-	re := regexp.MustCompile(`([\d]+)[\s]+([\w\{\}]+)[\s]+([\w\-\.\/]+)[^"]*(.*)?`)
+//
+// A response without a model only has a message, e.g. `@Failure 404 "Order not found"`
+func (operation *Operation) ParseResponseComment(commentLine string) (err error) {
+	defer recoverFatal(&err)
+	return operation.parseResponseComment(commentLine)
+}
+
+func (operation *Operation) parseResponseComment(commentLine string) error {
+	if matches := messageOnlyResponsePattern.FindStringSubmatch(commentLine); matches != nil {
+		code, _ := strconv.Atoi(matches[1])
+		response := ResponseMessage{Code: code, Message: matches[2]}
+		response.Headers = operation.responseHeaders[response.Code]
+		delete(operation.responseHeaders, response.Code)
+		operation.ResponseMessages = append(operation.ResponseMessages, response)
+		return nil
+	}
+
+	re := regexp.MustCompile(`([\d]+)[\s]+([\w\{\}]+)[\s]+([\w\-\.\/\[\],]+)([^"]*)(.*)?`)
 	var matches []string
 
-	if matches = re.FindStringSubmatch(commentLine); len(matches) != 5 {
+	if matches = re.FindStringSubmatch(commentLine); len(matches) != 6 {
 		return fmt.Errorf("Can not parse response comment \"%s\", skipped.", commentLine)
 	}
 
@@ -189,16 +1162,40 @@ func (operation *Operation) ParseResponseComment(commentLine string) error {
 	} else {
 		response.Code = code
 	}
-	response.Message = strings.Trim(matches[4], "\"")
+	if mediaType := strings.TrimSpace(matches[4]); responseMediaTypePattern.MatchString(mediaType) {
+		response.ContentType = mediaType
+	}
+	response.Message = strings.Trim(matches[5], "\"")
+
+	// a slice type is an array of its elements, e.g. {object} []User is the same as {array} User
+	responseType, modelName := matches[2], matches[3]
+	if strings.HasPrefix(modelName, "[]") {
+		if responseType == "{array}" {
+			return fmt.Errorf("Can not parse response comment \"%s\", arrays of arrays are not supported.", commentLine)
+		}
+		responseType, modelName = "{array}", modelName[len("[]"):]
+	}
+	if !strings.HasSuffix(strings.ToLower(responseType), "of}") {
+		modelName = operation.parser.resolveType(modelName, operation.parser.CurrentPackage)
+	}
 
 	typeName := ""
-	if IsBasicType(matches[3]) {
-		typeName = matches[3]
+	if strings.HasSuffix(strings.ToLower(responseType), "of}") {
+		composition, err := operation.parseComposition(responseType, modelName)
+		if err != nil {
+			return err
+		}
+		response.Composition = composition
+		if response.Code == 200 {
+			operation.Composition = composition
+		}
+	} else if IsBasicType(modelName) {
+		typeName = modelName
 	} else {
 		model := NewModel(operation.parser)
-		response.ResponseModel = matches[3]
+		response.ResponseModel = modelName
 		knownModelNames := map[string]bool{}
-		if err, innerModels := model.ParseModel(response.ResponseModel, operation.parser.CurrentPackage, knownModelNames); err != nil {
+		if err, innerModels := model.parseModel(response.ResponseModel, operation.parser.CurrentPackage, knownModelNames); err != nil {
 			return err
 		} else {
 			typeName = model.Id
@@ -209,8 +1206,16 @@ func (operation *Operation) ParseResponseComment(commentLine string) error {
 	}
 
 	response.ResponseModel = typeName
+	response.isArray = responseType == "{array}"
+	if response.ContentType != "" {
+		for i := range operation.ResponseMessages {
+			if existing := &operation.ResponseMessages[i]; existing.Code == response.Code {
+				return operation.addResponseVariant(existing, response, responseType)
+			}
+		}
+	}
 	if response.Code == 200 {
-		if matches[2] == "{array}" {
+		if responseType == "{array}" {
 			operation.SetItemsType(typeName)
 			operation.Type = "array"
 		} else {
@@ -218,6 +1223,141 @@ func (operation *Operation) ParseResponseComment(commentLine string) error {
 		}
 	}
 
+	response.Headers = operation.responseHeaders[response.Code]
+	delete(operation.responseHeaders, response.Code)
+
 	operation.ResponseMessages = append(operation.ResponseMessages, response)
 	return nil
 }
+
+// addResponseVariant merges a response into the one of the same code in another media type. The response without
+// a media type, or else the JSON one, is the one of Swagger 1.2 and 2.0, the others are only in the content of the
+// OpenAPI 3.0 response
+func (operation *Operation) addResponseVariant(existing *ResponseMessage, response ResponseMessage, responseType string) error {
+	for _, variant := range append([]ResponseMessage{*existing}, existing.Variants...) {
+		if variant.ContentType == response.ContentType {
+			return fmt.Errorf("Can not parse response comment, response %d is already given in %s.", response.Code, response.ContentType)
+		}
+	}
+	if existing.ContentType == "" || response.ContentType != ContentTypeJson {
+		existing.Variants = append(existing.Variants, response)
+		return nil
+	}
+
+	response.Headers = existing.Headers
+	if response.Message == "" {
+		response.Message = existing.Message
+	}
+	existing.Variants, response.Variants = nil, append([]ResponseMessage{*existing}, existing.Variants...)
+	*existing = response
+	if response.Code == 200 {
+		operation.Items = OperationItems{}
+		if responseType == "{array}" {
+			operation.SetItemsType(response.ResponseModel)
+			operation.Type = "array"
+		} else {
+			operation.Type = response.ResponseModel
+		}
+	}
+	return nil
+}
+
+// @Tags users,admin
+func (operation *Operation) ParseTagsComment(commentLine string) error {
+	tags := strings.TrimSpace(commentLine[len("@Tags"):])
+	if tags == "" {
+		return fmt.Errorf("Can not parse tags comment \"%s\", expected a comma separated list of tags.", commentLine)
+	}
+	for _, tag := range strings.Split(tags, ",") {
+		operation.Tags = append(operation.Tags, strings.TrimSpace(tag))
+	}
+	operation.NormalizeTags()
+	return nil
+}
+
+// NormalizeTags removes the duplicate and empty tags of the operation and sorts the rest
+func (operation *Operation) NormalizeTags() {
+	tags := make([]string, 0, len(operation.Tags))
+	isExists := make(map[string]bool)
+	for _, tag := range operation.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !isExists[tag] {
+			isExists[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	operation.Tags = tags
+}
+
+// ParseComposition parses the models of a {allOf}, {oneOf} or {anyOf} declaration, e.g. "{oneOf} Cat,Dog"
+func (operation *Operation) ParseComposition(keyword string, modelList string) (composition Composition, err error) {
+	defer recoverFatal(&err)
+	return operation.parseComposition(keyword, modelList)
+}
+
+func (operation *Operation) parseComposition(keyword string, modelList string) (Composition, error) {
+	composition := Composition{}
+	var modelIds []string
+	for _, modelName := range SplitModelList(modelList) {
+		if IsBasicType(modelName) {
+			return composition, fmt.Errorf("Can not compose basic type %s, only models can be composed.", modelName)
+		}
+		model := NewModel(operation.parser)
+		knownModelNames := map[string]bool{}
+		err, innerModels := model.parseModel(modelName, operation.parser.CurrentPackage, knownModelNames)
+		if err != nil {
+			return composition, err
+		}
+		modelIds = append(modelIds, model.Id)
+		operation.Models = append(operation.Models, model)
+		operation.Models = append(operation.Models, innerModels...)
+	}
+
+	switch strings.ToLower(keyword) {
+	case "{allof}":
+		composition.AllOf = modelIds
+	case "{oneof}":
+		composition.OneOf = modelIds
+	case "{anyof}":
+		composition.AnyOf = modelIds
+	default:
+		return composition, fmt.Errorf("Unknown composition %s, expected {allOf}, {oneOf} or {anyOf}.", keyword)
+	}
+	return composition, nil
+}
+
+// @Header 200 X-Rate-Limit int "Requests remaining"
+func (operation *Operation) ParseHeaderComment(commentLine string) error {
+	sourceString := strings.TrimSpace(commentLine[len("@Header"):])
+
+	re := regexp.MustCompile(`^([\d]+)[\s]+([-\w]+)[\s]+([\w]+)(?:[\s]+"([^"]*)")?`)
+	matches := re.FindStringSubmatch(sourceString)
+	if len(matches) != 5 {
+		return fmt.Errorf("Can not parse header comment \"%s\", skipped.", commentLine)
+	}
+	if !IsBasicType(matches[3]) {
+		return fmt.Errorf("Can not parse header comment \"%s\", header type must be a basic type.", commentLine)
+	}
+	code, _ := strconv.Atoi(matches[1])
+	header := ResponseHeader{Type: matches[3], Description: matches[4]}
+
+	for i := range operation.ResponseMessages {
+		if operation.ResponseMessages[i].Code == code {
+			if operation.ResponseMessages[i].Headers == nil {
+				operation.ResponseMessages[i].Headers = make(map[string]ResponseHeader)
+			}
+			operation.ResponseMessages[i].Headers[matches[2]] = header
+			return nil
+		}
+	}
+
+	// the response is not parsed yet, the header will be added to it
+	if operation.responseHeaders == nil {
+		operation.responseHeaders = make(map[int]map[string]ResponseHeader)
+	}
+	if operation.responseHeaders[code] == nil {
+		operation.responseHeaders[code] = make(map[string]ResponseHeader)
+	}
+	operation.responseHeaders[code][matches[2]] = header
+	return nil
+}
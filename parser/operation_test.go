@@ -1,6 +1,7 @@
 package parser_test
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -48,6 +49,164 @@ func (suite *OperationSuite) TestParseAcceptComment() {
 	assert.Equal(suite.T(), op2.Produces, expected, "Can not parse accept comment with multiple types")
 }
 
+func (suite *OperationSuite) TestParseProduceComment() {
+	op := parser.NewOperation(suite.parser, "test")
+	for _, line := range []string{
+		"// @Accept json,multipart/form-data",
+		"// @Produce json, xml",
+		"// @Accept form",
+	} {
+		err := op.ParseComment(line)
+		assert.Nil(suite.T(), err, "Can not parse accept and produce comments")
+	}
+	assert.Equal(suite.T(), []string{parser.ContentTypeJson, parser.ContentTypeMultipart, parser.ContentTypeForm}, op.Consumes, "Can not parse accept comment")
+	assert.Equal(suite.T(), []string{parser.ContentTypeJson, parser.ContentTypeXml}, op.Produces, "Produced types should not follow accepted ones once given")
+
+	op2 := parser.NewOperation(suite.parser, "test")
+	err := op2.ParseProduceComment("@Produce application/pdf,octet-stream")
+	assert.Nil(suite.T(), err, "Can not parse produce comment")
+	assert.Equal(suite.T(), []string{"application/pdf", parser.ContentTypeOctetStream}, op2.Produces, "Can not parse produce comment")
+	assert.Len(suite.T(), op2.Consumes, 0, "Produce comment should not change consumed types")
+	op2.SetDefaultContentTypes()
+	assert.Equal(suite.T(), []string{parser.ContentTypeJson}, op2.Consumes, "Consumed types should default to JSON")
+	assert.Equal(suite.T(), []string{"application/pdf", parser.ContentTypeOctetStream}, op2.Produces, "Given produced types should be kept")
+
+	op3 := parser.NewOperation(suite.parser, "test")
+	assert.NotNil(suite.T(), op3.ParseProduceComment("@Produce yaml"), "Unknown content type shorthand should not be accepted")
+	assert.NotNil(suite.T(), op3.ParseAcceptComment("@Accept"), "Accept comment without content type should not be accepted")
+	op3.SetDefaultContentTypes()
+	assert.Equal(suite.T(), []string{parser.ContentTypeJson}, op3.Consumes, "Consumed types should default to JSON")
+	assert.Equal(suite.T(), []string{parser.ContentTypeJson}, op3.Produces, "Produced types should default to JSON")
+
+	marshaled, err := json.Marshal(op)
+	assert.Nil(suite.T(), err, "Can not marshal operation")
+	assert.Contains(suite.T(), string(marshaled), `"consumes":["application/json","multipart/form-data","application/x-www-form-urlencoded"]`, "Consumed types not emitted")
+	assert.Contains(suite.T(), string(marshaled), `"produces":["application/json","application/xml"]`, "Produced types not emitted")
+}
+
+func (suite *OperationSuite) TestParseBodyParamComment() {
+	p := parser.NewParser()
+	p.ParseTypeDefinitions(ExamplePackageName)
+	p.CurrentPackage = ExamplePackageName
+
+	op := parser.NewOperation(p, ExamplePackageName)
+	err := op.ParseParamComment(`@Param body body subpackage.SimpleStructure true "payload"`)
+	assert.Nil(suite.T(), err, "Can not parse body param referencing a model")
+	modelId := "github.com.RobotsAndPencils.go-swaggerLite.example.subpackage.SimpleStructure"
+	assert.Equal(suite.T(), modelId, op.Parameters[0].Type, "Body param should reference the model")
+	assert.Equal(suite.T(), modelId, op.Parameters[0].DataType, "Body param should reference the model")
+	if assert.Len(suite.T(), op.Models, 1, "Model of the body param not added") {
+		assert.Equal(suite.T(), modelId, op.Models[0].Id, "Model of the body param not added")
+	}
+
+	err = op.ParseParamComment(`@Param body body []SimpleStructure true "payload"`)
+	assert.Nil(suite.T(), err, "Can not parse body param with an array of models")
+	assert.Equal(suite.T(), "array", op.Parameters[1].Type, "Body param should be an array")
+	assert.Equal(suite.T(), &parser.OperationItems{Ref: "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleStructure"}, op.Parameters[1].Items, "Body param should reference the model of its items")
+
+	err = op.ParseParamComment(`@Param ids body []int64 true "payload"`)
+	assert.Nil(suite.T(), err, "Can not parse body param with an array of a basic type")
+	assert.Equal(suite.T(), "array", op.Parameters[2].Type, "Body param should be an array")
+	assert.Equal(suite.T(), &parser.OperationItems{Type: "int64"}, op.Parameters[2].Items, "Body param should have basic items")
+
+	err = op.ParseParamComment(`@Param shapes body []Shape true "payload"`)
+	assert.Nil(suite.T(), err, "Can not parse body param with an array of interfaces")
+	assert.Equal(suite.T(), &parser.OperationItems{Type: "object"}, op.Parameters[3].Items, "Interface items should be free-form objects")
+	assert.Len(suite.T(), op.Models, 2, "Interface should not be a model")
+
+	// the package of a model referenced by its absolute name is parsed on demand
+	p2 := parser.NewParser()
+	p2.CurrentPackage = "test"
+	op2 := parser.NewOperation(p2, "test")
+	err = op2.ParseParamComment(`@Param user body ` + ExamplePackageName + `/users.User true "The user"`)
+	assert.Nil(suite.T(), err, "Can not parse body param referencing a model by its absolute name")
+	assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.users.User", op2.Parameters[0].Type, "Body param should reference the model")
+	assert.Equal(suite.T(), "test", p2.CurrentPackage, "Parsing the package of the model should not change the current package")
+}
+
+func (suite *OperationSuite) TestParseParamCommentWithNamedPrimitiveTypes() {
+	p := parser.NewParser()
+	p.ParseTypeDefinitions(ExamplePackageName)
+	p.CurrentPackage = ExamplePackageName
+
+	op := parser.NewOperation(p, ExamplePackageName)
+	for _, line := range []string{
+		`// @Param number path subpackage.OrderNumber true "Order number"`,
+		`// @Param id query OrderId false "Order id"`,
+		`// @Param status query OrderStatus false "Status"`,
+		`// @Param statuses query []OrderStatus false "Statuses"`,
+		`// @Param name query SimpleAlias false "Name"`,
+		`// @Router /orders/{number} [get]`,
+	} {
+		assert.Nil(suite.T(), op.ParseComment(line), "Can not parse param comment %s", line)
+	}
+	if !assert.Len(suite.T(), op.Parameters, 5, "Params not parsed") {
+		return
+	}
+	assert.Equal(suite.T(), "int64", op.Parameters[0].Type, "Named type should be documented as its underlying primitive")
+	assert.Equal(suite.T(), "int64", op.Parameters[0].Format, "Format should be inferred from the underlying primitive")
+	assert.Equal(suite.T(), "string", op.Parameters[1].Type, "Named type should be documented as the mapping of its underlying type")
+	assert.Equal(suite.T(), "uuid", op.Parameters[1].Format, "Named type should be documented as the mapping of its underlying type")
+	assert.Equal(suite.T(), "string", op.Parameters[2].Type, "Enum type should be documented as its underlying primitive")
+	assert.Equal(suite.T(), []string{"active", "inactive", "pending"}, op.Parameters[2].Enum, "Enum values not found")
+	assert.Equal(suite.T(), &parser.OperationItems{Type: "string"}, op.Parameters[3].Items, "Array of a named type should be documented as an array of its primitive")
+	assert.Equal(suite.T(), []string{"active", "inactive", "pending"}, op.Parameters[3].Enum, "Enum values not found")
+	assert.Equal(suite.T(), "string", op.Parameters[4].Type, "Named type should be documented as its underlying primitive")
+	assert.Empty(suite.T(), op.Models, "Named primitive types should not be models")
+
+	p.AddOperation(op)
+	param := p.Swagger20().Paths["/orders/{number}"]["get"].Parameters[0]
+	assert.Equal(suite.T(), "integer", param.Type, "Named type should be a primitive param")
+	assert.Equal(suite.T(), "int64", param.Format, "Named type should be a primitive param")
+	assert.Nil(suite.T(), param.Schema, "Named type should be a primitive param")
+
+	assert.NotNil(suite.T(), op.ParseComment(`// @Param body query SimpleStructure false "Structure"`), "Structs should not be non-body params")
+}
+
+func (suite *OperationSuite) TestParseParamsComment() {
+	p := parser.NewParser()
+	p.ParseTypeDefinitions(ExamplePackageName)
+	p.CurrentPackage = ExamplePackageName
+
+	op := parser.NewOperation(p, ExamplePackageName)
+	err := op.ParseComment("// @Params ListOrdersRequest")
+	assert.Nil(suite.T(), err, "Can not parse params comment")
+	params := make(map[string]parser.Parameter)
+	for _, param := range op.Parameters {
+		params[param.Name] = param
+	}
+	assert.Len(suite.T(), op.Parameters, 5, "Only the tagged fields should be params (%#v)", op.Parameters)
+
+	assert.Equal(suite.T(), "path", params["customerId"].ParamType, "Location of the param should be its tag")
+	assert.Equal(suite.T(), "int64", params["customerId"].Type, "Type of the param should be the type of its field")
+	assert.Equal(suite.T(), "int64", params["customerId"].Format, "Format of the param should be inferred from its type")
+	assert.Equal(suite.T(), "Id of the customer", params["customerId"].Description, "Description of the param should be the comment of its field")
+	assert.True(suite.T(), params["customerId"].Required, "Path params should be required")
+
+	assert.Equal(suite.T(), "query", params["status"].ParamType, "Location of the param should be its tag")
+	assert.Equal(suite.T(), "array", params["status"].Type, "Slices should be array params")
+	assert.Equal(suite.T(), &parser.OperationItems{Type: "string"}, params["status"].Items, "Items of the param should be the type of the elements of its field")
+	assert.Equal(suite.T(), "csv", params["status"].CollectionFormat, "Array params should be comma separated by default")
+	assert.Contains(suite.T(), params["status"].Enum, "active", "Enum values of the elements should be the enum of the param")
+
+	assert.Equal(suite.T(), "header", params["X-Request-Id"].ParamType, "Location of the param should be its tag")
+	assert.Equal(suite.T(), "string", params["X-Request-Id"].Type, "Pointers should be params of the type they point to")
+	assert.Equal(suite.T(), "Id of the request, for tracing", params["X-Request-Id"].Description, "Description tag should be the description of the param")
+	assert.False(suite.T(), params["X-Request-Id"].Required, "Params should be optional by default")
+
+	assert.Equal(suite.T(), int64(1), params["page"].Default, "Default tag should be the default of the param")
+	assert.False(suite.T(), params["page"].Required, "Params of embedded structs should be expanded")
+	assert.True(suite.T(), params["limit"].Required, "Params with a required validation should be required")
+
+	for _, comment := range []string{
+		"// @Params",
+		"// @Params ListOrdersRequest Paging",
+		"// @Params SimpleStructure",
+	} {
+		assert.NotNil(suite.T(), op.ParseComment(comment), "Invalid params comment should fail: %s", comment)
+	}
+}
+
 func (suite *OperationSuite) TestParseRouterComment() {
 	op := parser.NewOperation(suite.parser, "test")
 	err := op.ParseRouterComment("@Router /customer/get-wishlist/ [get]")
@@ -60,6 +219,181 @@ func (suite *OperationSuite) TestParseRouterComment() {
 	assert.Nil(suite.T(), err2, "Can not parse router comment")
 	assert.Equal(suite.T(), op2.Path, "/customer/get-wishlist/{id}", "Can not parse router comment")
 	assert.Equal(suite.T(), op2.HttpMethod, "POST", "Can not parse router comment")
+
+	op3 := parser.NewOperation(suite.parser, "test")
+	err3 := op3.ParseRouterComment("@Router /customer/get-wishlist/{id} [fetch]")
+	assert.NotNil(suite.T(), err3, "Unknown http method should not be accepted")
+	assert.Equal(suite.T(), op3.Path, "", "Unknown http method should not be accepted")
+
+	op4 := parser.NewOperation(suite.parser, "test")
+	err4 := op4.ParseRouterComment("@Route /customer/get-wishlist/{id} [put]")
+	assert.Nil(suite.T(), err4, "Can not parse route comment")
+	assert.Equal(suite.T(), op4.Path, "/customer/get-wishlist/{id}", "Can not parse route comment")
+	assert.Equal(suite.T(), op4.HttpMethod, "PUT", "Can not parse route comment")
+}
+
+func (suite *OperationSuite) TestParseMalformedRouterComment() {
+	for commentLine, reason := range map[string]string{
+		"@Router /customer/{id}":             "missing http method",
+		"@Router /customer/{id} [get":        "unclosed http method",
+		"@Router [get]":                      "missing path",
+		"@Router /customer/{id [get]":        "unbalanced braces",
+		"@Router /customer/id}/orders [get]": "unbalanced braces",
+		"@Router /customer/{{id}} [get]":     "unbalanced braces",
+		"@Router /customer/{} [get]":         "unnamed path parameter",
+		"@Router /customer id [get]":         "invalid characters",
+		"@Router /customer?id=1 [get]":       "must be a placeholder",
+		"@Router /customer?a b [get]":        "invalid query param",
+		"@Router /customer/{id} [fetch]":     "unknown http method fetch",
+	} {
+		op := parser.NewOperation(suite.parser, "test")
+		op.Handler = "GetCustomer"
+		err := op.ParseRouterComment(commentLine)
+		if assert.NotNil(suite.T(), err, "Malformed router comment %s should not be accepted", commentLine) {
+			assert.Contains(suite.T(), err.Error(), reason, "Router error should say what is wrong")
+			assert.Contains(suite.T(), err.Error(), commentLine, "Router error should include the offending line")
+			assert.Contains(suite.T(), err.Error(), "GetCustomer", "Router error should name the handler")
+		}
+		assert.Equal(suite.T(), "", op.Path, "Malformed router comment %s should not set the path", commentLine)
+		assert.Equal(suite.T(), "", op.HttpMethod, "Malformed router comment %s should not set the http method", commentLine)
+	}
+}
+
+func (suite *OperationSuite) TestParseRouterCommentWithQuery() {
+	op := parser.NewOperation(suite.parser, "test")
+	for _, line := range []string{
+		`// @Param sort query string false "Sort order" Enums(asc, desc)`,
+		`// @Router /users?active={active}&sort={sort}&q [get]`,
+		`// @Param q query string true "Search text"`,
+	} {
+		assert.Nil(suite.T(), op.ParseComment(line), "Can not parse comment %s", line)
+	}
+	assert.Equal(suite.T(), "/users", op.Path, "Path should not include the query")
+	assert.Equal(suite.T(), []parser.Route{{Path: "/users", HttpMethod: "GET"}}, op.Routes, "Route should not include the query")
+
+	params := map[string]parser.Parameter{}
+	for _, param := range op.Parameters {
+		params[param.Name] = param
+	}
+	assert.Len(suite.T(), op.Parameters, 3, "Declared query params should not be added twice")
+	assert.Equal(suite.T(), parser.Parameter{ParamType: "query", Name: "active", Type: "string", DataType: "string"}, params["active"], "Query param of the path not added")
+	assert.Equal(suite.T(), "Sort order", params["sort"].Description, "Query param declared before the router should be kept")
+	assert.Equal(suite.T(), []string{"asc", "desc"}, params["sort"].Enum, "Query param declared before the router should be kept")
+	assert.True(suite.T(), params["q"].Required, "Query param declared after the router should replace the one of the path")
+	assert.Equal(suite.T(), "Search text", params["q"].Description, "Query param declared after the router should replace the one of the path")
+
+	p := parser.NewParser()
+	op2 := parser.NewOperation(p, "test")
+	assert.Nil(suite.T(), op2.ParseRouterComment("@Router /users?active={active} [get]"), "Can not parse router comment")
+	p.AddOperation(op2)
+	assert.Contains(suite.T(), p.TopLevelApis, "users", "Resource should be derived from the path without the query")
+	assert.Equal(suite.T(), "/users", p.TopLevelApis["users"].Apis[0].Path, "Api should be at the path without the query")
+}
+
+func (suite *OperationSuite) TestAddOperationWithoutPath() {
+	p := parser.NewParser()
+	p.LibraryMode = true
+	for _, operationPath := range []string{"", " / "} {
+		op := parser.NewOperation(p, "test")
+		op.Handler = "GetNothing"
+		op.Path = operationPath
+		p.AddOperation(op)
+	}
+	assert.Empty(suite.T(), p.TopLevelApis, "Operation without path should not be added")
+	if assert.Len(suite.T(), p.Warnings, 2, "Operation without path should be reported") {
+		assert.Contains(suite.T(), p.Warnings[0].Error(), "GetNothing", "Warning should name the handler")
+	}
+}
+
+func (suite *OperationSuite) TestParseRouterCommentMixedCase() {
+	p := parser.NewParser()
+	for _, method := range []string{"get", "Put", "DELETE"} {
+		op := parser.NewOperation(p, "test")
+		err := op.ParseRouterComment("@Router /customer/{id} [" + method + "]")
+		assert.Nil(suite.T(), err, "Can not parse router comment")
+		p.AddOperation(op)
+	}
+
+	api := p.TopLevelApis["customer"]
+	assert.Len(suite.T(), api.Apis, 1, "Operations on the same path should share one api")
+	assert.Len(suite.T(), api.Apis[0].Operations, 3, "Operations not added")
+	for i, expected := range []string{"GET", "PUT", "DELETE"} {
+		assert.Equal(suite.T(), expected, api.Apis[0].Operations[i].HttpMethod, "Http method casing not normalized")
+	}
+}
+
+func (suite *OperationSuite) TestParseDeprecatedComment() {
+	op := parser.NewOperation(suite.parser, "test")
+	err := op.ParseDeprecatedComment("@Deprecated")
+	assert.Nil(suite.T(), err, "Can not parse deprecated comment")
+	assert.Equal(suite.T(), op.Deprecated, "true", "Can not parse deprecated comment")
+	assert.Equal(suite.T(), op.Sunset, "", "Can not parse deprecated comment")
+
+	op2 := parser.NewOperation(suite.parser, "test")
+	err2 := op2.ParseDeprecatedComment("@Deprecated 2025-12-31")
+	assert.Nil(suite.T(), err2, "Can not parse deprecated comment with sunset date")
+	assert.Equal(suite.T(), op2.Deprecated, "true", "Can not parse deprecated comment with sunset date")
+	assert.Equal(suite.T(), op2.Sunset, "2025-12-31", "Can not parse deprecated comment with sunset date")
+
+	op3 := parser.NewOperation(suite.parser, "test")
+	err3 := op3.ParseDeprecatedComment("@Deprecated 31/12/2025")
+	assert.NotNil(suite.T(), err3, "Invalid sunset date should not be accepted")
+	assert.Equal(suite.T(), op3.Deprecated, "", "Invalid sunset date should not be accepted")
+}
+
+func (suite *OperationSuite) TestParseIdempotentAndCacheableComments() {
+	op := parser.NewOperation(suite.parser, "test")
+	for _, line := range []string{
+		"// @Router /orders/{order_id} [get]",
+		"// @Idempotent",
+		"// @Cacheable 5m",
+	} {
+		err := op.ParseComment(line)
+		assert.Nil(suite.T(), err, "Can not parse idempotent and cacheable comments on a GET")
+	}
+	assert.Equal(suite.T(), true, op.Idempotent, "Can not parse idempotent comment on a GET")
+	assert.Equal(suite.T(), "5m", op.Cacheable, "Can not parse cacheable comment on a GET")
+
+	op2 := parser.NewOperation(suite.parser, "test")
+	for _, line := range []string{
+		"// @Router /orders/{order_id} [put]",
+		"// @Idempotent",
+		"// @Cacheable 30s",
+	} {
+		err := op2.ParseComment(line)
+		assert.Nil(suite.T(), err, "Can not parse idempotent and cacheable comments on a PUT")
+	}
+	assert.Equal(suite.T(), true, op2.Idempotent, "Can not parse idempotent comment on a PUT")
+	assert.Equal(suite.T(), "30s", op2.Cacheable, "Can not parse cacheable comment on a PUT")
+
+	marshaled, err := json.Marshal(op2)
+	assert.Nil(suite.T(), err, "Can not marshal operation")
+	assert.Contains(suite.T(), string(marshaled), `"x-idempotent":true`, "Idempotent extension not emitted")
+	assert.Contains(suite.T(), string(marshaled), `"x-cacheable":"30s"`, "Cacheable extension not emitted")
+
+	op3 := parser.NewOperation(suite.parser, "test")
+	for _, ttl := range []string{"", "5 minutes", "-1m", "0s"} {
+		err := op3.ParseCacheableComment("@Cacheable " + ttl)
+		assert.NotNil(suite.T(), err, "Invalid ttl \"%s\" should not be accepted", ttl)
+	}
+	assert.Equal(suite.T(), "", op3.Cacheable, "Invalid ttl should not be accepted")
+}
+
+func (suite *OperationSuite) TestParseSecurityComment() {
+	op := parser.NewOperation(suite.parser, "test")
+	err := op.ParseSecurityComment("@Security api_key")
+	assert.Nil(suite.T(), err, "Can not parse security comment")
+	err2 := op.ParseSecurityComment("@Security oauth write:orders,read:orders")
+	assert.Nil(suite.T(), err2, "Can not parse security comment with scopes")
+
+	expected := map[string][]parser.AuthorizationScope{
+		"api_key": []parser.AuthorizationScope{},
+		"oauth":   []parser.AuthorizationScope{{Scope: "write:orders"}, {Scope: "read:orders"}},
+	}
+	assert.Equal(suite.T(), expected, op.Authorizations, "Can not parse security comment")
+
+	op2 := parser.NewOperation(suite.parser, "test")
+	assert.NotNil(suite.T(), op2.ParseSecurityComment("@Security"), "Security comment without name should not be accepted")
 }
 
 func (suite *OperationSuite) TestParseParamComment() {
@@ -76,6 +410,75 @@ func (suite *OperationSuite) TestParseParamComment() {
 	assert.Equal(suite.T(), op.Parameters[0].Description, "Order number", "Can not parse param comment")
 }
 
+func (suite *OperationSuite) TestParseParamCommentWithEnums() {
+	op := parser.NewOperation(suite.parser, "test")
+	err := op.ParseParamComment("@Param   status     query    string  true	\"Status\" Enums(active, inactive, pending)")
+	assert.Nil(suite.T(), err, "Can not parse param comment with enums")
+	assert.Len(suite.T(), op.Parameters, 1, "Can not parse param comment with enums")
+	assert.Equal(suite.T(), op.Parameters[0].Description, "Status", "Can not parse param comment with enums")
+	assert.Equal(suite.T(), op.Parameters[0].Enum, []string{"active", "inactive", "pending"}, "Can not parse param comment with enums")
+
+	op2 := parser.NewOperation(suite.parser, "test")
+	err2 := op2.ParseParamComment("@Param   status     query    string  true	\"Status\" Unknown(value)")
+	assert.NotNil(suite.T(), err2, "Unknown param clause should not be accepted")
+	assert.Len(suite.T(), op2.Parameters, 0, "Unknown param clause should not be accepted")
+}
+
+func (suite *OperationSuite) TestParseParamCommentWithDefault() {
+	operationComment := `
+// @Param   limit    query    int     false  "Page size" default(20)
+// @Param   active   query    bool    false  "Active only" default(true)
+// @Param   sort     query    string  false  "Sort order" Enums(asc, desc) default(asc)
+// @Param   name     query    string  false  "Name" default("John Doe")
+`
+	op := parser.NewOperation(suite.parser, "test")
+	for _, line := range strings.Split(operationComment, "\n") {
+		err := op.ParseComment(line)
+		assert.Nil(suite.T(), err, "Can not parse param comment with default")
+	}
+	assert.Len(suite.T(), op.Parameters, 4, "Can not parse param comment with default")
+	assert.Equal(suite.T(), int64(20), op.Parameters[0].Default, "Can not parse int default")
+	assert.Equal(suite.T(), true, op.Parameters[1].Default, "Can not parse bool default")
+	assert.Equal(suite.T(), "asc", op.Parameters[2].Default, "Can not parse string default")
+	assert.Equal(suite.T(), "John Doe", op.Parameters[3].Default, "Can not parse quoted string default")
+
+	assert.NotNil(suite.T(), op.ParseParamComment(`@Param limit query int false "Page size" default(twenty)`), "Default which is not an int should fail")
+	assert.NotNil(suite.T(), op.ParseParamComment(`@Param active query bool false "Active only" default(yes)`), "Default which is not a bool should fail")
+}
+
+func (suite *OperationSuite) TestParseBatchItemComment() {
+	operationComment := `
+// @Title batchOrders
+// @BatchItem OrderBatchItem method  string    true  "HTTP method of the sub-request"
+// @BatchItem OrderBatchItem path    string    true  "Path of the sub-request"
+// @BatchItem OrderBatchItem headers []string  false "Headers of the sub-request"
+// @Success 200 {simple} string
+// @Router /orders/batch [post]
+`
+	op := parser.NewOperation(suite.parser, ExamplePackageName)
+	for _, line := range strings.Split(operationComment, "\n") {
+		err := op.ParseComment(line)
+		assert.Nil(suite.T(), err, "Can not parse batch operation comment")
+	}
+
+	assert.Len(suite.T(), op.Parameters, 1, "Batch body parameter not added")
+	assert.Equal(suite.T(), "body", op.Parameters[0].ParamType, "Batch body parameter not added")
+	assert.Equal(suite.T(), "array", op.Parameters[0].Type, "Batch body parameter not added")
+	assert.Equal(suite.T(), &parser.OperationItems{Ref: exampleModelPrefix + "OrderBatchItem"}, op.Parameters[0].Items, "Batch body parameter not added")
+
+	assert.Len(suite.T(), op.Models, 1, "Batch item model not added")
+	batchItem := op.Models[0]
+	assert.Equal(suite.T(), exampleModelPrefix+"OrderBatchItem", batchItem.Id, "Batch item model should be named after the package of the operation")
+	assert.Equal(suite.T(), []string{"method", "path"}, batchItem.Required, "Batch item model not parsed")
+	assert.Len(suite.T(), batchItem.Properties, 3, "Batch item model not parsed")
+	assert.Equal(suite.T(), "string", batchItem.Properties["method"].Type, "Batch item model not parsed")
+	assert.Equal(suite.T(), "Path of the sub-request", batchItem.Properties["path"].Description, "Batch item model not parsed")
+	assert.Equal(suite.T(), "array", batchItem.Properties["headers"].Type, "Batch item model not parsed")
+	assert.Equal(suite.T(), "string", batchItem.Properties["headers"].Items.Type, "Batch item model not parsed")
+
+	assert.NotNil(suite.T(), op.ParseBatchItemComment("@BatchItem OrderBatchItem order Order true \"Order\""), "Batch item fields should have basic types")
+}
+
 func (suite *OperationSuite) TestParseResponseComment() {
 	op := parser.NewOperation(suite.parser, "test")
 	err := op.ParseResponseComment("200 {simple} string")
@@ -106,6 +509,520 @@ func (suite *OperationSuite) TestParseResponseComment() {
 	assert.Equal(suite.T(), op3.Items.Type, "string", "Can not parse response comment")
 }
 
+func (suite *OperationSuite) TestParseArrayResponseComment() {
+	newParser := func() *parser.Parser {
+		p := parser.NewParser()
+		p.ParseTypeDefinitions(ExamplePackageName)
+		p.CurrentPackage = ExamplePackageName
+		return p
+	}
+	modelId := "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleStructure"
+
+	cases := []struct {
+		comment string
+		items   parser.OperationItems
+		schema  *parser.Schema
+	}{
+		{"200 {array} string", parser.OperationItems{Type: "string"}, &parser.Schema{Type: "string"}},
+		{"200 {array} int64", parser.OperationItems{Type: "int64", Format: "int64"}, &parser.Schema{Type: "integer", Format: "int64"}},
+		{"200 {array} SimpleStructure", parser.OperationItems{Ref: modelId}, &parser.Schema{Ref: "#/definitions/" + modelId}},
+		{"200 {object} []SimpleStructure", parser.OperationItems{Ref: modelId}, &parser.Schema{Ref: "#/definitions/" + modelId}},
+	}
+	for _, c := range cases {
+		p := newParser()
+		op := parser.NewOperation(p, ExamplePackageName)
+		assert.Nil(suite.T(), op.ParseResponseComment(c.comment), "Can not parse response comment %s", c.comment)
+		assert.Nil(suite.T(), op.ParseRouterComment("@Router /orders [get]"), "Can not parse router comment")
+		assert.Equal(suite.T(), "array", op.Type, "Response %s should be an array", c.comment)
+		assert.Equal(suite.T(), c.items, op.Items, "Items of response %s not set", c.comment)
+
+		p.AddOperation(op)
+		schema := p.Swagger20().Paths["/orders"]["get"].Responses["200"].Schema
+		assert.Equal(suite.T(), &parser.Schema{Type: "array", Items: c.schema}, schema, "Schema of response %s should be an array of its items", c.comment)
+		content := p.OpenAPI3().Paths["/orders"]["get"].Responses["200"].Content[parser.ContentTypeJson]
+		assert.Equal(suite.T(), "array", content.Schema.Type, "Schema of response %s should be an array in OpenAPI 3.0", c.comment)
+	}
+
+	p := newParser()
+	op := parser.NewOperation(p, ExamplePackageName)
+	assert.Nil(suite.T(), op.ParseResponseComment("200 {object} SimpleStructure"), "Can not parse response comment")
+	assert.Equal(suite.T(), modelId, op.Type, "Object response should be its model")
+	assert.Equal(suite.T(), parser.OperationItems{}, op.Items, "Object response should have no items")
+	assert.NotNil(suite.T(), parser.NewOperation(p, ExamplePackageName).ParseResponseComment("200 {array} []string"), "Array of arrays should fail")
+
+	op = parser.NewOperation(p, ExamplePackageName)
+	assert.Nil(suite.T(), op.ParseResponseComment("200 object SimpleStructure"), "Can not parse response comment without braces")
+	assert.Equal(suite.T(), modelId, op.Type, "Response without braces should be its model")
+	if assert.Len(suite.T(), op.ResponseMessages, 1, "Can not parse response comment without braces") {
+		assert.Equal(suite.T(), modelId, op.ResponseMessages[0].ResponseModel, "Response without braces should keep its model")
+		assert.Equal(suite.T(), "", op.ResponseMessages[0].Message, "Response without braces should have no message")
+	}
+}
+
+func (suite *OperationSuite) TestParseFailureComment() {
+	p := parser.NewParser()
+	p.ParseTypeDefinitions(ExamplePackageName)
+	p.CurrentPackage = ExamplePackageName
+
+	operationComment := `
+// @Success 200 {object} SimpleStructure
+// @Failure 400 {object} subpackage.SimpleStructure "bad request"
+// @Failure 409 {array} SimpleStructure "Conflicting orders"
+// @Failure 404 "Order not found"
+// @Failure 500
+// @Router /orders/{id} [get]
+`
+	op := parser.NewOperation(p, ExamplePackageName)
+	for _, line := range strings.Split(operationComment, "\n") {
+		err := op.ParseComment(line)
+		assert.Nil(suite.T(), err, "Can not parse failure comment")
+	}
+
+	errorModelId := "github.com.RobotsAndPencils.go-swaggerLite.example.subpackage.SimpleStructure"
+	expected := []parser.ResponseMessage{
+		{Code: 200, ResponseModel: "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleStructure"},
+		{Code: 400, Message: "bad request", ResponseModel: errorModelId},
+		{Code: 409, Message: "Conflicting orders", ResponseModel: "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleStructure"},
+		{Code: 404, Message: "Order not found"},
+		{Code: 500},
+	}
+	if assert.Len(suite.T(), op.ResponseMessages, len(expected), "Can not parse failure comment") {
+		for i, response := range expected {
+			assert.Equal(suite.T(), response.Code, op.ResponseMessages[i].Code, "Can not parse failure comment")
+			assert.Equal(suite.T(), response.Message, op.ResponseMessages[i].Message, "Can not parse failure comment")
+			assert.Equal(suite.T(), response.ResponseModel, op.ResponseMessages[i].ResponseModel, "Can not parse failure comment")
+		}
+	}
+	assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleStructure", op.Type, "Failures should not change the type of the operation")
+	modelIds := make([]string, 0)
+	for _, model := range op.Models {
+		modelIds = append(modelIds, model.Id)
+	}
+	assert.Contains(suite.T(), modelIds, errorModelId, "Model of the failure not added")
+
+	p.AddOperation(op)
+	responses := p.Swagger20().Paths["/orders/{id}"]["get"].Responses
+	assert.Equal(suite.T(), &parser.Schema{Ref: "#/definitions/" + errorModelId}, responses["400"].Schema, "Failure should reference its model")
+	assert.Equal(suite.T(), "array", responses["409"].Schema.Type, "Failure should be an array of its model")
+	assert.Nil(suite.T(), responses["404"].Schema, "Failure without a model should have no schema")
+	assert.Equal(suite.T(), "Internal Server Error", responses["500"].Description, "Failure without a message should be described by its code")
+
+	assert.NotNil(suite.T(), op.ParseComment("// @Failure bad request"), "Failure must have a response code")
+}
+
+func (suite *OperationSuite) TestParseResponseCommentWithMediaTypes() {
+	p := parser.NewParser()
+	p.ParseTypeDefinitions(ExamplePackageName)
+	p.CurrentPackage = ExamplePackageName
+
+	operationComment := `
+// @Produce json,xml
+// @Success 200 {object} subpackage.SimpleStructure application/xml "The order"
+// @Success 200 {object} SimpleStructure application/json
+// @Failure 400 {object} SimpleStructure "bad request"
+// @Failure 400 {object} subpackage.SimpleStructure application/xml
+// @Router /orders/{id} [get]
+`
+	op := parser.NewOperation(p, ExamplePackageName)
+	for _, line := range strings.Split(operationComment, "\n") {
+		err := op.ParseComment(line)
+		assert.Nil(suite.T(), err, "Can not parse response comment with a media type")
+	}
+
+	jsonModelId := "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleStructure"
+	xmlModelId := "github.com.RobotsAndPencils.go-swaggerLite.example.subpackage.SimpleStructure"
+	if assert.Len(suite.T(), op.ResponseMessages, 2, "Responses of the same code should be merged") {
+		success := op.ResponseMessages[0]
+		assert.Equal(suite.T(), jsonModelId, success.ResponseModel, "JSON variant should be the response")
+		assert.Equal(suite.T(), "The order", success.Message, "Message of the response should be kept")
+		if assert.Len(suite.T(), success.Variants, 1, "XML variant should be kept") {
+			assert.Equal(suite.T(), xmlModelId, success.Variants[0].ResponseModel, "XML variant should be kept")
+		}
+		assert.Equal(suite.T(), jsonModelId, op.Type, "Type of the operation should be the JSON variant")
+		assert.Equal(suite.T(), jsonModelId, op.ResponseMessages[1].ResponseModel, "Response without media type should be the response")
+	}
+
+	p.AddOperation(op)
+	swaggerResponses := p.Swagger20().Paths["/orders/{id}"]["get"].Responses
+	assert.Equal(suite.T(), "#/definitions/"+jsonModelId, swaggerResponses["200"].Schema.Ref, "Swagger 2.0 should keep the JSON variant")
+	openAPIResponses := p.OpenAPI3().Paths["/orders/{id}"]["get"].Responses
+	assert.Equal(suite.T(), map[string]*parser.OpenAPI3MediaType{
+		parser.ContentTypeJson: {Schema: &parser.Schema{Ref: "#/components/schemas/" + jsonModelId}},
+		parser.ContentTypeXml:  {Schema: &parser.Schema{Ref: "#/components/schemas/" + xmlModelId}},
+	}, openAPIResponses["200"].Content, "OpenAPI 3.0 should have a content per media type")
+	assert.Equal(suite.T(), map[string]*parser.OpenAPI3MediaType{
+		parser.ContentTypeJson: {Schema: &parser.Schema{Ref: "#/components/schemas/" + jsonModelId}},
+		parser.ContentTypeXml:  {Schema: &parser.Schema{Ref: "#/components/schemas/" + xmlModelId}},
+	}, openAPIResponses["400"].Content, "Variant should override the produced media type")
+
+	assert.NotNil(suite.T(), op.ParseComment("// @Success 200 {object} SimpleStructure application/xml"), "Media type of a response should be given once")
+
+	op = parser.NewOperation(p, ExamplePackageName)
+	assert.Nil(suite.T(), op.ParseComment(`// @Failure 409 {object} SimpleStructure read/write "Conflict"`), "Can not parse response comment")
+	assert.Equal(suite.T(), "", op.ResponseMessages[0].ContentType, "Only a registered top-level type should be a media type")
+	assert.Equal(suite.T(), "Conflict", op.ResponseMessages[0].Message, "Can not parse response message")
+}
+
+func (suite *OperationSuite) TestParseHeaderComment() {
+	operationComment := `
+// @Header 201 Location string "URL of the created order"
+// @Success 201 {simple} string
+// @Header 201 X-Request-Id string
+// @Failure 429 {simple} string "Too many requests"
+// @Header 429 X-Rate-Limit int "Requests remaining"
+// @Header 429 X-Rate-Limit-Reset int "Seconds until the limit is reset"
+`
+	op := parser.NewOperation(suite.parser, "test")
+	for _, line := range strings.Split(operationComment, "\n") {
+		err := op.ParseComment(line)
+		assert.Nil(suite.T(), err, "Can not parse header comment")
+	}
+
+	assert.Len(suite.T(), op.ResponseMessages, 2, "Can not parse header comment")
+	assert.Equal(suite.T(), map[string]parser.ResponseHeader{
+		"Location":     {Type: "string", Description: "URL of the created order"},
+		"X-Request-Id": {Type: "string"},
+	}, op.ResponseMessages[0].Headers, "Can not parse header comment")
+	assert.Equal(suite.T(), map[string]parser.ResponseHeader{
+		"X-Rate-Limit":       {Type: "int", Description: "Requests remaining"},
+		"X-Rate-Limit-Reset": {Type: "int", Description: "Seconds until the limit is reset"},
+	}, op.ResponseMessages[1].Headers, "Can not parse header comment")
+
+	assert.NotNil(suite.T(), op.ParseHeaderComment("@Header 200 X-Order Order"), "Header type must be a basic type")
+	assert.NotNil(suite.T(), op.ParseHeaderComment("@Header X-Rate-Limit int"), "Header must have a response code")
+}
+
+func (suite *OperationSuite) TestParseTagsComment() {
+	op := parser.NewOperation(suite.parser, "test")
+	err := op.ParseComment("// @Tags users, admin")
+	assert.Nil(suite.T(), err, "Can not parse tags comment")
+	err = op.ParseComment("// @Tags accounts,users")
+	assert.Nil(suite.T(), err, "Can not parse tags comment")
+	assert.Equal(suite.T(), []string{"accounts", "admin", "users"}, op.Tags, "Can not parse tags comment")
+
+	assert.NotNil(suite.T(), op.ParseComment("// @Tags"), "Tags comment without tags should fail")
+}
+
+func (suite *OperationSuite) TestParseParamCommentWithFormat() {
+	op := parser.NewOperation(suite.parser, "test")
+	for _, line := range []string{
+		`@Param   id      path    string  true  "Order ID" format(uuid)`,
+		`@Param   email   query   string  false "Email of the customer" format(email)`,
+		`@Param   since   query   string  false "Created after" Format(date-time)`,
+		`@Param   total   query   int64   false "Total"`,
+		`@Param   count   query   int32   false "Count"`,
+		`@Param   page    query   int     false "Page"`,
+		`@Param   size    query   int     false "Size" format(int64)`,
+	} {
+		err := op.ParseParamComment(line)
+		assert.Nil(suite.T(), err, "Can not parse param comment with format")
+	}
+
+	formats := []string{}
+	for _, param := range op.Parameters {
+		formats = append(formats, param.Format)
+	}
+	assert.Equal(suite.T(), []string{"uuid", "email", "date-time", "int64", "int32", "", "int64"}, formats, "Can not parse param formats")
+
+	assert.NotNil(suite.T(), op.ParseParamComment(`@Param id path string true "Order ID" format()`), "Param comment with empty format should fail")
+	assert.NotNil(suite.T(), op.ParseParamComment(`@Param id path string true "Order ID" format(a b)`), "Param comment with invalid format should fail")
+}
+
+func (suite *OperationSuite) TestParseParamCommentWithValidations() {
+	op := parser.NewOperation(suite.parser, "test")
+	err := op.ParseParamComment(`@Param limit query int false "Page size" minimum(1) maximum(100)`)
+	assert.Nil(suite.T(), err, "Can not parse param comment with minimum and maximum")
+	err = op.ParseParamComment(`@Param name query string false "Name" minLength(3) maxLength(50) pattern(^[a-z]+$)`)
+	assert.Nil(suite.T(), err, "Can not parse param comment with length and pattern")
+	err = op.ParseParamComment(`@Param verb query string false "Verb" pattern(^(get|set)[A-Z]\w*$)`)
+	assert.Nil(suite.T(), err, "Can not parse param comment with a pattern with parentheses")
+
+	limit := op.Parameters[0]
+	assert.Equal(suite.T(), 1.0, *limit.Minimum, "Can not parse minimum clause")
+	assert.Equal(suite.T(), 100.0, *limit.Maximum, "Can not parse maximum clause")
+	assert.Nil(suite.T(), limit.MinLength, "Length should not be set for numeric params")
+
+	name := op.Parameters[1]
+	assert.Equal(suite.T(), 3, *name.MinLength, "Can not parse minLength clause")
+	assert.Equal(suite.T(), 50, *name.MaxLength, "Can not parse maxLength clause")
+	assert.Equal(suite.T(), "^[a-z]+$", name.Pattern, "Can not parse pattern clause")
+	assert.Nil(suite.T(), name.Minimum, "Minimum should not be set for string params")
+	assert.Equal(suite.T(), `^(get|set)[A-Z]\w*$`, op.Parameters[2].Pattern, "Can not parse pattern clause with parentheses")
+
+	paramJson, err := json.Marshal(limit)
+	assert.Nil(suite.T(), err, "Can not marshal param")
+	assert.Contains(suite.T(), string(paramJson), `"minimum":1,"maximum":100`, "Bounds not serialized")
+	assert.NotContains(suite.T(), string(paramJson), "minLength", "Unset validations should be omitted")
+
+	for _, line := range []string{
+		`@Param name query string false "Name" minimum(1)`,
+		`@Param limit query int false "Page size" maxLength(10)`,
+		`@Param limit query int false "Page size" pattern(^[0-9]+$)`,
+		`@Param limit query int false "Page size" minimum(one)`,
+		`@Param limit query int false "Page size" minimum(10) maximum(1)`,
+		`@Param name query string false "Name" minLength(-1)`,
+		`@Param name query string false "Name" minLength(5) maxLength(3)`,
+		`@Param name query string false "Name" pattern([a-z)`,
+	} {
+		assert.NotNil(suite.T(), op.ParseParamComment(line), "Param comment with invalid validation should fail: %s", line)
+	}
+}
+
+func (suite *OperationSuite) TestParseParamCommentWithExample() {
+	op := parser.NewOperation(suite.parser, "test")
+	for _, line := range []string{
+		`@Param   id      path    int64    true  "Order ID" example(42)`,
+		`@Param   name    query   string   false "Name" example(Bob)`,
+		`@Param   price   query   float64  false "Price" example(9.99)`,
+		`@Param   paid    query   bool     false "Paid" example(true)`,
+		`@Param   ids     query   []int    false "IDs" example([1, 2])`,
+		`@Param   filter  query   string   false "Filter" example({"status": "paid"})`,
+	} {
+		err := op.ParseParamComment(line)
+		assert.Nil(suite.T(), err, "Can not parse param comment with example: %s", line)
+	}
+
+	examples := []interface{}{}
+	for _, param := range op.Parameters {
+		examples = append(examples, param.Example)
+	}
+	assert.Equal(suite.T(), []interface{}{int64(42), "Bob", 9.99, true, []interface{}{1.0, 2.0}, map[string]interface{}{"status": "paid"}}, examples, "Can not parse param examples")
+
+	paramJson, err := json.Marshal(op.Parameters[0])
+	assert.Nil(suite.T(), err, "Can not marshal param")
+	assert.Contains(suite.T(), string(paramJson), `"example":42`, "Example not serialized")
+
+	for _, line := range []string{
+		`@Param id path int64 true "Order ID" example(forty-two)`,
+		`@Param paid query bool false "Paid" example(maybe)`,
+		`@Param ids query []int false "IDs" example([1, 2)`,
+	} {
+		assert.NotNil(suite.T(), op.ParseParamComment(line), "Param comment with invalid example should fail: %s", line)
+	}
+}
+
+func (suite *OperationSuite) TestParseParamCommentWithCollectionFormat() {
+	op := parser.NewOperation(suite.parser, "test")
+	for _, line := range []string{
+		`@Param ids query []int64 false "Order IDs"`,
+		`@Param tags query []string false "Tags" collectionFormat(multi)`,
+		`@Param names header []string false "Names" collectionFormat(pipes)`,
+	} {
+		assert.Nil(suite.T(), op.ParseParamComment(line), "Can not parse param comment with collection format: %s", line)
+	}
+
+	ids := op.Parameters[0]
+	assert.Equal(suite.T(), "array", ids.Type, "Array param type not parsed")
+	assert.Equal(suite.T(), &parser.OperationItems{Type: "int64"}, ids.Items, "Array param items not parsed")
+	assert.True(suite.T(), ids.AllowMultiple, "Array param should allow multiple values")
+	assert.Equal(suite.T(), "csv", ids.CollectionFormat, "Collection format should default to csv")
+	assert.Equal(suite.T(), "multi", op.Parameters[1].CollectionFormat, "Can not parse collection format")
+	assert.Equal(suite.T(), "pipes", op.Parameters[2].CollectionFormat, "Can not parse collection format")
+
+	paramJson, err := json.Marshal(ids)
+	assert.Nil(suite.T(), err, "Can not marshal param")
+	assert.Contains(suite.T(), string(paramJson), `"x-collectionFormat":"csv"`, "Collection format not serialized")
+
+	for _, line := range []string{
+		`@Param name query string false "Name" collectionFormat(csv)`,
+		`@Param ids query []int false "IDs" collectionFormat(commas)`,
+		`@Param ids header []int false "IDs" collectionFormat(multi)`,
+		`@Param users query []User false "Users"`,
+	} {
+		assert.NotNil(suite.T(), op.ParseParamComment(line), "Param comment with invalid collection format should fail: %s", line)
+	}
+}
+
+func (suite *OperationSuite) TestParseGoSwaggerComment() {
+	comment := `
+// swagger:route GET /pets/{id} pets store getPet
+//
+// Gets a pet
+// by its id.
+//
+// Pets which were sold are returned as well.
+//
+//     Produces:
+//     - application/json
+//     - application/xml
+//
+//     Deprecated: true
+//
+//     Responses:
+//       default: genericError
+//       200: petResponse
+//       404: notFound
+// @Param id path int true "Pet ID"
+`
+	p := parser.NewParser()
+	p.GoSwaggerAnnotations = true
+	op := parser.NewOperation(p, "test")
+	for _, line := range strings.Split(strings.TrimSpace(comment), "\n") {
+		assert.Nil(suite.T(), op.ParseComment(line), "Can not parse go-swagger comment %s", line)
+	}
+
+	assert.Equal(suite.T(), "/pets/{id}", op.Path, "Can not parse go-swagger route")
+	assert.Equal(suite.T(), "GET", op.HttpMethod, "Can not parse go-swagger route")
+	assert.Equal(suite.T(), "getPet", op.OperationId, "Can not parse go-swagger route")
+	assert.Equal(suite.T(), "getPet", op.Nickname, "Operation id should be the nickname")
+	assert.Equal(suite.T(), []string{"pets", "store"}, op.Tags, "Can not parse go-swagger route")
+	assert.Equal(suite.T(), "Gets a pet by its id.", op.Summary, "First paragraph should be the summary")
+	assert.Equal(suite.T(), "Pets which were sold are returned as well.", op.Notes, "Following paragraphs should be the notes")
+	assert.Equal(suite.T(), []string{"application/json", "application/xml"}, op.Produces, "Can not parse go-swagger produces")
+	assert.Equal(suite.T(), "true", op.Deprecated, "Can not parse go-swagger deprecated")
+	assert.Equal(suite.T(), []parser.ResponseMessage{{Code: 200, Message: "petResponse"}, {Code: 404, Message: "notFound"}}, op.ResponseMessages, "Can not parse go-swagger responses")
+	if assert.Len(suite.T(), op.Parameters, 1, "Annotations should follow go-swagger comments") {
+		assert.Equal(suite.T(), "id", op.Parameters[0].Name, "Annotations should follow go-swagger comments")
+	}
+
+	op = parser.NewOperation(p, "test")
+	for _, line := range []string{"// swagger:operation POST /pets createPet", "// ---", "// responses:", "//   '200':"} {
+		assert.Nil(suite.T(), op.ParseComment(line), "Can not parse go-swagger comment %s", line)
+	}
+	assert.Equal(suite.T(), "POST", op.HttpMethod, "Can not parse go-swagger operation")
+	assert.Len(suite.T(), op.ResponseMessages, 0, "The YAML spec of a go-swagger operation should be skipped")
+
+	assert.NotNil(suite.T(), op.ParseComment("// swagger:route GET /pets"), "go-swagger route without operation id should fail")
+	assert.NotNil(suite.T(), op.ParseComment("// swagger:route FETCH /pets getPets"), "go-swagger route with unknown method should fail")
+
+	op = parser.NewOperation(suite.parser, "test")
+	assert.Nil(suite.T(), op.ParseComment("// swagger:route GET /pets listPets"), "go-swagger comments should be ignored by default")
+	assert.Equal(suite.T(), "", op.Path, "go-swagger comments should be ignored by default")
+}
+
+func (suite *OperationSuite) TestParseIdComment() {
+	op := parser.NewOperation(suite.parser, "test")
+	assert.Nil(suite.T(), op.ParseComment("// @ID createUser"), "Can not parse id comment")
+	assert.Equal(suite.T(), "createUser", op.OperationId, "Can not parse id comment")
+
+	operationJson, err := json.Marshal(op)
+	assert.Nil(suite.T(), err, "Can not marshal operation")
+	assert.Contains(suite.T(), string(operationJson), `"x-operationId":"createUser"`, "Operation id not serialized")
+
+	assert.NotNil(suite.T(), op.ParseComment("// @ID"), "Id comment without id should fail")
+	assert.NotNil(suite.T(), op.ParseComment("// @ID create user"), "Id comment with several ids should fail")
+}
+
+func (suite *OperationSuite) TestParseMultiLineDescription() {
+	operationComment := `
+// GetOrder is not part of the description
+// @Description Get an order
+// The order is looked up by its number.
+//    Cancelled orders are returned as well.
+//
+// Archived orders are not.
+//
+// @Param   id     path    int     true        "Order ID"
+// @Success 200 {simple} string
+// @Router /orders/{id} [get]
+`
+	op := parser.NewOperation(suite.parser, "test")
+	for _, line := range strings.Split(operationComment, "\n") {
+		err := op.ParseComment(line)
+		assert.Nil(suite.T(), err, "Can not parse multi-line description")
+	}
+
+	assert.Equal(suite.T(), "Get an order", op.Summary, "The first line of the description should be the summary")
+	assert.Equal(suite.T(), "The order is looked up by its number.\nCancelled orders are returned as well.\n\nArchived orders are not.", op.Notes, "Can not parse multi-line description")
+	assert.Len(suite.T(), op.Parameters, 1, "Annotations following the description should be parsed")
+	assert.Len(suite.T(), op.ResponseMessages, 1, "Annotations following the description should be parsed")
+	assert.Equal(suite.T(), "/orders/{id}", op.Path, "Annotations following the description should be parsed")
+
+	op2 := parser.NewOperation(suite.parser, "test")
+	assert.Nil(suite.T(), op2.ParseComment("// @Description Get an order"), "Can not parse single-line description")
+	assert.Nil(suite.T(), op2.ParseComment("// @Router /orders/{id} [get]"), "Can not parse router comment")
+	assert.Nil(suite.T(), op2.ParseComment("// Trailing text after the annotations"), "Can not parse comment")
+	assert.Equal(suite.T(), "Get an order", op2.Summary, "Can not parse single-line description")
+	assert.Empty(suite.T(), op2.Notes, "Lines after other annotations should not be part of the description")
+}
+
+func (suite *OperationSuite) TestParseCommentWithAnnotationPrefixes() {
+	operationComment := `
+// @Title getOrder
+// @swagger:Title fetchOrder
+// @swagger:Description get an order
+// @swagger:Router /orders/{id} [get]
+// @Param   id     path    int     true        "Order ID"
+// @Success 200 {simple} string
+// @SWAGGER:Failure 404 {simple} string "Order not found"
+// @openapi:Tags orders
+`
+	p := parser.NewParser()
+	p.AnnotationPrefixes = []string{"@", "@swagger:"}
+	op := parser.NewOperation(p, "test")
+	for _, line := range strings.Split(operationComment, "\n") {
+		err := op.ParseComment(line)
+		assert.Nil(suite.T(), err, "Can not parse comment with annotation prefixes")
+	}
+
+	assert.Equal(suite.T(), "getOrder", op.Nickname, "The first of the prefixes given for an attribute should win")
+	assert.Equal(suite.T(), "get an order", op.Summary, "Can not parse comment with annotation prefix")
+	assert.Equal(suite.T(), "/orders/{id}", op.Path, "Can not parse comment with annotation prefix")
+	assert.Len(suite.T(), op.Parameters, 1, "Can not parse comment with annotation prefix")
+	assert.Len(suite.T(), op.ResponseMessages, 2, "Can not parse comment with annotation prefix")
+	assert.Empty(suite.T(), op.Tags, "Annotation with unknown prefix should be ignored")
+
+	p.LibraryMode = true
+	mixed := parser.NewOperation(p, "test")
+	for _, line := range []string{
+		"// @Param   id     path    int     true        \"Order ID\"",
+		"// @swagger:Param   verbose     query    bool     false        \"Verbose output\"",
+		"// @Title getOrder",
+		"// @swagger:Title fetchOrder",
+	} {
+		assert.Nil(suite.T(), mixed.ParseComment(line), "Can not parse comment with annotation prefixes")
+	}
+	if assert.Len(suite.T(), mixed.Parameters, 2, "Repeatable attributes should be kept under every prefix") {
+		assert.Equal(suite.T(), "verbose", mixed.Parameters[1].Name, "Can not parse comment with annotation prefix")
+	}
+	assert.Equal(suite.T(), "getOrder", mixed.Nickname, "The first of the prefixes given for an attribute should win")
+	if assert.Len(suite.T(), p.Warnings, 1, "The skipped annotation should be warned about") {
+		assert.Contains(suite.T(), p.Warnings[0].Error(), "@swagger:Title fetchOrder", "The warning should name the skipped annotation")
+	}
+
+	p.AnnotationPrefixes = []string{"@swagger:"}
+	op2 := parser.NewOperation(p, "test")
+	assert.Nil(suite.T(), op2.ParseComment("// @Title getOrder"), "Can not parse comment without annotation prefix")
+	assert.Empty(suite.T(), op2.Nickname, "Annotation without an accepted prefix should be ignored")
+}
+
+func (suite *OperationSuite) TestParseParamShorthands() {
+	operationComment := `
+// @PathID   user_id int64 "User ID"
+// @PathID   order_id int
+// @QueryPage
+// @QueryLimit "Orders per page"
+`
+	op := parser.NewOperation(suite.parser, "test")
+	for _, line := range strings.Split(operationComment, "\n") {
+		err := op.ParseComment(line)
+		assert.Nil(suite.T(), err, "Can not parse param shorthand")
+	}
+
+	assert.Equal(suite.T(), []parser.Parameter{
+		{Name: "user_id", ParamType: "path", Type: "int64", DataType: "int64", Format: "int64", Required: true, Description: "User ID"},
+		{Name: "order_id", ParamType: "path", Type: "int", DataType: "int", Required: true, Description: "ID"},
+		{Name: "page", ParamType: "query", Type: "int", DataType: "int", Description: "Page number"},
+		{Name: "limit", ParamType: "query", Type: "int", DataType: "int", Description: "Orders per page"},
+	}, op.Parameters, "Can not parse param shorthand")
+
+	assert.NotNil(suite.T(), op.ParseComment("// @PathID"), "Path ID needs a name and a type")
+}
+
+func (suite *OperationSuite) TestParseCustomParamShorthand() {
+	p := parser.NewParser()
+	p.ParamShorthands["@querysort"] = func(args string) (string, error) {
+		return `sort query string false "Sort order" Enums(asc, desc)`, nil
+	}
+
+	op := parser.NewOperation(p, "test")
+	err := op.ParseComment("// @QuerySort")
+	assert.Nil(suite.T(), err, "Can not parse custom param shorthand")
+	assert.Len(suite.T(), op.Parameters, 1, "Can not parse custom param shorthand")
+	assert.Equal(suite.T(), "sort", op.Parameters[0].Name, "Can not parse custom param shorthand")
+	assert.Equal(suite.T(), []string{"asc", "desc"}, op.Parameters[0].Enum, "Can not parse custom param shorthand")
+}
+
 func (suite *OperationSuite) TestParseComment() {
 	operationComment := `
 // @Title getOrderByNumber
@@ -1,16 +1,25 @@
 package parser
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"go/ast"
+	"go/build"
 	goparser "go/parser"
 	"go/token"
+	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 type Parser struct {
@@ -19,62 +28,599 @@ type Parser struct {
 	PackagesCache                     map[string]map[string]*ast.Package
 	CurrentPackage                    string
 	TypeDefinitions                   map[string]map[string]*ast.TypeSpec
+	EnumValues                        map[string]map[string][]string
+	EnumVarNames                      map[string]map[string][]string // names of the constants of EnumValues, in the same order
 	PackagePathCache                  map[string]string
-	PackageImports                    map[string]map[string]string
+	PackageImports                    map[string]map[string]string // import paths by the name they are referenced with, dot imports by "." and their path
 	BasePath                          string
+	Schemes                           []string // transfer protocols of the API, from @Schemes
+	Consumes                          []string // default content types of the operations, from the general @Accept
+	Produces                          []string // default content types of the operations, from the general @Produce
 	IsController                      func(*ast.FuncDecl) bool
-	TypesImplementingMarshalInterface map[string]string
+	TypesImplementingMarshalInterface map[string]string                        // by import path or bare name, and type name, besides those ParseTypeDefinitions finds, see IsImplementMarshalInterface
+	ModelSchemas                      map[string]json.RawMessage               // @Schema overrides, by real package path and type name
+	ModelNamer                        func(pkg string, typeName string) string // names the models, see DefaultModelNamer
+	TypeMappings                      map[string]TypeMapping                   // field types documented as Swagger primitives, see DefaultTypeMappings
+	ParamShorthands                   map[string]ParamShorthand                // annotations expanded into a @Param, see DefaultParamShorthands
+	AnnotationPrefixes                []string                                 // prefixes annotations are written with, e.g. "@" or "@swagger:"
+	IgnoreDirs                        []string                                 // names of the directories ScanPackages does not descend into
+	GoSwaggerAnnotations              bool                                     // also parse the swagger:route and swagger:operation comments of go-swagger
+	SwaggerVersion                    string                                   // stamped on the resource listing and the api declarations, see SpecVersion
+	OpenAPIVersion                    string                                   // of the specs built by OpenAPI3, see SpecVersion
+	GroupByReceiver                   bool                                     // group the operations of controller methods by their receiver, see ReceiverResource
+	RoutePrefix                       string                                   // the paths of the operations are mounted under, e.g. "/api/v2", see AddOperation
+	PathNormalizer                    func(path string) string                 // rewrites the paths of the operations, e.g. ColonPathParams, see AddOperation
+	ReusableParams                    map[string]Parameter                     // declared once with @Parameter, by name, see ParseParameterDefinition
+	IncludeAllModels                  bool                                     // also define the models no operation references, see referencedSchemas
+	GlobalResponses                   []GlobalResponse                         // declared once with @GlobalResponse, see ParseGlobalResponseDefinition
+	MaxScanDepth                      int                                      // how deep ScanPackages descends below each package, 0 means no limit
+	BuildContext                      *build.Context                           // GOOS, GOARCH and build tags the files of a package must match, build.Default if nil
+	FileFilter                        func(os.FileInfo) bool                   // the files of a package which are parsed, see ParserFileFilter
+	// LibraryMode keeps the process alive on failures, the parser never calls os.Exit or log.Fatalf then: the
+	// exported methods with an error result, like ParseApiContext or ParseTypeDefinitions, return them as a
+	// *FatalError, the others and the failures parsing goes on after are collected into Warnings, see collectFatal
+	LibraryMode bool
+	Warnings    []error
+	// Logger traces the resolution of packages and the parsing of their types and models, e.g. to find out why
+	// the definition of a model can not be found. The parser logs nothing of the kind when it is nil
+	Logger *log.Logger
+
+	ctx                       context.Context            // of the running ParseApiContext
+	typeDefinitionsInProgress map[string]bool            // real paths of the packages ParseTypeDefinitions is parsing
+	modelNameOrigins          map[string]map[string]bool // types the models are parsed from, by model name
+	apiPackages               map[string]bool            // packages ParseApiDescription has parsed
+	typeDefinitionsParsed     map[string]bool            // real paths of the packages ParseTypeDefinitions has parsed
+	declarationCacheDir       string                     // see EnableDeclarationCache
+	fileSet                   *token.FileSet             // of the parsed packages, for the positions of the failures
+	dryRun                    bool                       // see ValidateApi
+	moduleDirs                map[string]string          // directories of the modules of the local packages, by module path
+	mainFileImports           map[string]string          // import paths of the main API file, by the name they are referenced with
+	basePathAnnotated         bool                       // BasePath is from @BasePath, so Reset drops it
+	marshalerTypes            map[string]bool            // types with a MarshalJSON method ParseTypeDefinitions found, by real package path and type name
+	globalResponseOperations  map[string]*Operation      // the GlobalResponses parsed, by name, see globalResponse
+	resourceDescriptions      map[string]string          // of the resources, by path, see ParseSubApiDescription
+	listingMutex              sync.Mutex                 // guards the api declarations and the resource listing
+}
+
+// FatalError is the failure which terminates the process, unless the parser is in LibraryMode
+type FatalError struct {
+	Message string
+}
+
+func (err *FatalError) Error() string {
+	return err.Message
+}
+
+// fatalf terminates the process, or in LibraryMode aborts the parsing with a *FatalError panic, see recoverFatal
+func (parser *Parser) fatalf(format string, args ...interface{}) {
+	if !parser.LibraryMode {
+		log.Fatalf(format, args...)
+	}
+	panic(&FatalError{Message: strings.TrimSpace(fmt.Sprintf(format, args...))})
+}
+
+// recoverFatal returns the *FatalError an aborted parsing panics with from the deferring function through err
+func recoverFatal(err *error) {
+	if recovered := recover(); recovered != nil {
+		fatalError, ok := recovered.(*FatalError)
+		if !ok {
+			panic(recovered)
+		}
+		*err = fatalError
+	}
+}
+
+// collectFatal collects the *FatalError an aborted parsing panics with into Warnings, for the exported methods
+// without an error result, which return their zero values then
+func (parser *Parser) collectFatal() {
+	if recovered := recover(); recovered != nil {
+		fatalError, ok := recovered.(*FatalError)
+		if !ok {
+			panic(recovered)
+		}
+		parser.Warnings = append(parser.Warnings, fatalError)
+	}
+}
+
+// warnf logs a failure which parsing goes on after, or in LibraryMode collects it into Warnings
+func (parser *Parser) warnf(format string, args ...interface{}) {
+	if !parser.LibraryMode {
+		log.Printf(format, args...)
+		return
+	}
+	parser.Warnings = append(parser.Warnings, errors.New(strings.TrimSpace(fmt.Sprintf(format, args...))))
+}
+
+// debugf writes a trace message to the Logger, if there is one
+func (parser *Parser) debugf(format string, args ...interface{}) {
+	if parser.Logger != nil {
+		parser.Logger.Printf(format, args...)
+	}
 }
 
 func NewParser() *Parser {
-	return &Parser{
-		Listing: &ResourceListing{
-			Infos: Infomation{},
-			Apis:  make([]*ApiRef, 0),
-		},
-		PackagesCache:                     make(map[string]map[string]*ast.Package),
-		TopLevelApis:                      make(map[string]*ApiDeclaration),
-		TypeDefinitions:                   make(map[string]map[string]*ast.TypeSpec),
-		PackagePathCache:                  make(map[string]string),
-		PackageImports:                    make(map[string]map[string]string),
+	parser := &Parser{
+		ModelNamer:                        DefaultModelNamer,
+		FileFilter:                        ParserFileFilter,
+		TypeMappings:                      DefaultTypeMappings(),
 		TypesImplementingMarshalInterface: make(map[string]string),
+		ParamShorthands:                   DefaultParamShorthands(),
+		IgnoreDirs:                        []string{"vendor", "Godeps", ".git", "node_modules", "testdata"},
+		AnnotationPrefixes:                []string{"@"},
+		SwaggerVersion:                    SwaggerVersion,
+		OpenAPIVersion:                    OpenAPI3Version,
+	}
+	parser.Reset()
+	parser.UseRouterAnnotationDetection()
+	return parser
+}
+
+// SpecVersion returns the version of the specs the parser writes in one of the formats of GenerateSpec. The
+// version of Swagger 1.2 and OpenAPI 3.0 can be overridden with the SwaggerVersion and OpenAPIVersion fields,
+// e.g. to declare a patch version of OpenAPI 3.0 the specs are checked against, Swagger 2.0 is always "2.0".
+// An empty field, e.g. of a Parser not made by NewParser, stands for the default version
+func (parser *Parser) SpecVersion(format string) string {
+	switch strings.ToLower(format) {
+	case FormatSwagger20:
+		return Swagger20Version
+	case FormatOpenAPI3:
+		if parser.OpenAPIVersion == "" {
+			return OpenAPI3Version
+		}
+		return parser.OpenAPIVersion
+	}
+	if parser.SwaggerVersion == "" {
+		return SwaggerVersion
+	}
+	return parser.SwaggerVersion
+}
+
+// DefaultModelNamer names a model after its package path and type name, e.g. "github.com.user.project.api.User"
+func DefaultModelNamer(pkg string, typeName string) string {
+	return strings.Join(append(strings.Split(pkg, "/"), typeName), ".")
+}
+
+// ModelName is the name of the model of typeName in pkg, used in the models and in the references to them
+func (parser *Parser) ModelName(pkg string, typeName string) string {
+	if parser.ModelNamer == nil {
+		return DefaultModelNamer(pkg, typeName)
+	}
+	return parser.ModelNamer(pkg, typeName)
+}
+
+// recordModelName remembers the type, e.g. "github.com/user/project/api.Page[string]", a model name is given to
+func (parser *Parser) recordModelName(modelName string, qualifiedTypeName string) {
+	if parser.modelNameOrigins == nil {
+		parser.modelNameOrigins = make(map[string]map[string]bool)
+	}
+	if _, ok := parser.modelNameOrigins[modelName]; !ok {
+		parser.modelNameOrigins[modelName] = make(map[string]bool)
+	}
+	parser.modelNameOrigins[modelName][qualifiedTypeName] = true
+}
+
+// Validate reports the model names given to several types, e.g. to types of the same name in two packages,
+// whose models would overwrite each other in the output, and the operation ids given to several operations
+func (parser *Parser) Validate() []error {
+	modelNames := make([]string, 0, len(parser.modelNameOrigins))
+	for modelName := range parser.modelNameOrigins {
+		modelNames = append(modelNames, modelName)
+	}
+	sort.Strings(modelNames)
+
+	errs := make([]error, 0)
+	for _, modelName := range modelNames {
+		if len(parser.modelNameOrigins[modelName]) < 2 {
+			continue
+		}
+		origins := make([]string, 0, len(parser.modelNameOrigins[modelName]))
+		for origin := range parser.modelNameOrigins[modelName] {
+			origins = append(origins, origin)
+		}
+		sort.Strings(origins)
+		errs = append(errs, fmt.Errorf("Model name %s is given to several types: %s", modelName, strings.Join(origins, ", ")))
+	}
+	return append(errs, parser.validateOperationIds()...)
+}
+
+// validateOperationIds reports the operation ids given to several operations, which client generators
+// can not tell apart
+func (parser *Parser) validateOperationIds() []error {
+	operationIds := make(map[string][]string)
+	for _, api := range parser.TopLevelApis {
+		for _, subApi := range api.Apis {
+			for _, op := range subApi.Operations {
+				if operationId := op.specOperationId(); operationId != "" {
+					operation := op.HttpMethod + " " + op.Path
+					if location := op.location(); location != "" {
+						operation += " (" + location + ")"
+					}
+					operationIds[operationId] = append(operationIds[operationId], operation)
+				}
+			}
+		}
+	}
+	duplicateIds := make([]string, 0)
+	for operationId, operations := range operationIds {
+		if len(operations) > 1 {
+			duplicateIds = append(duplicateIds, operationId)
+		}
+	}
+	sort.Strings(duplicateIds)
+
+	errs := make([]error, 0, len(duplicateIds))
+	for _, operationId := range duplicateIds {
+		operations := operationIds[operationId]
+		sort.Strings(operations)
+		errs = append(errs, fmt.Errorf("Operation id %s is given to several operations: %s", operationId, strings.Join(operations, ", ")))
+	}
+	return errs
+}
+
+// Reset drops everything parsed so far, so the parser can parse again, e.g. after the sources changed, without
+// keeping deleted operations or models. The configuration, like BasePath, TypeMappings or IsController, is kept,
+// except a BasePath from @BasePath
+func (parser *Parser) Reset() {
+	parser.Listing = &ResourceListing{
+		Infos: Infomation{},
+		Apis:  make([]*ApiRef, 0),
+	}
+	parser.TopLevelApis = make(map[string]*ApiDeclaration)
+	parser.PackagesCache = make(map[string]map[string]*ast.Package)
+	parser.fileSet = token.NewFileSet()
+	parser.CurrentPackage = ""
+	parser.TypeDefinitions = make(map[string]map[string]*ast.TypeSpec)
+	parser.EnumValues = make(map[string]map[string][]string)
+	parser.EnumVarNames = make(map[string]map[string][]string)
+	parser.PackagePathCache = make(map[string]string)
+	parser.PackageImports = make(map[string]map[string]string)
+	if parser.basePathAnnotated {
+		parser.BasePath = ""
+		parser.basePathAnnotated = false
+	}
+	parser.Schemes = nil
+	parser.Consumes = nil
+	parser.Produces = nil
+	parser.marshalerTypes = make(map[string]bool)
+	parser.ModelSchemas = make(map[string]json.RawMessage)
+	parser.typeDefinitionsInProgress = make(map[string]bool)
+	parser.typeDefinitionsParsed = make(map[string]bool)
+	parser.apiPackages = make(map[string]bool)
+	parser.resourceDescriptions = make(map[string]string)
+	parser.ReusableParams = make(map[string]Parameter)
+	parser.GlobalResponses = nil
+	parser.globalResponseOperations = make(map[string]*Operation)
+	parser.modelNameOrigins = nil
+	parser.mainFileImports = nil
+	parser.moduleDirs = nil
+	parser.Warnings = nil
+}
+
+// InvalidatePackage drops what is parsed from a package, e.g. after its files changed, so it is parsed again
+// the next time it is used
+func (parser *Parser) InvalidatePackage(packagePath string) {
+	defer parser.collectFatal()
+	parser.invalidatePackage(packagePath)
+}
+
+func (parser *Parser) invalidatePackage(packagePath string) {
+	pkgRealPath := parser.checkRealPackagePath(packagePath)
+	delete(parser.PackagePathCache, strings.Trim(packagePath, "\""))
+	if pkgRealPath == "" {
+		return
+	}
+	// the files are added to the file set again when the package is parsed again
+	for _, astPackage := range parser.PackagesCache[pkgRealPath] {
+		for _, astFile := range astPackage.Files {
+			if tokenFile := parser.fileSet.File(astFile.Pos()); tokenFile != nil {
+				parser.fileSet.RemoveFile(tokenFile)
+			}
+		}
+	}
+	delete(parser.PackagesCache, pkgRealPath)
+	delete(parser.TypeDefinitions, pkgRealPath)
+	delete(parser.EnumValues, pkgRealPath)
+	delete(parser.EnumVarNames, pkgRealPath)
+	delete(parser.PackageImports, pkgRealPath)
+	delete(parser.typeDefinitionsParsed, pkgRealPath)
+	parser.globalResponseOperations = make(map[string]*Operation)
+	for qualifiedTypeName := range parser.ModelSchemas {
+		if strings.HasPrefix(qualifiedTypeName, pkgRealPath+".") {
+			delete(parser.ModelSchemas, qualifiedTypeName)
+		}
+	}
+	for qualifiedTypeName := range parser.marshalerTypes {
+		if strings.HasPrefix(qualifiedTypeName, pkgRealPath+".") {
+			delete(parser.marshalerTypes, qualifiedTypeName)
+		}
+	}
+}
+
+// ReparsePackage parses a changed package again, along with the operations of the packages which import it,
+// directly or not, as their models may have changed. The operations parsed from them before are replaced
+func (parser *Parser) ReparsePackage(packagePath string) (err error) {
+	defer recoverFatal(&err)
+
+	affectedPackages := parser.dependentPackages(packagePath)
+	affectedPackages[packagePath] = true
+	parser.removeOperations(func(op *Operation) bool {
+		return affectedPackages[op.packageName]
+	})
+
+	parser.invalidatePackage(packagePath)
+	parser.parseTypeDefinitions(packagePath)
+
+	packages := make([]string, 0, len(affectedPackages))
+	for affectedPackage := range affectedPackages {
+		if parser.apiPackages[affectedPackage] {
+			packages = append(packages, affectedPackage)
+		}
+	}
+	sort.Strings(packages)
+	for _, affectedPackage := range packages {
+		parser.parseApiDescription(affectedPackage)
+	}
+	return nil
+}
+
+// dependentPackages returns the parsed packages which import packagePath, directly or not
+func (parser *Parser) dependentPackages(packagePath string) map[string]bool {
+	importPaths := make(map[string]string)
+	for importPath, pkgRealPath := range parser.PackagePathCache {
+		importPaths[pkgRealPath] = importPath
+	}
+	importers := make(map[string][]string)
+	for pkgRealPath, imports := range parser.PackageImports {
+		for _, importedPackage := range imports {
+			importers[importedPackage] = append(importers[importedPackage], importPaths[pkgRealPath])
+		}
+	}
+
+	dependents := make(map[string]bool)
+	queue := []string{packagePath}
+	for len(queue) > 0 {
+		for _, importer := range importers[queue[0]] {
+			if importer != "" && importer != packagePath && !dependents[importer] {
+				dependents[importer] = true
+				queue = append(queue, importer)
+			}
+		}
+		queue = queue[1:]
+	}
+	return dependents
+}
+
+// removeOperations removes the operations remove accepts, e.g. the ones parsed from a package,
+// and the api declarations left without operations
+func (parser *Parser) removeOperations(remove func(op *Operation) bool) {
+	parser.listingMutex.Lock()
+	defer parser.listingMutex.Unlock()
+	for resource, api := range parser.TopLevelApis {
+		operations := make([]*Operation, 0)
+		for _, subApi := range api.Apis {
+			for _, op := range subApi.Operations {
+				if !remove(op) {
+					operations = append(operations, op)
+				}
+			}
+		}
+
+		if len(operations) == 0 {
+			delete(parser.TopLevelApis, resource)
+			apiRefs := make([]*ApiRef, 0, len(parser.Listing.Apis))
+			for _, apiRef := range parser.Listing.Apis {
+				if apiRef.Path != api.ResourcePath {
+					apiRefs = append(apiRefs, apiRef)
+				}
+			}
+			parser.Listing.Apis = apiRefs
+			continue
+		}
+
+		// the models, produced and consumed types of the removed operations go with them
+		api.Apis = make([]*Api, 0)
+		api.Models = make(map[string]*Model)
+		api.Consumes = make([]string, 0)
+		api.Produces = make([]string, 0)
+		for _, op := range operations {
+			api.AddOperation(op)
+		}
+	}
+}
+
+// HasRouterAnnotation treats any function with a @Router (or @Route) annotation in its doc comment as a controller
+func HasRouterAnnotation(funcDeclaration *ast.FuncDecl) bool {
+	return hasRouterAnnotation(funcDeclaration, []string{"@"})
+}
+
+func hasRouterAnnotation(funcDeclaration *ast.FuncDecl, annotationPrefixes []string) bool {
+	if funcDeclaration.Doc == nil {
+		return false
+	}
+	for _, comment := range funcDeclaration.Doc.List {
+		commentLine, _ := NormalizeAnnotation(strings.TrimSpace(strings.TrimLeft(comment.Text, "//")), annotationPrefixes)
+		attribute := strings.ToLower(strings.Split(commentLine, " ")[0])
+		if attribute == "@router" || attribute == "@route" {
+			return true
+		}
+	}
+	return false
+}
+
+// UseRouterAnnotationDetection restores the default IsController, after it was overridden. The default
+// IsController is HasRouterAnnotation, recognizing the annotation with any of the AnnotationPrefixes,
+// and, with GoSwaggerAnnotations, the swagger:route and swagger:operation comments
+func (parser *Parser) UseRouterAnnotationDetection() {
+	parser.IsController = parser.hasRouteAnnotation
+}
+
+func (parser *Parser) hasRouteAnnotation(funcDeclaration *ast.FuncDecl) bool {
+	return hasRouterAnnotation(funcDeclaration, parser.AnnotationPrefixes) ||
+		parser.GoSwaggerAnnotations && hasGoSwaggerRoute(funcDeclaration)
+}
+
+// NormalizeAnnotation rewrites an annotation written with the longest matching of the prefixes to the "@" form,
+// e.g. "@swagger:Router /orders [get]" to "@Router /orders [get]", and returns the prefix it was written with.
+// Any other line is returned as an empty string, with an empty prefix
+func NormalizeAnnotation(commentLine string, prefixes []string) (string, string) {
+	matchedPrefix := ""
+	for _, prefix := range prefixes {
+		if len(prefix) > len(matchedPrefix) && len(commentLine) > len(prefix) && strings.EqualFold(commentLine[:len(prefix)], prefix) {
+			matchedPrefix = prefix
+		}
+	}
+	if matchedPrefix == "" {
+		return "", ""
+	}
+	return "@" + commentLine[len(matchedPrefix):], matchedPrefix
+}
+
+// annotationPrefixTracker remembers the prefix each attribute was first written with. When the same
+// single-valued attribute is written with another prefix as well, the first one wins
+type annotationPrefixTracker map[string]string
+
+// repeatableAnnotations are the attributes that may be given several times, under any of the prefixes
+var repeatableAnnotations = map[string]bool{
+	"@param":              true,
+	"@params":             true,
+	"@useparam":           true,
+	"@success":            true,
+	"@failure":            true,
+	"@header":             true,
+	"@security":           true,
+	"@tags":               true,
+	"@securitydefinition": true,
+	"@parameter":          true,
+	"@globalresponse":     true,
+	"@modeltag":           true,
+	"@allof":              true,
+	"@oneof":              true,
+	"@anyof":              true,
+	"@subtype":            true,
+	"@subtypes":           true,
+	"@required":           true,
+}
+
+// parseAnnotation normalizes an annotation, and returns it with its lowercase attribute, e.g. "@router".
+// The attribute is empty if the line is not an annotation, or if seen knows the single-valued attribute with another prefix
+func (parser *Parser) parseAnnotation(commentLine string, seen annotationPrefixTracker) (string, string) {
+	annotation, prefix := NormalizeAnnotation(commentLine, parser.AnnotationPrefixes)
+	if prefix == "" {
+		return commentLine, ""
+	}
+	attribute := strings.ToLower(strings.Split(annotation, " ")[0])
+	_, isShorthand := parser.ParamShorthands[attribute]
+	if seen != nil && !repeatableAnnotations[attribute] && !isShorthand {
+		if firstPrefix, ok := seen[attribute]; ok && firstPrefix != prefix {
+			parser.warnf("Annotation \"%s\" skipped, %s is already given with the %s prefix\n", commentLine, attribute, firstPrefix)
+			return commentLine, ""
+		}
+		seen[attribute] = prefix
 	}
+	return annotation, attribute
 }
 
+// IsImplementMarshalInterface reports whether typeName, qualified with the real path of its package, has a MarshalJSON method.
+// Besides the types found by ParseTypeDefinitions, TypesImplementingMarshalInterface can name them by import path,
+// e.g. "database/sql.NullString", or by the bare type name, e.g. "NullString" for such a type of any package
 func (parser *Parser) IsImplementMarshalInterface(typeName string) bool {
-	_, ok := parser.TypesImplementingMarshalInterface[typeName]
-	return ok
+	if parser.marshalerTypes[typeName] {
+		return true
+	}
+	if _, ok := parser.TypesImplementingMarshalInterface[typeName]; ok {
+		return true
+	}
+	for registeredName := range parser.TypesImplementingMarshalInterface {
+		if strings.HasSuffix(typeName, "/"+registeredName) {
+			return true
+		}
+		if !strings.Contains(registeredName, ".") && strings.HasSuffix(typeName, "."+registeredName) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMarshalJSONMethod reports whether funcDeclaration is a "MarshalJSON() ([]byte, error)" method
+func IsMarshalJSONMethod(funcDeclaration *ast.FuncDecl) bool {
+	if funcDeclaration.Recv == nil || funcDeclaration.Name.Name != "MarshalJSON" {
+		return false
+	}
+	funcType := funcDeclaration.Type
+	if funcType.Params.NumFields() != 0 || funcType.Results.NumFields() != 2 {
+		return false
+	}
+	bytesType, ok := funcType.Results.List[0].Type.(*ast.ArrayType)
+	if !ok || bytesType.Len != nil {
+		return false
+	}
+	if elementType, ok := bytesType.Elt.(*ast.Ident); !ok || elementType.Name != "byte" {
+		return false
+	}
+	errorType, ok := funcType.Results.List[len(funcType.Results.List)-1].Type.(*ast.Ident)
+	return ok && errorType.Name == "error"
+}
+
+// ReceiverTypeName returns the name of the type a method is declared on, without its pointer or type parameters,
+// e.g. Money for "func (m *Money) ..." or "func (m Money[T]) ...", or "" if the function is not a method
+func ReceiverTypeName(funcDeclaration *ast.FuncDecl) string {
+	if funcDeclaration.Recv == nil || len(funcDeclaration.Recv.List) == 0 {
+		return ""
+	}
+	receiverType := funcDeclaration.Recv.List[0].Type
+	if starExpr, ok := receiverType.(*ast.StarExpr); ok {
+		receiverType = starExpr.X
+	}
+	switch t := receiverType.(type) {
+	case *ast.IndexExpr:
+		receiverType = t.X
+	case *ast.IndexListExpr:
+		receiverType = t.X
+	}
+	if ident, ok := receiverType.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
 }
 
 // ParseGeneralAPIInfo reads web/main.go to get General info
 func (parser *Parser) ParseGeneralAPIInfo(mainAPIFile string) error {
+	return parser.parseGeneralAPIInfo(mainAPIFile, nil)
+}
+
+// ParseGeneralAPIInfoFromSrc gets General info from in-memory source code rather than a file on disk
+func (parser *Parser) ParseGeneralAPIInfoFromSrc(src []byte) error {
+	return parser.parseGeneralAPIInfo("", src)
+}
+
+func (parser *Parser) parseGeneralAPIInfo(mainAPIFile string, src interface{}) (err error) {
+	defer recoverFatal(&err)
 
-	fileSet := token.NewFileSet()
-	fileTree, err := goparser.ParseFile(fileSet, mainAPIFile, nil, goparser.ParseComments)
+	fileTree, err := goparser.ParseFile(parser.fileSet, mainAPIFile, src, goparser.ParseComments)
 	if err != nil {
 		return err
 	}
 
-	parser.Listing.SwaggerVersion = SwaggerVersion
-	if fileTree.Comments != nil {
-		for _, comment := range fileTree.Comments {
-			for _, commentLine := range strings.Split(comment.Text(), "\n") {
-				attribute := strings.ToLower(strings.Split(commentLine, " ")[0])
-				switch attribute {
-				case "@apiversion":
-					parser.Listing.ApiVersion = strings.TrimSpace(commentLine[len("@APIVersion"):])
-				case "@apititle":
-					parser.Listing.Infos.Title = strings.TrimSpace(commentLine[len("@ApiTitle"):])
-				case "@apidescription":
-					parser.Listing.Infos.Description = strings.TrimSpace(commentLine[len("@ApiDescription"):])
-				case "@termsofserviceurl":
-					parser.Listing.Infos.TermsOfServiceUrl = strings.TrimSpace(commentLine[len("@TermsOfServiceUrl"):])
-				case "@contact":
-					parser.Listing.Infos.Contact = strings.TrimSpace(commentLine[len("@Contact"):])
-				case "@licenseurl":
-					parser.Listing.Infos.LicenseUrl = strings.TrimSpace(commentLine[len("@LicenseUrl"):])
-				case "@license":
-					parser.Listing.Infos.License = strings.TrimSpace(commentLine[len("@License"):])
+	parser.Listing.SwaggerVersion = parser.SpecVersion(FormatSwagger12)
+	parser.mainFileImports = make(map[string]string)
+	for _, astImport := range fileTree.Imports {
+		importPath := strings.Trim(astImport.Path.Value, "\"")
+		importName := ImportName(importPath)
+		if astImport.Name != nil {
+			importName = astImport.Name.Name
+		}
+		parser.mainFileImports[importName] = importPath
+	}
+	seenPrefixes := make(annotationPrefixTracker)
+	for _, commentGroup := range fileTree.Comments {
+		// the comments of the handlers below, e.g. their @Accept, do not describe the API as a whole
+		if commentGroup.Pos() > fileTree.Package {
+			break
+		}
+		for _, comment := range commentGroup.List {
+			for _, commentLine := range strings.Split(commentText(comment), "\n") {
+				if err := parser.parseGeneralAPIComment(mainAPIFile, commentLine, seenPrefixes); err != nil {
+					return fmt.Errorf("%v: %v", parser.fileSet.Position(comment.Pos()), err)
 				}
 			}
 		}
@@ -82,32 +628,483 @@ func (parser *Parser) ParseGeneralAPIInfo(mainAPIFile string) error {
 	return nil
 }
 
+// parseGeneralAPIComment parses a line of the comments of the main API file
+func (parser *Parser) parseGeneralAPIComment(mainAPIFile string, commentLine string, seenPrefixes annotationPrefixTracker) error {
+	commentLine, attribute := parser.parseAnnotation(commentLine, seenPrefixes)
+	switch attribute {
+	case "@apiversion":
+		parser.Listing.ApiVersion = strings.TrimSpace(commentLine[len("@APIVersion"):])
+	case "@apititle":
+		parser.Listing.Infos.Title = strings.TrimSpace(commentLine[len("@ApiTitle"):])
+	case "@apidescription":
+		parser.Listing.Infos.Description = strings.TrimSpace(commentLine[len("@ApiDescription"):])
+	case "@apidescriptionfile":
+		parser.ParseApiDescriptionFile(mainAPIFile, strings.TrimSpace(commentLine[len("@ApiDescriptionFile"):]))
+	case "@termsofserviceurl":
+		parser.Listing.Infos.TermsOfServiceUrl = strings.TrimSpace(commentLine[len("@TermsOfServiceUrl"):])
+	case "@contact":
+		parser.Listing.Infos.Contact = strings.TrimSpace(commentLine[len("@Contact"):])
+	case "@licenseurl":
+		parser.Listing.Infos.LicenseUrl = strings.TrimSpace(commentLine[len("@LicenseUrl"):])
+	case "@license":
+		parser.Listing.Infos.License = strings.TrimSpace(commentLine[len("@License"):])
+	case "@securitydefinition":
+		if err := parser.ParseSecurityDefinition(commentLine); err != nil {
+			return err
+		}
+	case "@parameter":
+		if err := parser.parseParameterDefinition(commentLine); err != nil {
+			return err
+		}
+	case "@globalresponse":
+		if err := parser.ParseGlobalResponseDefinition(commentLine); err != nil {
+			return err
+		}
+	case "@basepath":
+		// a base path set beforehand, e.g. with the -basePath switch, wins
+		if parser.BasePath == "" {
+			parser.BasePath = strings.TrimSpace(commentLine[len("@BasePath"):])
+			parser.basePathAnnotated = true
+		}
+	case "@schemes":
+		if err := parser.ParseSchemesComment(commentLine); err != nil {
+			return err
+		}
+	case "@externaldocs":
+		externalDocs, err := ParseExternalDocsComment(commentLine)
+		if err != nil {
+			return err
+		}
+		parser.Listing.ExternalDocs = externalDocs
+	case "@accept":
+		contentTypes, err := ParseContentTypes(commentLine[len("@Accept"):])
+		if err != nil {
+			return fmt.Errorf("Can not parse accept comment \"%s\", %v.", commentLine, err)
+		}
+		parser.Consumes = appendContentTypes(parser.Consumes, contentTypes)
+	case "@produce", "@produces":
+		contentTypes, err := ParseContentTypes(commentLine[len(attribute):])
+		if err != nil {
+			return fmt.Errorf("Can not parse produce comment \"%s\", %v.", commentLine, err)
+		}
+		parser.Produces = appendContentTypes(parser.Produces, contentTypes)
+	default:
+		if strings.HasPrefix(attribute, "@x-") {
+			if err := parser.Listing.Extensions.add(commentLine); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// commentText returns the text of a comment like ast.CommentGroup.Text, so the lines of a comment group can be
+// told apart by their position
+func commentText(comment *ast.Comment) string {
+	return (&ast.CommentGroup{List: []*ast.Comment{comment}}).Text()
+}
+
+// ParseApiDescriptionFile loads the API description from a file, relative to the directory of the main API file.
+// A file which can not be read is only reported, the description is then left as it is
+func (parser *Parser) ParseApiDescriptionFile(mainAPIFile string, descriptionFile string) {
+	if !filepath.IsAbs(descriptionFile) && mainAPIFile != "" {
+		descriptionFile = filepath.Join(filepath.Dir(mainAPIFile), descriptionFile)
+	}
+	description, err := ioutil.ReadFile(descriptionFile)
+	if err != nil {
+		parser.warnf("Can not read API description file %s: %v\n", descriptionFile, err)
+		return
+	}
+	parser.Listing.Infos.Description = strings.TrimSpace(string(description))
+}
+
+// Parse security definition
+// @SecurityDefinition api_key apiKey header X-API-Key
+// @SecurityDefinition basic basic
+func (parser *Parser) ParseSecurityDefinition(commentLine string) error {
+	fields := strings.Fields(commentLine[len("@SecurityDefinition"):])
+	if len(fields) < 2 {
+		return fmt.Errorf("Can not parse security definition \"%s\", expected name and type.", commentLine)
+	}
+
+	definition := &AuthorizationDefinition{}
+	switch strings.ToLower(fields[1]) {
+	case "basic", "basicauth":
+		definition.Type = "basicAuth"
+	case "apikey":
+		if len(fields) != 4 {
+			return fmt.Errorf("Can not parse security definition \"%s\", apiKey needs header or query and the key name.", commentLine)
+		}
+		definition.Type = "apiKey"
+		definition.PassAs = strings.ToLower(fields[2])
+		definition.Keyname = fields[3]
+		if definition.PassAs != "header" && definition.PassAs != "query" {
+			return fmt.Errorf("Can not parse security definition \"%s\", apiKey must be passed as header or query.", commentLine)
+		}
+	default:
+		return fmt.Errorf("Can not parse security definition \"%s\", unsupported type %s.", commentLine, fields[1])
+	}
+
+	if parser.Listing.Authorizations == nil {
+		parser.Listing.Authorizations = make(map[string]*AuthorizationDefinition)
+	}
+	parser.Listing.Authorizations[fields[0]] = definition
+	return nil
+}
+
+// Parse a param operations reuse with @UseParam, declared like the param of an operation
+// @Parameter limit query int false "Page size" default(20)
+//
+// The reusable params are listed once, as the parameters of Swagger 2.0 and the parameter components of
+// OpenAPI 3.0, which the operations reference. Body and form params can not be reused, OpenAPI 3.0 moves
+// them into the request body
+func (parser *Parser) ParseParameterDefinition(commentLine string) (err error) {
+	defer recoverFatal(&err)
+	return parser.parseParameterDefinition(commentLine)
+}
+
+func (parser *Parser) parseParameterDefinition(commentLine string) error {
+	op := NewOperation(parser, parser.CurrentPackage)
+	if err := op.parseParamComment("@Param " + strings.TrimSpace(commentLine[len("@Parameter"):])); err != nil {
+		return fmt.Errorf("Can not parse parameter definition \"%s\", %v", commentLine, err)
+	}
+	param := op.Parameters[0]
+	if param.ParamType != "path" && param.ParamType != "query" && param.ParamType != "header" {
+		return fmt.Errorf("Can not parse parameter definition \"%s\", only path, query and header params can be reused.", commentLine)
+	}
+	if _, ok := parser.ReusableParams[param.Name]; ok {
+		return fmt.Errorf("Can not parse parameter definition \"%s\", %s is already declared.", commentLine, param.Name)
+	}
+	parser.ReusableParams[param.Name] = param
+	return nil
+}
+
+// GlobalResponse is a response declared once with @GlobalResponse, which every operation without a response of its
+// code has. The Swagger 2.0 and OpenAPI 3.0 operations reference it in the responses of the spec
+type GlobalResponse struct {
+	Name    string // in the responses of the spec, the status text of the code, e.g. "TooManyRequests" for 429
+	Code    int
+	Comment string // the response as written after @Failure, its model qualified with the import path of its package
+}
+
+var globalResponsePattern = regexp.MustCompile(`^(\d{3})(?:\s+(\{\w+\})\s+(\S+))?(\s.*)?$`)
+
+var nonAlphanumericPattern = regexp.MustCompile(`[^A-Za-z0-9]`)
+
+// @GlobalResponse 401 {object} models.Error "unauthorized"
+// @GlobalResponse 429 "Too many requests"
+//
+// Declares a response of every operation, in the format of @Failure. A model is looked up once for all of them, so
+// it must be of a package the main API file imports under its name, or be given by its absolute name
+func (parser *Parser) ParseGlobalResponseDefinition(commentLine string) error {
+	comment := strings.TrimSpace(commentLine[len("@GlobalResponse"):])
+	matches := globalResponsePattern.FindStringSubmatch(comment)
+	if matches == nil {
+		return fmt.Errorf("Can not parse global response comment \"%s\", expected @GlobalResponse code [{type} model] [\"description\"].", commentLine)
+	}
+	code, _ := strconv.Atoi(matches[1])
+	for _, response := range parser.GlobalResponses {
+		if response.Code == code {
+			return fmt.Errorf("Can not parse global response comment \"%s\", response %d is already declared.", commentLine, code)
+		}
+	}
+	if matches[3] != "" {
+		modelNames := strings.Split(matches[3], ",")
+		for i, modelName := range modelNames {
+			prefix := ""
+			if strings.HasPrefix(modelName, "[]") {
+				prefix, modelName = "[]", modelName[len("[]"):]
+			}
+			if IsBasicType(modelName) {
+				continue
+			}
+			dot := strings.LastIndex(modelName, ".")
+			if dot != -1 && !strings.Contains(modelName[:dot], "/") {
+				if importPath, ok := parser.mainFileImports[modelName[:dot]]; ok {
+					modelName = importPath + modelName[dot:]
+					dot = len(importPath)
+				}
+			}
+			if dot == -1 || !strings.Contains(modelName[:dot], "/") {
+				return fmt.Errorf("Can not parse global response comment \"%s\", model %s must be of a package the main API file imports, or be absolute.", commentLine, modelName)
+			}
+			modelNames[i] = prefix + modelName
+		}
+		comment = matches[1] + " " + matches[2] + " " + strings.Join(modelNames, ",") + matches[4]
+	}
+	name := nonAlphanumericPattern.ReplaceAllString(http.StatusText(code), "")
+	if name == "" {
+		name = "Response" + matches[1]
+	}
+	parser.GlobalResponses = append(parser.GlobalResponses, GlobalResponse{Name: name, Code: code, Comment: comment})
+	return nil
+}
+
+// globalResponse parses a GlobalResponse into the single response of an operation, along with its models, the first
+// time it is added to an operation. The response of the spec is built from it, see addGlobalResponses. It is
+// nil if the response can not be parsed
+func (parser *Parser) globalResponse(globalResponse GlobalResponse) *Operation {
+	if global, ok := parser.globalResponseOperations[globalResponse.Name]; ok {
+		return global
+	}
+	global := NewOperation(parser, "")
+	if err := global.parseResponseComment(globalResponse.Comment); err != nil {
+		parser.warnf("Can not parse global response %d: %v\n", globalResponse.Code, err)
+		global = nil
+	}
+	parser.globalResponseOperations[globalResponse.Name] = global
+	return global
+}
+
+// Parse the transfer protocols of the API
+// @Schemes https,http
+func (parser *Parser) ParseSchemesComment(commentLine string) error {
+	schemes := make([]string, 0)
+	for _, scheme := range strings.Split(commentLine[len("@Schemes"):], ",") {
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+		switch scheme {
+		case "":
+			continue
+		case "http", "https", "ws", "wss":
+			schemes = append(schemes, scheme)
+		default:
+			return fmt.Errorf("Can not parse schemes comment \"%s\", scheme must be one of http, https, ws or wss.", commentLine)
+		}
+	}
+	if len(schemes) == 0 {
+		return fmt.Errorf("Can not parse schemes comment \"%s\", no scheme given.", commentLine)
+	}
+	parser.Schemes = schemes
+	return nil
+}
+
 func (parser *Parser) GetResourceListingJson() []byte {
+	parser.SortApiDescriptions()
 	json, err := json.MarshalIndent(parser.Listing, "", "    ")
 	if err != nil {
-		log.Fatalf("Can not serialise ResourceListing to JSON: %v\n", err)
+		parser.failJson("Can not serialise ResourceListing to JSON: %v\n", err)
 	}
 	return json
 }
 
 func (parser *Parser) GetApiDescriptionJson() []byte {
+	parser.SortApiDescriptions()
 	json, err := json.MarshalIndent(parser.TopLevelApis, "", "    ")
 	if err != nil {
-		log.Fatalf("Can not serialise []ApiDescription to JSON: %v\n", err)
+		parser.failJson("Can not serialise []ApiDescription to JSON: %v\n", err)
+	}
+	return json
+}
+
+// GetMergedApiJson serializes the operations of every resource as a single api declaration, see MergedApiDeclaration
+func (parser *Parser) GetMergedApiJson() []byte {
+	json, err := json.MarshalIndent(parser.MergedApiDeclaration(), "", "    ")
+	if err != nil {
+		parser.failJson("Can not serialise merged ApiDeclaration to JSON: %v\n", err)
 	}
 	return json
 }
 
+// MergedApiDeclaration merges the resources into a single api declaration of resource path "/", e.g. for a small
+// service described by one file. It has the apis, models and content types of every resource, the apis sorted by
+// path and their operations by http method. The resources are left as they are
+func (parser *Parser) MergedApiDeclaration() *ApiDeclaration {
+	merged := NewApiDeclaration()
+	merged.ApiVersion = parser.Listing.ApiVersion
+	merged.SwaggerVersion = parser.SpecVersion(FormatSwagger12)
+	merged.ResourcePath = "/"
+	merged.BasePath = parser.BasePath
+	merged.Schemes = parser.Schemes
+	for _, api := range parser.TopLevelApis {
+		for id, model := range api.Models {
+			merged.Models[id] = model
+		}
+	}
+	for _, op := range parser.Operations() {
+		merged.AddOperation(op)
+	}
+	return merged
+}
+
+// SortApiDescriptions sorts the resources of the listing and the apis of each resource by path, and the operations
+// of each api by http method, so the output is the same on every run. Models and their properties are maps,
+// which are serialized sorted by name anyway
+func (parser *Parser) SortApiDescriptions() {
+	parser.listingMutex.Lock()
+	defer parser.listingMutex.Unlock()
+	sort.SliceStable(parser.Listing.Apis, func(i, j int) bool {
+		return parser.Listing.Apis[i].Path < parser.Listing.Apis[j].Path
+	})
+	for _, api := range parser.TopLevelApis {
+		sort.SliceStable(api.Apis, func(i, j int) bool {
+			return api.Apis[i].Path < api.Apis[j].Path
+		})
+		for _, subApi := range api.Apis {
+			operations := subApi.Operations
+			sort.SliceStable(operations, func(i, j int) bool {
+				return operations[i].HttpMethod < operations[j].HttpMethod
+			})
+		}
+	}
+}
+
+// Operations returns the parsed operations of every resource, sorted by path and http method, e.g. to look them
+// over or modify them before the API is serialized. See WalkOperations to modify them along with their resources
+func (parser *Parser) Operations() []*Operation {
+	parser.listingMutex.Lock()
+	operations := make([]*Operation, 0)
+	for _, api := range parser.TopLevelApis {
+		for _, subApi := range api.Apis {
+			operations = append(operations, subApi.Operations...)
+		}
+	}
+	parser.listingMutex.Unlock()
+	sort.SliceStable(operations, func(i, j int) bool {
+		if operations[i].Path != operations[j].Path {
+			return operations[i].Path < operations[j].Path
+		}
+		return operations[i].HttpMethod < operations[j].HttpMethod
+	})
+	return operations
+}
+
+// WalkOperations calls visit with each parsed operation, sorted by path and http method, and stops at the first
+// error it returns. The models and content types the visit adds to an operation are added to its resource, e.g. of
+// a response added with op.ParseComment("@Failure 500 {object} APIError \"Internal error\""), whose model is looked
+// up in the package of the operation. The path and http method of the operations must be left as they are
+func (parser *Parser) WalkOperations(visit func(op *Operation) error) error {
+	currentPackage := parser.CurrentPackage
+	defer func() {
+		parser.CurrentPackage = currentPackage
+	}()
+
+	resources := make(map[*Operation]*ApiDeclaration)
+	parser.listingMutex.Lock()
+	for _, api := range parser.TopLevelApis {
+		for _, subApi := range api.Apis {
+			for _, op := range subApi.Operations {
+				resources[op] = api
+			}
+		}
+	}
+	parser.listingMutex.Unlock()
+	// the visit is not guarded, it may add operations itself
+	for _, op := range parser.Operations() {
+		if op.packageName != "" {
+			parser.CurrentPackage = op.packageName
+		}
+		if err := visit(op); err != nil {
+			return err
+		}
+		parser.listingMutex.Lock()
+		api := resources[op]
+		api.AddProducesTypes(op)
+		api.AddConsumedTypes(op)
+		api.AddModels(op)
+		parser.listingMutex.Unlock()
+	}
+	return nil
+}
+
+// ResourceListingFileName is the name of the file WriteApiDescriptions writes the resource listing to
+const ResourceListingFileName = "api-docs.json"
+
+// WriteApiDescriptions writes the resource listing, and the api declaration of each resource to a file named after
+// the resource, e.g. "customer.json", to dir. Each file is encoded as it is written, not built in memory first
+func (parser *Parser) WriteApiDescriptions(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("Can not create API description directory %s: %v", dir, err)
+	}
+	parser.SortApiDescriptions()
+	if err := writeJsonFile(filepath.Join(dir, ResourceListingFileName), parser.Listing); err != nil {
+		return err
+	}
+
+	resources := make([]string, 0, len(parser.TopLevelApis))
+	for resource := range parser.TopLevelApis {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	for _, resource := range resources {
+		// a resource set with @Resource may contain slashes, it is then written to a subdirectory
+		fileName := filepath.Join(dir, filepath.FromSlash(resource)+".json")
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			return fmt.Errorf("Can not create API description directory %s: %v", filepath.Dir(fileName), err)
+		}
+		if err := writeJsonFile(fileName, parser.TopLevelApis[resource]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJsonFile(fileName string, value interface{}) (err error) {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("Can not create API description file %s: %v", fileName, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("Can not write API description file %s: %v", fileName, closeErr)
+		}
+	}()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("Can not write API description file %s: %v", fileName, err)
+	}
+	return nil
+}
+
+// failJson terminates the process, or in LibraryMode collects the failure into Warnings, the Json getters then return nil
+func (parser *Parser) failJson(format string, err error) {
+	if !parser.LibraryMode {
+		log.Fatalf(format, err)
+	}
+	parser.warnf(format, err)
+}
+
 func (parser *Parser) CheckRealPackagePath(packagePath string) string {
+	defer parser.collectFatal()
+	return parser.checkRealPackagePath(packagePath)
+}
+
+func (parser *Parser) checkRealPackagePath(packagePath string) string {
 	packagePath = strings.Trim(packagePath, "\"")
 
 	if cachedResult, ok := parser.PackagePathCache[packagePath]; ok {
 		return cachedResult
 	}
 
+	if IsLocalPackagePath(packagePath) {
+		pkgRealpath := ""
+		if evalutedPath, err := filepath.EvalSymlinks(packagePath); err == nil {
+			if absolutePath, err := filepath.Abs(evalutedPath); err == nil {
+				pkgRealpath = absolutePath
+			}
+		}
+		parser.PackagePathCache[packagePath] = pkgRealpath
+		return pkgRealpath
+	}
+	if pkgRealpath := parser.modulePackageDir(packagePath); pkgRealpath != "" {
+		parser.debugf("Package %s resolved to %s\n", packagePath, pkgRealpath)
+		parser.PackagePathCache[packagePath] = pkgRealpath
+		return pkgRealpath
+	}
+
+	// the standard library is looked up first, so a directory of the same path below $GOPATH/src does not hide it
+	if IsStandardPackage(packagePath) {
+		if pkgRealpath := parser.gorootPackageDir(packagePath); pkgRealpath != "" {
+			parser.debugf("Package %s resolved to %s\n", packagePath, pkgRealpath)
+			parser.PackagePathCache[packagePath] = pkgRealpath
+			return pkgRealpath
+		}
+	}
+
 	gopath := os.Getenv("GOPATH")
 	if gopath == "" {
-		log.Fatalf("Please, set $GOPATH environment variable\n")
+		parser.fatalf("Please, set $GOPATH environment variable\n")
 	}
 
 	pkgRealpath := ""
@@ -120,113 +1117,507 @@ func (parser *Parser) CheckRealPackagePath(packagePath string) string {
 			}
 		}
 	}
+	if pkgRealpath == "" && !IsStandardPackage(packagePath) {
+		// the packages the standard library vendors, e.g. golang.org/x/net/http/httpguts
+		pkgRealpath = parser.gorootPackageDir(packagePath)
+	}
 	if pkgRealpath == "" {
-		goroot := filepath.Clean(runtime.GOROOT())
-		if goroot == "" {
-			log.Fatalf("Please, set $GOROOT environment variable\n")
+		parser.debugf("Package %s not found in $GOPATH %s nor in $GOROOT\n", packagePath, gopath)
+	} else {
+		parser.debugf("Package %s resolved to %s\n", packagePath, pkgRealpath)
+	}
+	parser.PackagePathCache[packagePath] = pkgRealpath
+	return pkgRealpath
+}
+
+// IsStandardPackage reports whether an import path is the one of a package of the standard library, like the go
+// tool does: the first element of the path has no dot, e.g. "net/url" but not "github.com/myuser/myproject"
+func IsStandardPackage(packagePath string) bool {
+	return packagePath != "" && !IsLocalPackagePath(packagePath) && !strings.Contains(strings.Split(packagePath, "/")[0], ".")
+}
+
+// gorootDirs returns the directories the standard library may be in, with their symbolic links evaluated: the
+// GOROOT of the BuildContext, each entry of $GOROOT, then the GOROOT the parser is built with
+func (parser *Parser) gorootDirs() []string {
+	candidates := make([]string, 0)
+	if parser.BuildContext != nil {
+		candidates = append(candidates, parser.BuildContext.GOROOT)
+	}
+	candidates = append(candidates, filepath.SplitList(os.Getenv("GOROOT"))...)
+	candidates = append(candidates, build.Default.GOROOT, runtime.GOROOT())
+
+	dirs := make([]string, 0, len(candidates))
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
 		}
-		if evalutedPath, err := filepath.EvalSymlinks(filepath.Join(goroot, "src", packagePath)); err == nil {
-			if _, err := os.Stat(evalutedPath); err == nil {
-				pkgRealpath = evalutedPath
+		dir, err := filepath.EvalSymlinks(candidate)
+		if err != nil || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// gorootPackageDir returns the directory of a package of the standard library, or of a package it vendors, or ""
+func (parser *Parser) gorootPackageDir(packagePath string) string {
+	for _, goroot := range parser.gorootDirs() {
+		for _, dir := range []string{filepath.Join(goroot, "src", packagePath), filepath.Join(goroot, "src", "vendor", packagePath)} {
+			if evalutedPath, err := filepath.EvalSymlinks(dir); err == nil {
+				if info, err := os.Stat(evalutedPath); err == nil && info.IsDir() {
+					return evalutedPath
+				}
 			}
 		}
 	}
-	parser.PackagePathCache[packagePath] = pkgRealpath
-	return pkgRealpath
+	return ""
+}
+
+// IsLocalPackagePath reports whether a package is given by its directory, e.g. "./internal/api", "../api" or an
+// absolute path, rather than by its import path
+func IsLocalPackagePath(packagePath string) bool {
+	return packagePath == "." || packagePath == ".." || strings.HasPrefix(packagePath, "./") ||
+		strings.HasPrefix(packagePath, "../") || filepath.IsAbs(packagePath)
+}
+
+// localImportPath returns the import path of a package given by its directory: its path below $GOPATH/src, or
+// else the path of the module it is in, followed by its path in the module. The packages of the module are then
+// found in its directory, also when they are imported by the others
+func (parser *Parser) localImportPath(packagePath string) string {
+	dir := parser.getRealPackagePath(packagePath)
+	importPath := parser.dirImportPath(dir)
+	if importPath == "" {
+		parser.fatalf("Can not find the import path of package %s, %s is neither below $GOPATH/src nor in a module\n", packagePath, dir)
+	}
+	return importPath
+}
+
+// dirImportPath returns the import path of the package in a directory, see localImportPath, or "" if the
+// directory is neither below $GOPATH/src nor in a module
+func (parser *Parser) dirImportPath(dir string) string {
+	if importPath := gopathPackage(dir); importPath != "" {
+		return importPath
+	}
+	for moduleDir := dir; ; moduleDir = filepath.Dir(moduleDir) {
+		if modulePath := readModulePath(filepath.Join(moduleDir, "go.mod")); modulePath != "" {
+			if parser.moduleDirs == nil {
+				parser.moduleDirs = make(map[string]string)
+			}
+			parser.moduleDirs[modulePath] = moduleDir
+			relativePath, _ := filepath.Rel(moduleDir, dir)
+			if relativePath == "." {
+				return modulePath
+			}
+			return modulePath + "/" + filepath.ToSlash(relativePath)
+		}
+		if filepath.Dir(moduleDir) == moduleDir {
+			break
+		}
+	}
+	return ""
+}
+
+// modulePackageDir returns the directory of a package of a module found by localImportPath, or "". A package of
+// nested modules is in the innermost one
+func (parser *Parser) modulePackageDir(packagePath string) string {
+	pkgModulePath := ""
+	for modulePath := range parser.moduleDirs {
+		if (packagePath == modulePath || strings.HasPrefix(packagePath, modulePath+"/")) && len(modulePath) > len(pkgModulePath) {
+			pkgModulePath = modulePath
+		}
+	}
+	if pkgModulePath == "" {
+		return ""
+	}
+	dir := filepath.Join(parser.moduleDirs[pkgModulePath], filepath.FromSlash(strings.TrimPrefix(packagePath, pkgModulePath)))
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir
+	}
+	return ""
+}
+
+// readModulePath returns the module path declared by a go.mod file, or "" if there is no such file
+func readModulePath(goModFileName string) string {
+	content, err := ioutil.ReadFile(goModFileName)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(strings.Split(line, "//")[0])
+		if len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], "\"`")
+		}
+	}
+	return ""
 }
 
 func (parser *Parser) GetRealPackagePath(packagePath string) string {
-	pkgRealpath := parser.CheckRealPackagePath(packagePath)
+	defer parser.collectFatal()
+	return parser.getRealPackagePath(packagePath)
+}
+
+func (parser *Parser) getRealPackagePath(packagePath string) string {
+	pkgRealpath := parser.checkRealPackagePath(packagePath)
 	if pkgRealpath == "" {
-		log.Fatalf("Can not find package %s \n", packagePath)
+		parser.fatalf("Can not find package %s \n", packagePath)
 	}
 
 	return pkgRealpath
 }
 
 func (parser *Parser) GetPackageAst(packagePath string) map[string]*ast.Package {
-	//log.Printf("Parse %s package\n", packagePath)
+	defer parser.collectFatal()
+	return parser.getPackageAst(packagePath)
+}
+
+func (parser *Parser) getPackageAst(packagePath string) map[string]*ast.Package {
 	if cache, ok := parser.PackagesCache[packagePath]; ok {
 		return cache
 	} else {
-		fileSet := token.NewFileSet()
+		parser.debugf("Parse %s package\n", packagePath)
 
-		astPackages, err := goparser.ParseDir(fileSet, packagePath, ParserFileFilter, goparser.ParseComments)
+		var astPackages map[string]*ast.Package
+		var err error
+		if parser.declarationCacheDir != "" {
+			astPackages, err = parser.parseDirCached(parser.fileSet, packagePath, parser.fileFilter(packagePath))
+		} else {
+			astPackages, err = goparser.ParseDir(parser.fileSet, packagePath, parser.fileFilter(packagePath), goparser.ParseComments)
+		}
 		if err != nil {
-			log.Fatalf("Parse of %s pkg cause error: %s\n", packagePath, err)
+			parser.fatalf("Parse of %s pkg cause error: %s\n", packagePath, err)
+		}
+		parser.PackagesCache[packagePath] = astPackages
+		return astPackages
+	}
+}
+
+// AddOperation adds the operation to the api declaration of its resource, and its aliases to theirs. It may be
+// called from several goroutines, each resource is listed once whichever operation adds it first. The paths
+// are rewritten by the PathNormalizer first, then prefixed with the RoutePrefix once their resource is derived
+// from them, e.g. the operation annotated with @Router /orders/{id} is listed at /api/v2/orders/{id} but still
+// in the "orders" resource
+func (parser *Parser) AddOperation(op *Operation) {
+	parser.listingMutex.Lock()
+	defer parser.listingMutex.Unlock()
+	parser.addGlobalResponses(op)
+	for _, alias := range op.Aliases() {
+		parser.addOperation(alias)
+	}
+}
+
+func (parser *Parser) addOperation(op *Operation) {
+	// an operation added again, e.g. by ReparsePackage, is mounted under the RoutePrefix once
+	op.Path = parser.normalizePath(strings.TrimPrefix(op.Path, op.routePrefix))
+	op.routePrefix = ""
+	path := []string{}
+	for _, pathPart := range strings.Split(op.Path, "/") {
+		if pathPart = strings.TrimSpace(pathPart); pathPart != "" {
+			path = append(path, pathPart)
+		}
+	}
+
+	resource := op.ForceResource
+	if resource == "" {
+		if len(path) == 0 {
+			if location := op.location(); location != "" {
+				parser.warnf("%s: Operation of handler %s skipped, its path \"%s\" is empty\n", location, op.handlerName(), op.Path)
+			} else {
+				parser.warnf("Operation of handler %s skipped, its path \"%s\" is empty\n", op.handlerName(), op.Path)
+			}
+			return
+		}
+		resource = path[0]
+	}
+
+	api, ok := parser.TopLevelApis[resource]
+	if !ok {
+		api = NewApiDeclaration()
+
+		api.ApiVersion = parser.Listing.ApiVersion
+		api.SwaggerVersion = parser.SpecVersion(FormatSwagger12)
+		api.ResourcePath = "/" + resource
+		api.BasePath = parser.BasePath
+		api.Schemes = parser.Schemes
+
+		parser.TopLevelApis[resource] = api
+		parser.listResource(api.ResourcePath)
+	}
+	// the description of @SubApi is kept over the one of @Resource, and the first @Resource one over the others
+	if _, ok := parser.resourceDescriptions[api.ResourcePath]; !ok && op.ResourceDescription != "" {
+		parser.setResourceDescription(api.ResourcePath, op.ResourceDescription)
+	}
+
+	// the resource is derived from the path as annotated, the operation is listed at its mount point
+	if prefix := strings.Trim(parser.RoutePrefix, "/"); prefix != "" {
+		op.routePrefix = "/" + prefix
+		op.Path = op.routePrefix + "/" + strings.TrimLeft(op.Path, "/")
+	}
+
+	// Operations without @Tags are grouped by their resource, as in the resource listing
+	if len(op.Tags) == 0 {
+		op.Tags = []string{resource}
+	}
+	op.NormalizeTags()
+	parser.DeclareTags(op.Tags)
+	op.SetDefaultContentTypes()
+	api.AddOperation(op)
+}
+
+// addGlobalResponses adds the GlobalResponses to an operation, except the ones of the codes it has a response of
+func (parser *Parser) addGlobalResponses(op *Operation) {
+	codes := make(map[int]bool)
+	for _, response := range op.ResponseMessages {
+		codes[response.Code] = true
+	}
+	added := false
+	for _, globalResponse := range parser.GlobalResponses {
+		if codes[globalResponse.Code] {
+			continue
+		}
+		global := parser.globalResponse(globalResponse)
+		if global == nil {
+			continue
+		}
+		response := global.ResponseMessages[0]
+		response.GlobalRef = globalResponse.Name
+		op.ResponseMessages = append(op.ResponseMessages, response)
+		op.Models = append(op.Models, global.Models...)
+		added = true
+	}
+	if added {
+		op.Models = op.getUniqueModels()
+	}
+}
+
+// normalizePath rewrites the path of an operation with the PathNormalizer, if there is one
+func (parser *Parser) normalizePath(path string) string {
+	if parser.PathNormalizer == nil {
+		return path
+	}
+	return parser.PathNormalizer(path)
+}
+
+// ColonPathParams is a PathNormalizer for routers whose path params are written :param, e.g. /users/:id,
+// which it writes {param} like Swagger does, e.g. /users/{id}
+func ColonPathParams(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if len(segment) > 1 && segment[0] == ':' {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// listResource adds the resource to the resource listing, unless it is listed already, with the description given
+// to it so far
+func (parser *Parser) listResource(path string) {
+	for _, apiRef := range parser.Listing.Apis {
+		if apiRef.Path == path {
+			return
+		}
+	}
+	parser.Listing.Apis = append(parser.Listing.Apis, &ApiRef{Path: path, Description: parser.resourceDescriptions[path]})
+}
+
+// setResourceDescription describes the resource in the resource listing, including once it is listed
+func (parser *Parser) setResourceDescription(path string, description string) {
+	parser.resourceDescriptions[path] = description
+	for _, apiRef := range parser.Listing.Apis {
+		if apiRef.Path == path {
+			apiRef.Description = description
+		}
+	}
+}
+
+// DeclareTags adds the tags which are not declared yet to the resource listing, keeping them sorted by name
+func (parser *Parser) DeclareTags(tags []string) {
+	for _, tag := range tags {
+		isExists := false
+		for _, existTag := range parser.Listing.Tags {
+			if existTag.Name == tag {
+				isExists = true
+				break
+			}
+		}
+		if !isExists {
+			parser.Listing.Tags = append(parser.Listing.Tags, Tag{Name: tag})
 		}
-		parser.PackagesCache[packagePath] = astPackages
-		return astPackages
 	}
+	sort.Slice(parser.Listing.Tags, func(i, j int) bool {
+		return parser.Listing.Tags[i].Name < parser.Listing.Tags[j].Name
+	})
 }
 
-func (parser *Parser) AddOperation(op *Operation) {
-	path := []string{}
-	for _, pathPart := range strings.Split(op.Path, "/") {
-		if pathPart = strings.TrimSpace(pathPart); pathPart != "" {
-			path = append(path, pathPart)
-		}
+// ValidateApi parses the packages like ParseApi, as a dry run which collects the problems of their annotations
+// rather than stopping at the first one: the annotations which can not be parsed, including the models they
+// reference which can not be found, with the file and line of each, and the problems reported by Validate.
+// The failures are not logged, and the parser is left with what could be parsed
+func (parser *Parser) ValidateApi(packageNames string) []error {
+	libraryMode := parser.LibraryMode
+	parser.LibraryMode = true
+	parser.dryRun = true
+	defer func() {
+		parser.LibraryMode = libraryMode
+		parser.dryRun = false
+	}()
+
+	warnings := len(parser.Warnings)
+	err := parser.ParseApiContext(context.Background(), packageNames)
+	errs := append([]error(nil), parser.Warnings[warnings:]...)
+	if err != nil {
+		errs = append(errs, err)
 	}
+	return append(errs, parser.Validate()...)
+}
 
-	resource := path[0]
-	if op.ForceResource != "" {
-		resource = op.ForceResource
+// ParseApi parses the type definitions and the operations of the packages. In LibraryMode
+// a failure is collected into Warnings, use ParseApiContext to get it
+func (parser *Parser) ParseApi(packageNames string) {
+	if err := parser.ParseApiContext(context.Background(), packageNames); err != nil {
+		parser.warnf("Can not parse API: %v\n", err)
 	}
+}
 
-	api, ok := parser.TopLevelApis[resource]
-	if !ok {
-		api = NewApiDeclaration()
+// ParseApiContext is ParseApi, which stops between packages and files once ctx is done, and returns ctx.Err() then
+func (parser *Parser) ParseApiContext(ctx context.Context, packageNames string) (err error) {
+	defer recoverFatal(&err)
+	parser.ctx = ctx
+	defer func() {
+		parser.ctx = nil
+	}()
 
-		api.ApiVersion = parser.Listing.ApiVersion
-		api.SwaggerVersion = SwaggerVersion
-		api.ResourcePath = "/" + resource
-		api.BasePath = parser.BasePath
+	packages := parser.scanPackages(strings.Split(packageNames, ","))
+	for _, packageName := range packages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		parser.parseTypeDefinitions(packageName)
+	}
+	for _, packageName := range packages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		parser.parseApiDescription(packageName)
+	}
+	return ctx.Err()
+}
 
-		parser.TopLevelApis[resource] = api
+// isCancelled reports whether the context of ParseApiContext is done
+func (parser *Parser) isCancelled() bool {
+	return parser.ctx != nil && parser.ctx.Err() != nil
+}
 
-		apiRef := &ApiRef{
-			Path:        api.ResourcePath,
-			Description: op.Summary,
+func (parser *Parser) IsIgnoredDir(dirName string) bool {
+	for _, ignoredDir := range parser.IgnoreDirs {
+		if dirName == ignoredDir {
+			return true
 		}
-		parser.Listing.Apis = append(parser.Listing.Apis, apiRef)
 	}
+	return false
+}
 
-	api.AddOperation(op)
+// packagePattern selects a package given to ScanPackages, and with a trailing "/..." the packages below it too
+type packagePattern struct {
+	path      string
+	recursive bool
 }
 
-func (parser *Parser) ParseApi(packageNames string) {
-	packages := parser.ScanPackages(strings.Split(packageNames, ","))
-	for _, packageName := range packages {
-		parser.ParseTypeDefinitions(packageName)
-	}
-	for _, packageName := range packages {
-		parser.ParseApiDescription(packageName)
+func (pattern packagePattern) matches(packageName string) bool {
+	return packageName == pattern.path || pattern.recursive && strings.HasPrefix(packageName, pattern.path+"/")
+}
+
+// parsePackagePattern resolves the package of a pattern, which may be a local directory like ./internal/...
+func (parser *Parser) parsePackagePattern(pattern string) packagePattern {
+	packageName := strings.TrimSuffix(pattern, "/...")
+	if IsLocalPackagePath(packageName) {
+		packageName = parser.localImportPath(packageName)
 	}
+	return packagePattern{path: packageName, recursive: packageName != pattern}
 }
 
+// ScanPackages returns the packages to parse: the given ones and the packages below them. Like the go tool,
+// a trailing "/..." also selects the packages below, which are always scanned. A package prefixed with "-" is
+// excluded, e.g. -github.com/org/proj/internal/..., with the packages below it when it ends with "/...",
+// whichever package it is below
 func (parser *Parser) ScanPackages(packages []string) []string {
-	res := make([]string, len(packages))
+	defer parser.collectFatal()
+	return parser.scanPackages(packages)
+}
+
+func (parser *Parser) scanPackages(packages []string) []string {
+	res := make([]string, 0, len(packages))
 	existsPackages := make(map[string]bool)
 
+	roots := make([]string, 0, len(packages))
+	excludes := make([]packagePattern, 0)
 	for _, packageName := range packages {
+		if strings.HasPrefix(packageName, "-") {
+			excludes = append(excludes, parser.parsePackagePattern(packageName[1:]))
+		} else {
+			roots = append(roots, parser.parsePackagePattern(packageName).path)
+		}
+	}
+	// isExcluded reports whether a package is excluded, and whether the packages below it are as well
+	isExcluded := func(packageName string) (bool, bool) {
+		excluded := false
+		for _, exclude := range excludes {
+			if exclude.matches(packageName) {
+				if exclude.recursive {
+					return true, true
+				}
+				excluded = true
+			}
+		}
+		return excluded, false
+	}
+
+	for _, packageName := range roots {
+		excluded, subtreeExcluded := isExcluded(packageName)
+		if subtreeExcluded {
+			continue
+		}
 		if v, ok := existsPackages[packageName]; !ok || v == false {
 			// Add package
 			existsPackages[packageName] = true
-			res = append(res, packageName)
+			if !excluded {
+				res = append(res, packageName)
+			}
 			// get it's real path
-			pkgRealPath := parser.GetRealPackagePath(packageName)
+			pkgRealPath := parser.getRealPackagePath(packageName)
 			// Then walk
 			var walker filepath.WalkFunc = func(path string, info os.FileInfo, err error) error {
+				if parser.isCancelled() {
+					return parser.ctx.Err()
+				}
+				if err != nil {
+					return nil
+				}
 				if info.IsDir() {
 
-					// Ignore anything under a ./Godeps directory
-					if idx := strings.Index(path, pkgRealPath+"/Godeps/"); idx != -1 {
+					// Ignore anything under a ./Godeps, ./vendor or other ignored directory
+					if path != pkgRealPath && parser.IsIgnoredDir(info.Name()) {
+						return filepath.SkipDir
+					}
+					// The import path of a nested package is the import path of the scanned one, followed by its relative path
+					relPath, err := filepath.Rel(pkgRealPath, path)
+					if err != nil || relPath == "." {
 						return nil
 					}
-					if idx := strings.Index(path, packageName); idx != -1 {
-						pack := path[idx:]
-						if v, ok := existsPackages[pack]; !ok || v == false {
-							existsPackages[pack] = true
+					if parser.MaxScanDepth > 0 && len(strings.Split(relPath, string(filepath.Separator))) > parser.MaxScanDepth {
+						return filepath.SkipDir
+					}
+					pack := packageName + "/" + filepath.ToSlash(relPath)
+					excluded, subtreeExcluded := isExcluded(pack)
+					if subtreeExcluded {
+						return filepath.SkipDir
+					}
+					if v, ok := existsPackages[pack]; !ok || v == false {
+						existsPackages[pack] = true
+						if !excluded {
 							res = append(res, pack)
 						}
 					}
@@ -239,61 +1630,369 @@ func (parser *Parser) ScanPackages(packages []string) []string {
 	return res
 }
 
-func (parser *Parser) ParseTypeDefinitions(packageName string) {
+func (parser *Parser) ParseTypeDefinitions(packageName string) (err error) {
+	defer recoverFatal(&err)
+	parser.parseTypeDefinitions(packageName)
+	return nil
+}
+
+func (parser *Parser) parseTypeDefinitions(packageName string) {
 	parser.CurrentPackage = packageName
-	pkgRealPath := parser.GetRealPackagePath(packageName)
-	//	log.Printf("Parse type definition of %#v\n", packageName)
+	pkgRealPath := parser.getRealPackagePath(packageName)
+
+	// Each package is parsed once, re-entering one whose imports are being parsed would never end on cyclic imports
+	if parser.typeDefinitionsParsed[pkgRealPath] || parser.typeDefinitionsInProgress[pkgRealPath] {
+		return
+	}
+	parser.typeDefinitionsInProgress[pkgRealPath] = true
+	defer delete(parser.typeDefinitionsInProgress, pkgRealPath)
+	parser.debugf("Parse type definitions of %s\n", packageName)
 
 	if _, ok := parser.TypeDefinitions[pkgRealPath]; !ok {
 		parser.TypeDefinitions[pkgRealPath] = make(map[string]*ast.TypeSpec)
 	}
+	if _, ok := parser.EnumValues[pkgRealPath]; !ok {
+		parser.EnumValues[pkgRealPath] = make(map[string][]string)
+		parser.EnumVarNames[pkgRealPath] = make(map[string][]string)
+	}
 
-	astPackages := parser.GetPackageAst(pkgRealPath)
+	astPackages := parser.getPackageAst(pkgRealPath)
 	for _, astPackage := range astPackages {
-		for _, astFile := range astPackage.Files {
+		for _, astFile := range sortedFiles(astPackage) {
+			if parser.isCancelled() {
+				return
+			}
 			for _, astDeclaration := range astFile.Decls {
 				if generalDeclaration, ok := astDeclaration.(*ast.GenDecl); ok && generalDeclaration.Tok == token.TYPE {
 					for _, astSpec := range generalDeclaration.Specs {
 						if typeSpec, ok := astSpec.(*ast.TypeSpec); ok {
+							// The doc comment of a non-grouped "type X ..." declaration belongs to the GenDecl
+							if typeSpec.Doc == nil && len(generalDeclaration.Specs) == 1 {
+								typeSpec.Doc = generalDeclaration.Doc
+							}
 							parser.TypeDefinitions[pkgRealPath][typeSpec.Name.String()] = typeSpec
+							if err := parser.ParseModelSchema(pkgRealPath, typeSpec); err != nil {
+								parser.warnf("%v\n", err)
+							}
 						}
 					}
 				}
+				if generalDeclaration, ok := astDeclaration.(*ast.GenDecl); ok && generalDeclaration.Tok == token.CONST {
+					parser.ParseEnumValues(pkgRealPath, generalDeclaration)
+				}
+				if funcDeclaration, ok := astDeclaration.(*ast.FuncDecl); ok && IsMarshalJSONMethod(funcDeclaration) {
+					typeName := ReceiverTypeName(funcDeclaration)
+					parser.marshalerTypes[pkgRealPath+"."+typeName] = true
+				}
+			}
+		}
+	}
+
+	for importedPackage, _ := range parser.parseImportStatements(packageName) {
+		if parser.isCancelled() {
+			return
+		}
+		parser.parseTypeDefinitions(importedPackage)
+	}
+	if !parser.isCancelled() {
+		parser.typeDefinitionsParsed[pkgRealPath] = true
+		parser.debugf("Type definitions of %s parsed, %d types\n", packageName, len(parser.TypeDefinitions[pkgRealPath]))
+	}
+}
+
+// ParseModelSchema records the @Schema annotation of a type, which replaces the schema introspected from its fields.
+// A malformed annotation is returned as an error, naming the type and its position, and the type keeps its fields
+func (parser *Parser) ParseModelSchema(pkgRealPath string, typeSpec *ast.TypeSpec) error {
+	if typeSpec.Doc == nil {
+		return nil
+	}
+	for _, comment := range typeSpec.Doc.List {
+		commentLine, attribute := parser.parseAnnotation(strings.TrimSpace(strings.TrimLeft(comment.Text, "//")), nil)
+		if attribute != "@schema" {
+			continue
+		}
+		schema, err := ParseSchemaComment(commentLine)
+		if err != nil {
+			return fmt.Errorf("%v: Can not parse schema of %s in %s: %v", parser.fileSet.Position(comment.Pos()), typeSpec.Name.Name, pkgRealPath, err)
+		}
+		parser.ModelSchemas[pkgRealPath+"."+typeSpec.Name.Name] = schema
+	}
+	return nil
+}
+
+// @Schema {"type":"string","format":"date-time"}
+func ParseSchemaComment(commentLine string) (json.RawMessage, error) {
+	schema := strings.TrimSpace(commentLine[len("@Schema"):])
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &object); err != nil {
+		return nil, fmt.Errorf("Schema comment \"%s\" must be a JSON object: %v", commentLine, err)
+	}
+	return json.RawMessage(schema), nil
+}
+
+// ParseEnumValues collects the values of typed constants, which are the enum values of their type.
+// Like the compiler, it repeats the type and values of the previous constant for those without, and evaluates iota
+//
+//	const (
+//		StatusActive   Status = "active"
+//		StatusInactive Status = "inactive"
+//	)
+//	const (
+//		Red Color = iota
+//		Green
+//	)
+func (parser *Parser) ParseEnumValues(pkgRealPath string, constDeclaration *ast.GenDecl) {
+	var constType ast.Expr
+	var constValues []ast.Expr
+	for iota, astSpec := range constDeclaration.Specs {
+		valueSpec, ok := astSpec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		if len(valueSpec.Values) > 0 {
+			constType, constValues = valueSpec.Type, valueSpec.Values
+		} else if valueSpec.Type != nil {
+			continue
+		}
+		typeIdent, ok := constType.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		for i, name := range valueSpec.Names {
+			if i >= len(constValues) || name.Name == "_" {
+				continue
+			}
+			enumValue, ok := EvalConstExpr(constValues[i], iota)
+			if !ok {
+				continue
+			}
+			parser.EnumValues[pkgRealPath][typeIdent.Name] = append(parser.EnumValues[pkgRealPath][typeIdent.Name], enumValue)
+			parser.EnumVarNames[pkgRealPath][typeIdent.Name] = append(parser.EnumVarNames[pkgRealPath][typeIdent.Name], name.Name)
+		}
+	}
+}
+
+// EvalConstExpr evaluates a constant expression of a string or integer literal, iota, and integer arithmetic on them,
+// e.g. "1 << iota". Other expressions, e.g. referencing other constants, can not be evaluated
+func EvalConstExpr(expr ast.Expr, iota int) (string, bool) {
+	if basicLit, ok := expr.(*ast.BasicLit); ok && basicLit.Kind == token.STRING {
+		value, err := strconv.Unquote(basicLit.Value)
+		return value, err == nil
+	}
+	value, ok := evalIntConstExpr(expr, iota)
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatInt(value, 10), true
+}
+
+func evalIntConstExpr(expr ast.Expr, iota int) (int64, bool) {
+	switch astExpr := expr.(type) {
+	case *ast.BasicLit:
+		if astExpr.Kind != token.INT {
+			return 0, false
+		}
+		value, err := strconv.ParseInt(astExpr.Value, 0, 64)
+		return value, err == nil
+	case *ast.Ident:
+		return int64(iota), astExpr.Name == "iota"
+	case *ast.ParenExpr:
+		return evalIntConstExpr(astExpr.X, iota)
+	case *ast.CallExpr:
+		// a conversion, e.g. Color(2)
+		if len(astExpr.Args) != 1 {
+			return 0, false
+		}
+		return evalIntConstExpr(astExpr.Args[0], iota)
+	case *ast.UnaryExpr:
+		value, ok := evalIntConstExpr(astExpr.X, iota)
+		switch astExpr.Op {
+		case token.SUB:
+			return -value, ok
+		case token.ADD:
+			return value, ok
+		}
+	case *ast.BinaryExpr:
+		x, xOk := evalIntConstExpr(astExpr.X, iota)
+		y, yOk := evalIntConstExpr(astExpr.Y, iota)
+		if !xOk || !yOk {
+			return 0, false
+		}
+		switch astExpr.Op {
+		case token.ADD:
+			return x + y, true
+		case token.SUB:
+			return x - y, true
+		case token.MUL:
+			return x * y, true
+		case token.QUO, token.REM:
+			// a constant division by zero does not compile
+			if y == 0 {
+				return 0, false
+			}
+			if astExpr.Op == token.QUO {
+				return x / y, true
 			}
+			return x % y, true
+		case token.SHL:
+			return x << uint64(y), y >= 0
+		case token.SHR:
+			return x >> uint64(y), y >= 0
+		case token.OR:
+			return x | y, true
+		case token.AND:
+			return x & y, true
+		}
+	}
+	return 0, false
+}
+
+// FindEnumValues returns the underlying basic type, the enum values of a named type, if it has any, and the names of their constants
+func (parser *Parser) FindEnumValues(typeName string, currentPackage string) (string, []string, []string) {
+	defer parser.collectFatal()
+	return parser.findEnumValues(typeName, currentPackage)
+}
+
+func (parser *Parser) findEnumValues(typeName string, currentPackage string) (string, []string, []string) {
+	typeNameParts := strings.Split(typeName, ".")
+	packageName := currentPackage
+	if dot := strings.LastIndex(typeName, "."); strings.Contains(typeName, "/") && dot != -1 {
+		// absolute name, e.g. github.com/user/package.Status
+		packageName = typeName[:dot]
+	} else if len(typeNameParts) == 2 {
+		imports, ok := parser.PackageImports[parser.checkRealPackagePath(currentPackage)]
+		if !ok {
+			return "", nil, nil
+		}
+		if packageName, ok = imports[typeNameParts[0]]; !ok {
+			return "", nil, nil
+		}
+	} else if len(typeNameParts) == 1 {
+		packageName = parser.declaringPackage(typeName, currentPackage)
+	} else {
+		return "", nil, nil
+	}
+	typeName = typeNameParts[len(typeNameParts)-1]
+
+	pkgRealPath := parser.checkRealPackagePath(packageName)
+	enumValues, ok := parser.EnumValues[pkgRealPath][typeName]
+	if !ok {
+		return "", nil, nil
+	}
+	typeSpec := parser.getModelDefinition(typeName, packageName)
+	if typeSpec == nil {
+		return "", nil, nil
+	}
+	underlyingType, ok := typeSpec.Type.(*ast.Ident)
+	if !ok || !IsBasicType(underlyingType.Name) {
+		return "", nil, nil
+	}
+	return underlyingType.Name, enumValues, parser.EnumVarNames[pkgRealPath][typeName]
+}
+
+// declaringPackage returns the package a type name without package, written in currentPackage, is declared in:
+// currentPackage, or else the first of the packages it imports with a dot import which declares it
+func (parser *Parser) declaringPackage(typeName string, currentPackage string) string {
+	if currentPackage == "" || parser.getModelDefinition(typeName, currentPackage) != nil {
+		return currentPackage
+	}
+	imports := parser.PackageImports[parser.checkRealPackagePath(currentPackage)]
+	dotImports := make([]string, 0)
+	for importName, importPath := range imports {
+		if strings.HasPrefix(importName, ".") {
+			dotImports = append(dotImports, importPath)
 		}
 	}
+	sort.Strings(dotImports)
+	for _, importPath := range dotImports {
+		if parser.getModelDefinition(typeName, importPath) != nil {
+			return importPath
+		}
+	}
+	return currentPackage
+}
+
+// QualifyTypeName resolves the packages referenced by a type name written in currentPackage to their import paths,
+// e.g. "[]users.User" to "[]github.com/me/app/users.User", so the name can be resolved from any package
+func (parser *Parser) QualifyTypeName(typeName string, currentPackage string) string {
+	defer parser.collectFatal()
+	return parser.qualifyTypeName(typeName, currentPackage)
+}
 
-	//log.Fatalf("Type definition parsed %#v\n", parser.ParseImportStatements(packageName))
+func (parser *Parser) qualifyTypeName(typeName string, currentPackage string) string {
+	if strings.HasPrefix(typeName, "[]") {
+		return "[]" + parser.qualifyTypeName(typeName[2:], currentPackage)
+	}
+	if valueType, ok := SplitMapType(typeName); ok {
+		return typeName[:len(typeName)-len(valueType)] + parser.qualifyTypeName(valueType, currentPackage)
+	}
 
-	for importedPackage, _ := range parser.ParseImportStatements(packageName) {
-		//log.Printf("Import: %v, %v\n", importedPackage, v)
-		parser.ParseTypeDefinitions(importedPackage)
+	baseName, typeArgs := SplitGenericModelName(typeName)
+	if IsBasicType(baseName) {
+		return typeName
+	}
+	qualifiedName := baseName
+	if !strings.Contains(baseName, "/") {
+		if dot := strings.LastIndex(baseName, "."); dot == -1 {
+			qualifiedName = parser.declaringPackage(baseName, currentPackage) + "." + baseName
+		} else if importPath, ok := parser.PackageImports[parser.checkRealPackagePath(currentPackage)][baseName[:dot]]; ok {
+			qualifiedName = importPath + baseName[dot:]
+		}
+	}
+	if len(typeArgs) > 0 {
+		for i, typeArg := range typeArgs {
+			typeArgs[i] = parser.qualifyTypeName(typeArg, currentPackage)
+		}
+		qualifiedName += "[" + strings.Join(typeArgs, ",") + "]"
 	}
+	return qualifiedName
 }
 
 func (parser *Parser) ParseImportStatements(packageName string) map[string]bool {
+	defer parser.collectFatal()
+	return parser.parseImportStatements(packageName)
+}
+
+func (parser *Parser) parseImportStatements(packageName string) map[string]bool {
 
 	parser.CurrentPackage = packageName
-	pkgRealPath := parser.GetRealPackagePath(packageName)
+	pkgRealPath := parser.getRealPackagePath(packageName)
 
 	imports := make(map[string]bool)
-	astPackages := parser.GetPackageAst(pkgRealPath)
+	astPackages := parser.getPackageAst(pkgRealPath)
 
 	parser.PackageImports[pkgRealPath] = make(map[string]string)
+	unresolvedImports := make(map[string]bool)
 	for _, astPackage := range astPackages {
-		for _, astFile := range astPackage.Files {
+		for _, astFile := range sortedFiles(astPackage) {
 			for _, astImport := range astFile.Imports {
 				importedPackageName := strings.Trim(astImport.Path.Value, "\"")
 				if !IsIgnoredPackage(importedPackageName) {
-					realPath := parser.GetRealPackagePath(importedPackageName)
-					//log.Printf("path: %#v, original path: %#v", realPath, astImport.Path.Value)
-					if _, ok := parser.TypeDefinitions[realPath]; !ok {
+					// Packages which can not be found, e.g. build-only dependencies, are skipped, unless a model is looked up in them
+					if realPath := parser.checkRealPackagePath(importedPackageName); realPath == "" {
+						if !unresolvedImports[importedPackageName] {
+							unresolvedImports[importedPackageName] = true
+							parser.warnf("Can not find package %s imported by %s, its types are skipped\n", importedPackageName, packageName)
+						}
+					} else if _, ok := parser.TypeDefinitions[realPath]; !ok {
 						imports[importedPackageName] = true
-						//log.Printf("Parse %s, Add new import definition:%s\n", packageName, astImport.Path.Value)
+						parser.debugf("Package %s imports %s, its type definitions are parsed next\n", packageName, importedPackageName)
 					}
 
-					importPath := strings.Split(importedPackageName, "/")
-					parser.PackageImports[pkgRealPath][importPath[len(importPath)-1]] = importedPackageName
+					importName := ImportName(importedPackageName)
+					if astImport.Name != nil {
+						switch astImport.Name.Name {
+						case "_":
+							// Blank imports have no local name, their types can only be referenced by absolute name
+							continue
+						case ".":
+							// The types of dot imports are referenced without a name, see declaringPackage
+							importName = "." + importedPackageName
+						default:
+							importName = astImport.Name.Name
+						}
+					}
+					parser.PackageImports[pkgRealPath][importName] = importedPackageName
 				}
 			}
 		}
@@ -301,13 +2000,63 @@ func (parser *Parser) ParseImportStatements(packageName string) map[string]bool
 	return imports
 }
 
+// majorVersionSegmentPattern matches the last segment of a package path which is a major version, e.g. "v2", and
+// majorVersionSuffixPattern the major version suffix of a gopkg.in package, e.g. ".v2" in "yaml.v2"
+var (
+	majorVersionSegmentPattern = regexp.MustCompile(`^v[0-9]+$`)
+	majorVersionSuffixPattern  = regexp.MustCompile(`\.v[0-9]+$`)
+)
+
+// ImportName is the name a package is referred to by where it is imported, which by convention is the last
+// segment of its path without the major version, e.g. "models" for ".../models/v2" and "yaml" for "gopkg.in/yaml.v2"
+func ImportName(importPath string) string {
+	segments := strings.Split(importPath, "/")
+	name := segments[len(segments)-1]
+	if majorVersionSegmentPattern.MatchString(name) && len(segments) > 1 {
+		name = segments[len(segments)-2]
+	}
+	return majorVersionSuffixPattern.ReplaceAllString(name, "")
+}
+
+// ImportGraph maps the real path of every parsed package to the sorted real paths of the packages it imports.
+// Blank imports are not recorded in PackageImports, so they are not part of the graph.
+func (parser *Parser) ImportGraph() map[string][]string {
+	defer parser.collectFatal()
+	graph := make(map[string][]string, len(parser.PackageImports))
+	for pkgRealPath, imports := range parser.PackageImports {
+		importedPackages := make([]string, 0, len(imports))
+		for _, importedPackageName := range imports {
+			importedRealPath := parser.checkRealPackagePath(importedPackageName)
+			if importedRealPath == "" {
+				importedRealPath = importedPackageName
+			}
+			importedPackages = append(importedPackages, importedRealPath)
+		}
+		sort.Strings(importedPackages)
+		graph[pkgRealPath] = importedPackages
+	}
+	return graph
+}
+
 func (parser *Parser) GetModelDefinition(model string, packageName string) *ast.TypeSpec {
-	pkgRealPath := parser.CheckRealPackagePath(packageName)
+	defer parser.collectFatal()
+	return parser.getModelDefinition(model, packageName)
+}
+
+func (parser *Parser) getModelDefinition(model string, packageName string) *ast.TypeSpec {
+	pkgRealPath := parser.checkRealPackagePath(packageName)
 	if pkgRealPath == "" {
 		return nil
 	}
 
 	packageModels, ok := parser.TypeDefinitions[pkgRealPath]
+	if !ok && !IsIgnoredPackage(packageName) {
+		// a package which is only referenced by its absolute name in an annotation is not imported, so it is parsed here
+		currentPackage := parser.CurrentPackage
+		parser.parseTypeDefinitions(packageName)
+		parser.CurrentPackage = currentPackage
+		packageModels, ok = parser.TypeDefinitions[pkgRealPath]
+	}
 	if !ok {
 		return nil
 	}
@@ -316,84 +2065,336 @@ func (parser *Parser) GetModelDefinition(model string, packageName string) *ast.
 }
 
 func (parser *Parser) FindModelDefinition(modelName string, currentPackage string) (*ast.TypeSpec, string) {
+	defer parser.collectFatal()
+	return parser.findModelDefinition(modelName, currentPackage)
+}
+
+func (parser *Parser) findModelDefinition(modelName string, currentPackage string) (*ast.TypeSpec, string) {
 	var model *ast.TypeSpec
 	var modelPackage string
 
 	modelNameParts := strings.Split(modelName, ".")
 
-	//if no dot in name - it can be only model from current package
+	//if no dot in name - it can be only model from current package, or from a package it dot imports
 	if len(modelNameParts) == 1 {
-		modelPackage = currentPackage
-		if model = parser.GetModelDefinition(modelName, currentPackage); model == nil {
-			log.Fatalf("Can not find definition of %s model. Current package %s", modelName, currentPackage)
+		modelPackage = parser.declaringPackage(modelName, currentPackage)
+		if model = parser.getModelDefinition(modelName, modelPackage); model == nil {
+			parser.fatalf("Can not find definition of %s model. Current package %s", modelName, currentPackage)
 		}
 	} else {
-		//first try to assume what name is absolute
+		//first try to assume what name is absolute, either github.com/user/package.Model or package.subpackage.Model
 		absolutePackageName := strings.Join(modelNameParts[:len(modelNameParts)-1], "/")
 		modelNameFromPath := modelNameParts[len(modelNameParts)-1]
+		if importPath := modelName[:strings.LastIndex(modelName, ".")]; strings.Contains(importPath, "/") {
+			absolutePackageName = importPath
+		}
 
-		modelPackage = absolutePackageName
-		if model = parser.GetModelDefinition(modelNameFromPath, absolutePackageName); model == nil {
+		if model, modelPackage = parser.findAbsoluteModelDefinition(modelName); model == nil {
+			modelPackage = absolutePackageName
 
 			//can not get model by absolute name.
 			if len(modelNameParts) > 2 {
-				log.Fatalf("Can not find definition of %s model. Name looks like absolute, but model not found in %s package", modelNameFromPath, absolutePackageName)
+				parser.fatalf("Can not find definition of %s model. Name looks like absolute, but model not found in %s package", modelNameFromPath, absolutePackageName)
 			}
 
 			// lets try to find it in imported packages
-			pkgRealPath := parser.CheckRealPackagePath(currentPackage)
+			parser.debugf("Model %s not found in package %s, looking in the imports of %s\n", modelNameFromPath, absolutePackageName, currentPackage)
+			pkgRealPath := parser.checkRealPackagePath(currentPackage)
 			if imports, ok := parser.PackageImports[pkgRealPath]; !ok {
-				log.Fatalf("Can not find definition of %s model. Package %s dont import anything", modelNameFromPath, pkgRealPath)
+				parser.fatalf("Can not find definition of %s model. Package %s dont import anything", modelNameFromPath, pkgRealPath)
 			} else if relativePackage, ok := imports[modelNameParts[0]]; !ok {
-				log.Fatalf("Package %s is not imported to %s, Imported: %#v\n", modelNameParts[0], currentPackage, imports)
-			} else if model = parser.GetModelDefinition(modelNameFromPath, relativePackage); model == nil {
-				log.Fatalf("Can not find definition of %s model in package %s", modelNameFromPath, relativePackage)
+				parser.fatalf("Package %s is not imported to %s, Imported: %#v\n", modelNameParts[0], currentPackage, imports)
+			} else if parser.checkRealPackagePath(relativePackage) == "" {
+				parser.fatalf("Can not find definition of %s model, package %s imported by %s can not be found", modelNameFromPath, relativePackage, currentPackage)
+			} else if model = parser.getModelDefinition(modelNameFromPath, relativePackage); model == nil {
+				parser.fatalf("Can not find definition of %s model in package %s", modelNameFromPath, relativePackage)
 			} else {
 				modelPackage = relativePackage
 			}
 		}
 	}
+
+	// an alias, unlike a defined type, is the same type as the one it names, so it has the same model
+	if model.Assign.IsValid() {
+		if aliasedType := parser.resolveTypeAlias(modelName, currentPackage); isNamedType(aliasedType) {
+			parser.debugf("Model %s is an alias of %s\n", modelName, aliasedType)
+			return parser.findModelDefinition(aliasedType, currentPackage)
+		}
+	}
+	parser.debugf("Model %s of package %s found in package %s\n", modelName, currentPackage, modelPackage)
 	return model, modelPackage
 }
 
-func (parser *Parser) ParseApiDescription(packageName string) {
+// findAbsoluteModelDefinition looks a model up by its absolute name, e.g. "github.com/org/proj/models/v2.User",
+// or with dots instead of slashes, e.g. "github.com.org.proj.models.v2.User". Each dot of the package path may
+// separate two segments, or be part of one, e.g. "github.com" or "yaml.v2". The paths are built segment by segment,
+// dropping those whose directory can not be found, and the first path holding the model wins
+func (parser *Parser) findAbsoluteModelDefinition(modelName string) (*ast.TypeSpec, string) {
+	dot := strings.LastIndex(modelName, ".")
+	typeName := modelName[dot+1:]
+	// the segments up to the last slash are given
+	slash := strings.LastIndex(modelName[:dot], "/")
+	parts := strings.Split(modelName[slash+1:dot], ".")
+
+	packagePaths := []string{modelName[:slash+1] + parts[0]}
+	for _, part := range parts[1:] {
+		longerPaths := make([]string, 0, 2*len(packagePaths))
+		for _, packagePath := range packagePaths {
+			if parser.checkRealPackagePath(packagePath) != "" {
+				longerPaths = append(longerPaths, packagePath+"/"+part)
+			}
+			longerPaths = append(longerPaths, packagePath+"."+part)
+		}
+		packagePaths = longerPaths
+	}
+	for _, packagePath := range packagePaths {
+		if model := parser.getModelDefinition(typeName, packagePath); model != nil {
+			return model, packagePath
+		}
+	}
+	return nil, ""
+}
+
+// ResolveTypeAlias replaces the aliases a type name written in currentPackage refers to with the aliased types,
+// e.g. "[]UserID" with "[]int64" for "type UserID = int64". A type aliased in another package is qualified
+// with QualifyTypeName. Defined types, e.g. "type UserID int64", are new types, and are kept
+func (parser *Parser) ResolveTypeAlias(typeName string, currentPackage string) string {
+	defer parser.collectFatal()
+	return parser.resolveTypeAlias(typeName, currentPackage)
+}
+
+func (parser *Parser) resolveTypeAlias(typeName string, currentPackage string) string {
+	if strings.HasPrefix(typeName, "[]") {
+		return "[]" + parser.resolveTypeAlias(typeName[2:], currentPackage)
+	}
+	if valueType, ok := SplitMapType(typeName); ok {
+		return typeName[:len(typeName)-len(valueType)] + parser.resolveTypeAlias(valueType, currentPackage)
+	}
+	if !isNamedType(typeName) {
+		return typeName
+	}
+
+	typeSpec, typePackage := parser.lookupTypeSpec(typeName, currentPackage)
+	if typeSpec == nil || !typeSpec.Assign.IsValid() {
+		return typeName
+	}
+	aliasedType := (&ModelProperty{}).GetTypeAsString(typeSpec.Type)
+	if _, ok := parser.typeMapping(aliasedType, typePackage); !ok && typePackage != currentPackage {
+		aliasedType = parser.qualifyTypeName(aliasedType, typePackage)
+	}
+	return parser.resolveTypeAlias(aliasedType, currentPackage)
+}
+
+// isNamedType reports whether typeName names a type which may be declared, e.g. "User" or "users.User",
+// rather than a basic, slice, map or generic type
+func isNamedType(typeName string) bool {
+	return typeName != "" && !IsBasicType(typeName) && !strings.ContainsAny(typeName, "[]")
+}
+
+// resolveType resolves a type name written in currentPackage to the type it is documented as, for the fields of
+// the models and the types of @Param and @Success alike: the type an alias refers to, and "object" for an
+// interface. The types of TypeMappings are kept as they are
+func (parser *Parser) resolveType(typeName string, currentPackage string) string {
+	if _, ok := parser.typeMapping(typeName, currentPackage); ok {
+		return typeName
+	}
+	return parser.resolveInterfaceType(parser.resolveTypeAlias(typeName, currentPackage), currentPackage)
+}
+
+// ResolveInterfaceType replaces the interfaces a type name written in currentPackage refers to, i.e. interface{}, any
+// and named interfaces like "type Shape interface { Area() float64 }", with "object". Any JSON value may be found
+// where the Go type is an interface, so it is documented as a free-form object rather than as a model
+func (parser *Parser) ResolveInterfaceType(typeName string, currentPackage string) string {
+	defer parser.collectFatal()
+	return parser.resolveInterfaceType(typeName, currentPackage)
+}
+
+func (parser *Parser) resolveInterfaceType(typeName string, currentPackage string) string {
+	if strings.HasPrefix(typeName, "[]") {
+		return "[]" + parser.resolveInterfaceType(typeName[2:], currentPackage)
+	}
+	if valueType, ok := SplitMapType(typeName); ok {
+		return typeName[:len(typeName)-len(valueType)] + parser.resolveInterfaceType(valueType, currentPackage)
+	}
+	if typeName == "interface" || typeName == "any" {
+		return "object"
+	}
+	if !isNamedType(typeName) {
+		return typeName
+	}
+	if typeSpec, _ := parser.lookupTypeSpec(typeName, currentPackage); typeSpec != nil {
+		if _, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+			return "object"
+		}
+	}
+	return typeName
+}
+
+// lookupTypeSpec returns the declaration of a named type written in currentPackage, and its package,
+// or nil if it is not found
+func (parser *Parser) lookupTypeSpec(typeName string, currentPackage string) (*ast.TypeSpec, string) {
+	dot := strings.LastIndex(typeName, ".")
+	if dot == -1 {
+		typePackage := parser.declaringPackage(typeName, currentPackage)
+		return parser.getModelDefinition(typeName, typePackage), typePackage
+	}
+	typePackage := typeName[:dot]
+	if !strings.Contains(typePackage, "/") {
+		importPath, ok := parser.PackageImports[parser.checkRealPackagePath(currentPackage)][typePackage]
+		if !ok {
+			return nil, ""
+		}
+		typePackage = importPath
+	}
+	return parser.getModelDefinition(typeName[dot+1:], typePackage), typePackage
+}
+
+func (parser *Parser) ParseApiDescription(packageName string) (err error) {
+	defer recoverFatal(&err)
+	parser.parseApiDescription(packageName)
+	return nil
+}
+
+func (parser *Parser) parseApiDescription(packageName string) {
 	parser.CurrentPackage = packageName
-	pkgRealPath := parser.GetRealPackagePath(packageName)
+	parser.apiPackages[packageName] = true
+	pkgRealPath := parser.getRealPackagePath(packageName)
 
-	astPackages := parser.GetPackageAst(pkgRealPath)
+	astPackages := parser.getPackageAst(pkgRealPath)
 	for _, astPackage := range astPackages {
-		for _, astFile := range astPackage.Files {
-			for _, astDescription := range astFile.Decls {
-				switch astDeclaration := astDescription.(type) {
-				case *ast.FuncDecl:
-					if parser.IsController(astDeclaration) {
-						operation := NewOperation(parser, packageName)
-						if astDeclaration.Doc != nil && astDeclaration.Doc.List != nil {
-							for _, comment := range astDeclaration.Doc.List {
-								if err := operation.ParseComment(comment.Text); err != nil {
-									log.Printf("Can not parse comment for function: %v, package: %v, got error: %v\n", astDeclaration.Name.String(), packageName, err)
-								}
-							}
-						}
-						if operation.Path != "" {
-							parser.AddOperation(operation)
+		for _, fileName := range sortedFileNames(astPackage) {
+			if parser.isCancelled() {
+				return
+			}
+			parser.parseApiFile(packageName, fileName, astPackage.Files[fileName])
+		}
+	}
+}
+
+// ParseApiFile parses the operations of the controllers of a single file, e.g. the one just edited in an editor,
+// rather than of its whole package. The types of its package are parsed again, as the models may have changed,
+// and the operations parsed from the file before are replaced. The file must be in a package below $GOPATH/src
+// or in a module, see localImportPath
+func (parser *Parser) ParseApiFile(filePath string) (err error) {
+	defer recoverFatal(&err)
+
+	realFilePath, err := filepath.Abs(filePath)
+	if err == nil {
+		realFilePath, err = filepath.EvalSymlinks(realFilePath)
+	}
+	if err != nil {
+		return fmt.Errorf("Can not parse API file %s: %v", filePath, err)
+	}
+	packageName := parser.dirImportPath(filepath.Dir(realFilePath))
+	if packageName == "" {
+		return fmt.Errorf("Can not parse API file %s, it is neither in a package below $GOPATH/src nor in a module", filePath)
+	}
+
+	parser.invalidatePackage(packageName)
+	parser.parseTypeDefinitions(packageName)
+	parser.CurrentPackage = packageName
+	pkgRealPath := parser.getRealPackagePath(packageName)
+	fileName := filepath.Join(pkgRealPath, filepath.Base(realFilePath))
+	for _, astPackage := range parser.getPackageAst(pkgRealPath) {
+		if astFile, ok := astPackage.Files[fileName]; ok {
+			parser.removeOperations(func(op *Operation) bool {
+				return op.fileName == fileName
+			})
+			parser.parseApiFile(packageName, fileName, astFile)
+			return nil
+		}
+	}
+	return fmt.Errorf("Can not parse API file %s, it is not a Go file of package %s, or it does not match the FileFilter", filePath, packageName)
+}
+
+// gopathPackage returns the import path of a directory below $GOPATH/src, or "" if it is not in one
+func gopathPackage(dir string) string {
+	for _, gopath := range filepath.SplitList(os.Getenv("GOPATH")) {
+		src, err := filepath.EvalSymlinks(filepath.Join(gopath, "src"))
+		if err != nil {
+			continue
+		}
+		if relativePath, err := filepath.Rel(src, dir); err == nil && relativePath != "." && !strings.HasPrefix(relativePath, "..") {
+			return filepath.ToSlash(relativePath)
+		}
+	}
+	return ""
+}
+
+// parseApiFile parses the operations of the controllers of a file of the package, and the sub api descriptions
+func (parser *Parser) parseApiFile(packageName string, fileName string, astFile *ast.File) {
+	for _, astDescription := range astFile.Decls {
+		switch astDeclaration := astDescription.(type) {
+		case *ast.FuncDecl:
+			isController := parser.IsController
+			if isController == nil {
+				isController = parser.hasRouteAnnotation
+			}
+			if isController(astDeclaration) {
+				operation := NewOperation(parser, packageName)
+				operation.Handler = astDeclaration.Name.String()
+				operation.Receiver = ReceiverTypeName(astDeclaration)
+				operation.fileName = fileName
+				operation.line = parser.fileSet.Position(astDeclaration.Pos()).Line
+				if astDeclaration.Doc != nil && astDeclaration.Doc.List != nil {
+					for _, comment := range astDeclaration.Doc.List {
+						if err := parser.parseOperationComment(operation, comment.Text); err != nil {
+							parser.warnf("%v: Can not parse comment for function: %v, package: %v, got error: %v\n", parser.fileSet.Position(comment.Pos()), astDeclaration.Name.String(), packageName, err)
 						}
 					}
 				}
-			}
-			for _, astComment := range astFile.Comments {
-				for _, commentLine := range strings.Split(astComment.Text(), "\n") {
-					parser.ParseSubApiDescription(commentLine)
+				if operation.OperationId == "" {
+					operation.OperationId = operation.Handler
 				}
+				if parser.GroupByReceiver && operation.ForceResource == "" && operation.Receiver != "" {
+					operation.ForceResource = ReceiverResource(operation.Receiver)
+				}
+				parser.AddOperation(operation)
 			}
 		}
 	}
+	for _, astComment := range astFile.Comments {
+		for _, commentLine := range strings.Split(astComment.Text(), "\n") {
+			parser.ParseSubApiDescription(commentLine)
+		}
+	}
+}
+
+// ReceiverResource is the resource of the operations of the methods of a controller type, when GroupByReceiver
+// is set and they have no @Resource: the name of the type without a Controller or Handler suffix, starting in
+// lower case, e.g. "order" for OrderController. Their default tag is the same
+func ReceiverResource(receiver string) string {
+	if receiver == "" {
+		return ""
+	}
+	resource := receiver
+	for _, suffix := range []string{"Controller", "Handler"} {
+		if trimmed := strings.TrimSuffix(resource, suffix); trimmed != "" {
+			resource = trimmed
+		}
+	}
+	return strings.ToLower(resource[:1]) + resource[1:]
+}
+
+// parseOperationComment parses a comment line of a controller. While ValidateApi runs, the failures which abort
+// the parsing, like a model which can not be found, are returned instead, so the next lines are still validated
+func (parser *Parser) parseOperationComment(operation *Operation, commentLine string) (err error) {
+	if parser.dryRun {
+		currentPackage := parser.CurrentPackage
+		defer func() {
+			parser.CurrentPackage = currentPackage
+		}()
+		defer recoverFatal(&err)
+	}
+	return operation.parseComment(commentLine)
 }
 
 // Parse sub api declaration
 // @SubApi Very fancy API [/fancy-api]
+//
+// The description is kept for the resource, whether it is listed already or its operations are parsed later
 func (parser *Parser) ParseSubApiDescription(commentLine string) {
-	if !strings.HasPrefix(commentLine, "@SubApi") {
+	if commentLine, _ = parser.parseAnnotation(commentLine, nil); !strings.HasPrefix(commentLine, "@SubApi") {
 		return
 	} else {
 		commentLine = strings.TrimSpace(commentLine[len("@SubApi"):])
@@ -401,21 +2402,66 @@ func (parser *Parser) ParseSubApiDescription(commentLine string) {
 	re := regexp.MustCompile(`([^\[]+)\[{1}([\w\_\-/]+)`)
 
 	if matches := re.FindStringSubmatch(commentLine); len(matches) != 3 {
-		log.Printf("Can not parse sub api description %s, skipped", commentLine)
+		parser.warnf("Can not parse sub api description %s, skipped", commentLine)
 	} else {
-		for _, ref := range parser.Listing.Apis {
-			if ref.Path == matches[2] {
-				ref.Description = strings.TrimSpace(matches[1])
-			}
-		}
+		parser.listingMutex.Lock()
+		defer parser.listingMutex.Unlock()
+		parser.setResourceDescription(matches[2], strings.TrimSpace(matches[1]))
+	}
+}
+
+// sortedFiles returns the files of a package sorted by name, so they are parsed in the same order on every run
+func sortedFiles(astPackage *ast.Package) []*ast.File {
+	fileNames := sortedFileNames(astPackage)
+	files := make([]*ast.File, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		files = append(files, astPackage.Files[fileName])
 	}
+	return files
+}
+
+func sortedFileNames(astPackage *ast.Package) []string {
+	fileNames := make([]string, 0, len(astPackage.Files))
+	for fileName := range astPackage.Files {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+	return fileNames
 }
 
 func IsIgnoredPackage(packageName string) bool {
 	return packageName == "C" || packageName == "appengine/cloudsql" || packageName == "appengine/datastore"
 }
 
+// ParserFileFilter is the default FileFilter, it accepts the Go files of a package, except tests and dotfiles
 func ParserFileFilter(info os.FileInfo) bool {
 	name := info.Name()
 	return !info.IsDir() && !strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
 }
+
+// fileFilter accepts the files of the FileFilter whose name and build constraints, e.g. "_windows.go" or
+// "//go:build linux", match the BuildContext, so types declared for other platforms are not parsed
+func (parser *Parser) fileFilter(dir string) func(os.FileInfo) bool {
+	buildContext := parser.BuildContext
+	if buildContext == nil {
+		buildContext = &build.Default
+	}
+	fileFilter := parser.FileFilter
+	if fileFilter == nil {
+		fileFilter = ParserFileFilter
+	}
+	return func(info os.FileInfo) bool {
+		if !fileFilter(info) {
+			return false
+		}
+		match, err := buildContext.MatchFile(dir, info.Name())
+		if err != nil {
+			// the file is left to the Go parser, which reports what is wrong with it
+			return true
+		}
+		if !match {
+			parser.debugf("File %s skipped, it does not match the build constraints\n", filepath.Join(dir, info.Name()))
+		}
+		return match
+	}
+}
@@ -1,17 +1,28 @@
 package parser_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"go/ast"
+	"go/build"
+	goparser "go/parser"
+	"go/token"
 
 	"github.com/RobotsAndPencils/go-swaggerLite/parser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
-	//	"log"
+	"io/ioutil"
+	"log"
 	"os"
 	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type ParserSuite struct {
@@ -45,7 +56,7 @@ func (suite *ParserSuite) SetupSuite() {
 			suite.T().Fatalf("Please, set $GOPATH environment variable\n")
 		}
 
-		initialisedParser2.ParseGeneralApiInfo(path.Join(gopath, "src", "github.com/RobotsAndPencils/go-swaggerLite/example/web/main.go"))
+		initialisedParser2.ParseGeneralAPIInfo(path.Join(gopath, "src", "github.com/RobotsAndPencils/go-swaggerLite/example/web/main.go"))
 		initialisedParser2.ParseApi("github.com/RobotsAndPencils/go-swaggerLite/example")
 	}
 	suite.parser = initialisedParser2
@@ -75,7 +86,7 @@ func (suite *ParserSuite) TestTopLevelAPI() {
 }
 
 func (suite *ParserSuite) CheckSubApiList(topApi *parser.ApiDeclaration) {
-	assert.Len(suite.T(), topApi.Apis, 9, "Sub API was not parsed corectly")
+	assert.Len(suite.T(), topApi.Apis, 10, "Sub API was not parsed corectly")
 
 	for _, subApi := range topApi.Apis {
 		switch subApi.Path {
@@ -124,6 +135,11 @@ func (suite *ParserSuite) CheckSubApiList(topApi *parser.ApiDeclaration) {
 			assert.Len(suite.T(), subApi.Operations, 1, "Operations not parsed correctly")
 			suite.CheckGetStruct3(subApi.Operations[0])
 
+		case "/testapi/pets":
+			assert.Equal(suite.T(), subApi.Description, "add a cat or a dog", "Description was not parsed properly")
+			assert.Len(suite.T(), subApi.Operations, 1, "Operations not parsed correctly")
+			suite.CheckAddPet(subApi.Operations[0])
+
 		default:
 			suite.T().Fatalf("Undefined sub API: %#v", subApi)
 		}
@@ -150,6 +166,7 @@ func (suite *ParserSuite) CheckGetStringByInt(op *parser.Operation) {
 func (suite *ParserSuite) CheckGetStructByInt(op *parser.Operation) {
 	assert.Equal(suite.T(), "GET", op.HttpMethod, "Http method not parsed")
 	assert.Equal(suite.T(), "GetStructByInt", op.Nickname, "Nickname not parsed")
+	assert.Equal(suite.T(), "GetStructByInt", op.OperationId, "Operation id should default to the handler name")
 	assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.StructureWithEmbededStructure", op.Type, "Type not parsed")
 
 	assert.Equal(suite.T(), op.Path, "/testapi/get-struct-by-int/{some_id}", "Resource path invalid")
@@ -220,7 +237,7 @@ func (suite *ParserSuite) CheckGetStructArrayByString(op *parser.Operation) {
 func (suite *ParserSuite) CheckGetInterface(op *parser.Operation) {
 	assert.Equal(suite.T(), "GET", op.HttpMethod, "Http method not parsed")
 	assert.Equal(suite.T(), "GetInterface", op.Nickname, "Nickname not parsed")
-	assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.InterfaceType", op.Type, "Type not parsed")
+	assert.Equal(suite.T(), "object", op.Type, "Interface response should be a free-form object")
 
 	assert.Equal(suite.T(), op.Path, "/testapi/get-interface", "Resource path invalid")
 
@@ -230,7 +247,7 @@ func (suite *ParserSuite) CheckGetInterface(op *parser.Operation) {
 
 	assert.Len(suite.T(), op.Parameters, 0, "Params not parsed")
 	assert.Len(suite.T(), op.ResponseMessages, 3, "Response message not parsed")
-	assert.Len(suite.T(), op.Models, 2, "Models not parsed %#v", op.Models)
+	assert.Len(suite.T(), op.Models, 1, "Interface response should not be a model %#v", op.Models)
 }
 
 func (suite *ParserSuite) CheckGetSimpleAliased(op *parser.Operation) {
@@ -253,7 +270,8 @@ func (suite *ParserSuite) CheckGetArrayOfInterfaces(op *parser.Operation) {
 	assert.Equal(suite.T(), "GET", op.HttpMethod, "Http method not parsed")
 	assert.Equal(suite.T(), "GetArrayOfInterfaces", op.Nickname, "Nickname not parsed")
 	assert.Equal(suite.T(), "array", op.Type, "Type not parsed")
-	assert.Equal(suite.T(), "github.com.RobotsAndPencils.go-swaggerLite.example.InterfaceType", op.Items.Ref, "Type not parsed")
+	assert.Equal(suite.T(), "object", op.Items.Type, "Interface items should be free-form objects")
+	assert.Equal(suite.T(), "", op.Items.Ref, "Interface items should not reference a model")
 
 	assert.Equal(suite.T(), op.Path, "/testapi/get-array-of-interfaces", "Resource path invalid")
 
@@ -263,7 +281,7 @@ func (suite *ParserSuite) CheckGetArrayOfInterfaces(op *parser.Operation) {
 
 	assert.Len(suite.T(), op.Parameters, 0, "Params not parsed")
 	assert.Len(suite.T(), op.ResponseMessages, 3, "Response message not parsed")
-	assert.Len(suite.T(), op.Models, 2, "Models not parsed %#v", op.Models)
+	assert.Len(suite.T(), op.Models, 1, "Interface items should not be a model %#v", op.Models)
 }
 
 func (suite *ParserSuite) CheckGetStruct3(op *parser.Operation) {
@@ -283,8 +301,26 @@ func (suite *ParserSuite) CheckGetStruct3(op *parser.Operation) {
 	assert.Len(suite.T(), op.Models, 2, "Models not parsed %#v", op.Models)
 }
 
+func (suite *ParserSuite) CheckAddPet(op *parser.Operation) {
+	assert.Equal(suite.T(), "POST", op.HttpMethod, "Http method not parsed")
+	assert.Equal(suite.T(), "AddPet", op.Nickname, "Nickname not parsed")
+	assert.Equal(suite.T(), "", op.Type, "Composed response should not have a type")
+
+	expectedOneOf := []string{
+		"github.com.RobotsAndPencils.go-swaggerLite.example.Cat",
+		"github.com.RobotsAndPencils.go-swaggerLite.example.Dog",
+	}
+	assert.Equal(suite.T(), expectedOneOf, op.OneOf, "Composed response not parsed")
+
+	assert.Len(suite.T(), op.Parameters, 1, "Params not parsed")
+	assert.Equal(suite.T(), expectedOneOf, op.Parameters[0].OneOf, "Composed body param not parsed")
+
+	assert.Len(suite.T(), op.ResponseMessages, 2, "Response message not parsed")
+	assert.Equal(suite.T(), expectedOneOf, op.ResponseMessages[0].OneOf, "Composed response not parsed")
+}
+
 func (suite *ParserSuite) CheckModelList(topApi *parser.ApiDeclaration) {
-	assert.Len(suite.T(), topApi.Models, 7, "Models was not parsed corectly")
+	assert.Len(suite.T(), topApi.Models, 9, "Models was not parsed corectly")
 
 	for _, model := range topApi.Models {
 		switch model.Id {
@@ -294,9 +330,6 @@ func (suite *ParserSuite) CheckModelList(topApi *parser.ApiDeclaration) {
 		case "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleAlias":
 			assert.Len(suite.T(), model.Properties, 0, "Model not parsed correctly")
 
-		case "github.com.RobotsAndPencils.go-swaggerLite.example.InterfaceType":
-			assert.Len(suite.T(), model.Properties, 0, "Model not parsed correctly")
-
 		case "github.com.RobotsAndPencils.go-swaggerLite.example.StructureWithEmbededPointer":
 			assert.Len(suite.T(), model.Properties, 2, "Model not parsed correctly")
 
@@ -309,6 +342,16 @@ func (suite *ParserSuite) CheckModelList(topApi *parser.ApiDeclaration) {
 		case "github.com.RobotsAndPencils.go-swaggerLite.example.SimpleStructureWithAnnotations":
 			assert.Len(suite.T(), model.Properties, 2, "Model not parsed correctly")
 
+		case "github.com.RobotsAndPencils.go-swaggerLite.example.Cat":
+			assert.Len(suite.T(), model.Properties, 1, "Model not parsed correctly")
+
+		case "github.com.RobotsAndPencils.go-swaggerLite.example.Dog":
+			assert.Len(suite.T(), model.Properties, 1, "Model not parsed correctly")
+			assert.Equal(suite.T(), []string{"github.com.RobotsAndPencils.go-swaggerLite.example.Animal"}, model.AllOf, "Model not parsed correctly")
+
+		case "github.com.RobotsAndPencils.go-swaggerLite.example.Animal":
+			assert.Len(suite.T(), model.Properties, 1, "Model not parsed correctly")
+
 		default:
 			suite.T().Errorf("Model %#v", model)
 		}
@@ -334,6 +377,1792 @@ func (suite *ParserSuite) TestAPIListing() {
 
 }
 
+func TestParseGeneralAPIInfoFromSrc(t *testing.T) {
+	src := `// @APIVersion 2.0.1
+// @APITitle In-memory API
+// @APIDescription API described without a file on disk
+// @Contact api@contact.me
+// @License BSD
+package main
+`
+	p := parser.NewParser()
+	err := p.ParseGeneralAPIInfoFromSrc([]byte(src))
+	assert.Nil(t, err, "Can not parse general API info from source")
+
+	assert.Equal(t, "2.0.1", p.Listing.ApiVersion, "Api version not parsed")
+	assert.Equal(t, parser.SwaggerVersion, p.Listing.SwaggerVersion, "Swagger version not set")
+	assert.Equal(t, "In-memory API", p.Listing.Infos.Title, "Title is not parsed")
+	assert.Equal(t, "API described without a file on disk", p.Listing.Infos.Description, "Description is not parsed")
+	assert.Equal(t, "api@contact.me", p.Listing.Infos.Contact, "Contact is not parsed")
+	assert.Equal(t, "BSD", p.Listing.Infos.License, "License is not parsed")
+
+	err2 := parser.NewParser().ParseGeneralAPIInfoFromSrc([]byte("not go code"))
+	assert.NotNil(t, err2, "Invalid source should not be parsed")
+}
+
+func TestParseBasePathAndSchemes(t *testing.T) {
+	src := `// @APIVersion 1.0.0
+// @BasePath /api/v1
+// @Schemes https, HTTP
+package main
+`
+	p := parser.NewParser()
+	err := p.ParseGeneralAPIInfoFromSrc([]byte(src))
+	assert.Nil(t, err, "Can not parse base path and schemes")
+	assert.Equal(t, "/api/v1", p.BasePath, "Base path not parsed")
+	assert.Equal(t, []string{"https", "http"}, p.Schemes, "Schemes not parsed")
+
+	op := parser.NewOperation(p, "test")
+	op.Path = "/orders/{id}"
+	p.AddOperation(op)
+	assert.Equal(t, "/api/v1", p.TopLevelApis["orders"].BasePath, "Base path not set on the api declaration")
+	assert.Equal(t, []string{"https", "http"}, p.TopLevelApis["orders"].Schemes, "Schemes not set on the api declaration")
+
+	p2 := parser.NewParser()
+	p2.BasePath = "http://127.0.0.1:3000"
+	assert.Nil(t, p2.ParseGeneralAPIInfoFromSrc([]byte(src)), "Can not parse base path and schemes")
+	assert.Equal(t, "http://127.0.0.1:3000", p2.BasePath, "Base path set beforehand should win")
+
+	assert.NotNil(t, p.ParseSchemesComment("@Schemes ftp"), "Unsupported scheme should not be accepted")
+	assert.NotNil(t, p.ParseSchemesComment("@Schemes"), "Schemes comment without scheme should not be accepted")
+}
+
+func TestSpecVersion(t *testing.T) {
+	p := parser.NewParser()
+	assert.Equal(t, parser.SwaggerVersion, p.SpecVersion(parser.FormatSwagger12), "Default Swagger 1.2 version not set")
+	assert.Equal(t, parser.Swagger20Version, p.SpecVersion(parser.FormatSwagger20), "Swagger 2.0 version not set")
+	assert.Equal(t, parser.OpenAPI3Version, p.SpecVersion(parser.FormatOpenAPI3), "Default OpenAPI 3.0 version not set")
+
+	p.SwaggerVersion = "1.2.1"
+	p.OpenAPIVersion = "3.0.0"
+	assert.Nil(t, p.ParseGeneralAPIInfoFromSrc([]byte("// @APIVersion 1.0.0\npackage main\n")), "Can not parse general API info")
+	op := parser.NewOperation(p, "test")
+	assert.Nil(t, op.ParseComment("// @Router /orders [get]"), "Can not parse router comment")
+	p.AddOperation(op)
+
+	assert.Equal(t, "1.2.1", p.Listing.SwaggerVersion, "Resource listing should have the configured version")
+	assert.Equal(t, "1.2.1", p.TopLevelApis["orders"].SwaggerVersion, "Api declaration should have the configured version")
+	assert.Equal(t, "2.0", p.Swagger20().Swagger, "Swagger 2.0 spec should have its version")
+	assert.Equal(t, "3.0.0", p.OpenAPI3().OpenAPI, "OpenAPI 3.0 spec should have the configured version")
+
+	p.SwaggerVersion, p.OpenAPIVersion = "", ""
+	assert.Equal(t, parser.SwaggerVersion, p.SpecVersion(parser.FormatSwagger12), "Empty Swagger 1.2 version should fall back to the default")
+	assert.Equal(t, parser.OpenAPI3Version, p.OpenAPI3().OpenAPI, "Empty OpenAPI 3.0 version should fall back to the default")
+	assert.Nil(t, p.ParseGeneralAPIInfoFromSrc([]byte("// @APIVersion 1.0.0\npackage main\n")), "Can not parse general API info")
+	assert.Equal(t, parser.SwaggerVersion, p.Listing.SwaggerVersion, "Empty Swagger 1.2 version should fall back to the default")
+}
+
+func TestExternalDocs(t *testing.T) {
+	src := `// @APIVersion 1.0.0
+// @ExternalDocs https://example.com/docs "API guide"
+package main
+`
+	p := parser.NewParser()
+	assert.Nil(t, p.ParseGeneralAPIInfoFromSrc([]byte(src)), "Can not parse general external docs")
+	apiDocs := &parser.ExternalDocs{Url: "https://example.com/docs", Description: "API guide"}
+	assert.Equal(t, apiDocs, p.Listing.ExternalDocs, "General @ExternalDocs not parsed")
+	assert.Contains(t, string(p.GetResourceListingJson()), `"x-externalDocs": {`, "General @ExternalDocs not serialized")
+
+	op := parser.NewOperation(p, "test")
+	assert.Nil(t, op.ParseComment("// @ExternalDocs https://example.com/docs/orders"), "Can not parse operation external docs")
+	assert.Nil(t, op.ParseComment("// @Router /orders [get]"), "Can not parse router comment")
+	p.AddOperation(op)
+	orderDocs := &parser.ExternalDocs{Url: "https://example.com/docs/orders"}
+	assert.Equal(t, orderDocs, op.ExternalDocs, "Operation @ExternalDocs not parsed")
+
+	swagger := p.Swagger20()
+	assert.Equal(t, apiDocs, swagger.ExternalDocs, "General @ExternalDocs not converted")
+	assert.Equal(t, orderDocs, swagger.Paths["/orders"]["get"].ExternalDocs, "Operation @ExternalDocs not converted")
+	openAPI := p.OpenAPI3()
+	assert.Equal(t, apiDocs, openAPI.ExternalDocs, "General @ExternalDocs not converted")
+	assert.Equal(t, orderDocs, openAPI.Paths["/orders"]["get"].ExternalDocs, "Operation @ExternalDocs not converted")
+
+	for _, comment := range []string{
+		"// @ExternalDocs",
+		"// @ExternalDocs docs/orders.html",
+		`// @ExternalDocs https://example.com/docs More info`,
+	} {
+		assert.NotNil(t, parser.NewOperation(p, "test").ParseComment(comment), "Invalid external docs should fail: %s", comment)
+	}
+	assert.NotNil(t, p.ParseGeneralAPIInfoFromSrc([]byte("// @ExternalDocs /docs\npackage main\n")), "Invalid general external docs should fail")
+}
+
+func TestParseGeneralContentTypes(t *testing.T) {
+	src := `// @APIVersion 1.0.0
+// @Accept json,form
+// @Produce json
+package main
+
+// @Title Upload
+// @Accept xml
+// @Produce plain
+// @Router /upload [post]
+func Upload() {}
+`
+	p := parser.NewParser()
+	assert.Nil(t, p.ParseGeneralAPIInfoFromSrc([]byte(src)), "Can not parse general content types")
+	assert.Equal(t, []string{"application/json", "application/x-www-form-urlencoded"}, p.Consumes, "General @Accept not parsed")
+	assert.Equal(t, []string{"application/json"}, p.Produces, "General @Produce not parsed")
+
+	operations := map[string]string{
+		"default": "",
+		"accept":  "// @Accept xml",
+		"produce": "// @Produce plain",
+	}
+	for name, comment := range operations {
+		op := parser.NewOperation(p, "test")
+		if comment != "" {
+			assert.Nil(t, op.ParseComment(comment), "Can not parse operation comment")
+		}
+		assert.Nil(t, op.ParseComment("// @Router /"+name+" [get]"), "Can not parse router comment")
+		p.AddOperation(op)
+	}
+	operation := func(name string) *parser.Operation {
+		return p.TopLevelApis[name].Apis[0].Operations[0]
+	}
+	assert.Equal(t, []string{"application/json", "application/x-www-form-urlencoded"}, operation("default").Consumes, "Operation should inherit the general @Accept")
+	assert.Equal(t, []string{"application/json"}, operation("default").Produces, "Operation should inherit the general @Produce")
+	assert.Equal(t, []string{"application/xml"}, operation("accept").Consumes, "@Accept of the operation should win")
+	assert.Equal(t, []string{"application/json"}, operation("accept").Produces, "Operation should inherit the general @Produce")
+	assert.Equal(t, []string{"application/json", "application/x-www-form-urlencoded"}, operation("produce").Consumes, "Operation should inherit the general @Accept")
+	assert.Equal(t, []string{"text/plain"}, operation("produce").Produces, "@Produce of the operation should win")
+
+	// the defaults are not shared between operations
+	operation("default").Consumes[0] = "text/html"
+	assert.Equal(t, "application/json", p.Consumes[0], "General @Accept should be copied to the operations")
+
+	assert.NotNil(t, p.ParseGeneralAPIInfoFromSrc([]byte("// @Accept foo\npackage main\n")), "Unknown general content type should fail")
+}
+
+func TestParseApiDescriptionFile(t *testing.T) {
+	src := `// @APIVersion 1.0.0
+// @ApiDescriptionFile testdata/api_description.md
+package main
+`
+	p := parser.NewParser()
+	err := p.ParseGeneralAPIInfoFromSrc([]byte(src))
+	assert.Nil(t, err, "Can not parse API description file")
+	assert.Equal(t, "# Orders API\n\nManages the **orders** of customers.", p.Listing.Infos.Description, "API description not loaded from file")
+
+	dir, err := ioutil.TempDir("", "web")
+	if err != nil {
+		t.Fatalf("Can not create main API dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	mainAPIFile := filepath.Join(dir, "main.go")
+	ioutil.WriteFile(mainAPIFile, []byte("// @ApiDescription Inline\n// @ApiDescriptionFile description.md\npackage main\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "description.md"), []byte("From the main API dir\n"), 0644)
+
+	p2 := parser.NewParser()
+	assert.Nil(t, p2.ParseGeneralAPIInfo(mainAPIFile), "Can not parse API description file")
+	assert.Equal(t, "From the main API dir", p2.Listing.Infos.Description, "API description file not relative to the main API file")
+
+	p3 := parser.NewParser()
+	err = p3.ParseGeneralAPIInfoFromSrc([]byte("// @ApiDescription Inline\n// @ApiDescriptionFile missing.md\npackage main\n"))
+	assert.Nil(t, err, "Missing API description file should not fail")
+	assert.Equal(t, "Inline", p3.Listing.Infos.Description, "Missing API description file should keep the description")
+}
+
+func TestOperationDescriptionFile(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	dir := filepath.Join(gopath, "src", "example.com/api/users")
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	source := "package users\n\n" +
+		"// @Title CreateUser\n// @Description Create a user\n// @DescriptionFile ./docs/create_user.md\n// @Router /users [post]\nfunc CreateUser() {}\n\n" +
+		"// @Title DeleteUser\n// @DescriptionFile ./docs/delete_user.md\n// @Router /users/{id} [delete]\nfunc DeleteUser() {}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "users.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Can not write package source: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "docs", "create_user.md"), []byte("Creates a **user**.\n\nThe email must be unique.\n"), 0644); err != nil {
+		t.Fatalf("Can not write description file: %v", err)
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.LibraryMode = true
+	assert.Nil(t, p.ParseApiContext(context.Background(), "example.com/api/users"), "Can not parse API")
+	operations := map[string]*parser.Operation{}
+	p.WalkOperations(func(op *parser.Operation) error {
+		operations[op.Nickname] = op
+		return nil
+	})
+	assert.Equal(t, "Create a user", operations["CreateUser"].Summary, "Description file should not change the summary")
+	assert.Equal(t, "Creates a **user**.\n\nThe email must be unique.", operations["CreateUser"].Notes, "Notes not loaded from the description file")
+	assert.Equal(t, "", operations["DeleteUser"].Notes, "Missing description file should leave the notes empty")
+	if assert.Len(t, p.Warnings, 1, "Missing description file should fail the annotation") {
+		assert.Contains(t, p.Warnings[0].Error(), filepath.Join(dir, "docs", "delete_user.md"), "Failure should name the missing file")
+	}
+	assert.NotNil(t, parser.NewOperation(p, "test").ParseComment("// @DescriptionFile"), "Description file without a path should fail")
+	err = parser.NewOperation(p, "test").ParseComment("// @DescriptionFile ./docs/create_user.md")
+	if assert.NotNil(t, err, "Relative description file of an operation without a file should fail") {
+		assert.Contains(t, err.Error(), "not parsed from a file", "Relative description file should not be read from the working directory")
+	}
+}
+
+func TestAnnotationPrefixes(t *testing.T) {
+	src := `// @APIVersion 1.0.0
+// @swagger:ApiTitle Orders API
+// @swagger:APIVersion 2.0.0
+package main
+
+// @swagger:Router /orders [get]
+func GetOrders() {}
+
+// @Route /orders [post]
+func CreateOrder() {}
+`
+	p := parser.NewParser()
+	p.AnnotationPrefixes = []string{"@", "@swagger:"}
+	err := p.ParseGeneralAPIInfoFromSrc([]byte(src))
+	assert.Nil(t, err, "Can not parse general API info with annotation prefixes")
+	assert.Equal(t, "Orders API", p.Listing.Infos.Title, "Can not parse general API info with annotation prefix")
+	assert.Equal(t, "1.0.0", p.Listing.ApiVersion, "The first of the prefixes given for an attribute should win")
+
+	fileTree, err := goparser.ParseFile(token.NewFileSet(), "", src, goparser.ParseComments)
+	if err != nil {
+		t.Fatalf("Can not parse source: %v", err)
+	}
+	for _, decl := range fileTree.Decls {
+		funcDeclaration := decl.(*ast.FuncDecl)
+		assert.True(t, p.IsController(funcDeclaration), "Controller not detected: %s", funcDeclaration.Name.Name)
+	}
+	assert.False(t, parser.HasRouterAnnotation(fileTree.Decls[0].(*ast.FuncDecl)), "HasRouterAnnotation only knows the @ prefix")
+
+	annotation, prefix := parser.NormalizeAnnotation("@swagger:Router /orders [get]", p.AnnotationPrefixes)
+	assert.Equal(t, "@Router /orders [get]", annotation, "Can not normalize annotation")
+	assert.Equal(t, "@swagger:", prefix, "The longest matching prefix should be used")
+}
+
+func TestParseApiContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := parser.NewParser()
+	err := p.ParseApiContext(ctx, "github.com/RobotsAndPencils/go-swaggerLite/example")
+	assert.Equal(t, context.Canceled, err, "Cancelled parsing should return the context error")
+	assert.Empty(t, p.TypeDefinitions, "No package should be parsed once the context is cancelled")
+	assert.Empty(t, p.TopLevelApis, "No package should be parsed once the context is cancelled")
+
+	p2 := parser.NewParser()
+	err = p2.ParseApiContext(context.Background(), "github.com/RobotsAndPencils/go-swaggerLite/example")
+	assert.Nil(t, err, "Can not parse API with context")
+	assert.NotEmpty(t, p2.TopLevelApis, "Can not parse API with context")
+}
+
+func TestParseSecurityDefinition(t *testing.T) {
+	src := `// @APIVersion 1.0.0
+// @SecurityDefinition api_key apiKey header X-API-Key
+// @SecurityDefinition basic basic
+package main
+`
+	p := parser.NewParser()
+	err := p.ParseGeneralAPIInfoFromSrc([]byte(src))
+	assert.Nil(t, err, "Can not parse security definitions")
+	assert.Len(t, p.Listing.Authorizations, 2, "Security definitions not parsed")
+
+	assert.Equal(t, &parser.AuthorizationDefinition{Type: "apiKey", PassAs: "header", Keyname: "X-API-Key"}, p.Listing.Authorizations["api_key"], "apiKey definition not parsed")
+	assert.Equal(t, &parser.AuthorizationDefinition{Type: "basicAuth"}, p.Listing.Authorizations["basic"], "basic definition not parsed")
+
+	assert.NotNil(t, p.ParseSecurityDefinition("@SecurityDefinition api_key apiKey cookie X-API-Key"), "apiKey can only be passed as header or query")
+	assert.NotNil(t, p.ParseSecurityDefinition("@SecurityDefinition api_key apiKey header"), "apiKey needs a key name")
+	assert.NotNil(t, p.ParseSecurityDefinition("@SecurityDefinition token jwt"), "Unsupported type should not be accepted")
+}
+
+func TestAddOperationTags(t *testing.T) {
+	p := parser.NewParser()
+	p.Listing.Tags = []parser.Tag{{Name: "users", Description: "User management"}}
+
+	op := parser.NewOperation(p, "test")
+	op.Path = "/users/{id}"
+	// inherited tags, followed by the explicit ones
+	op.Tags = []string{"users", "admin", "", "users", " admin"}
+	p.AddOperation(op)
+
+	op2 := parser.NewOperation(p, "test")
+	op2.Path = "/invoices"
+	op2.Tags = []string{"billing", "admin"}
+	p.AddOperation(op2)
+
+	assert.Equal(t, []string{"admin", "users"}, op.Tags, "Operation tags not deduplicated and sorted")
+	assert.Equal(t, []string{"admin", "billing"}, op2.Tags, "Operation tags not sorted")
+	assert.Equal(t, []parser.Tag{
+		{Name: "admin"},
+		{Name: "billing"},
+		{Name: "users", Description: "User management"},
+	}, p.Listing.Tags, "Operation tags not declared in the resource listing")
+
+	untagged := parser.NewOperation(p, "test")
+	untagged.Path = "/invoices/{id}/payments"
+	untagged.ForceResource = "payment"
+	p.AddOperation(untagged)
+	assert.Equal(t, []string{"payment"}, untagged.Tags, "Operation without tags not tagged with its resource")
+}
+
+func TestWriteApiDescriptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api-docs")
+	if err != nil {
+		t.Fatalf("Can not create API description directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := parser.NewParser()
+	p.Listing.ApiVersion = "1.0.0"
+	for _, operationPath := range []string{"/orders/{id}", "/customers"} {
+		op := parser.NewOperation(p, "test")
+		op.Path = operationPath
+		p.AddOperation(op)
+	}
+	op := parser.NewOperation(p, "test")
+	op.Path = "/v1/invoices"
+	op.ForceResource = "v1/invoices"
+	p.AddOperation(op)
+
+	err = p.WriteApiDescriptions(filepath.Join(dir, "spec"))
+	assert.Nil(t, err, "Can not write API descriptions")
+
+	var listing parser.ResourceListing
+	content, err := ioutil.ReadFile(filepath.Join(dir, "spec", parser.ResourceListingFileName))
+	assert.Nil(t, err, "Resource listing not written")
+	assert.Nil(t, json.Unmarshal(content, &listing), "Resource listing not written as JSON")
+	assert.Equal(t, "1.0.0", listing.ApiVersion, "Resource listing not written")
+	assert.Len(t, listing.Apis, 3, "Resource listing not written")
+
+	for resource, fileName := range map[string]string{"orders": "orders.json", "customers": "customers.json", "v1/invoices": "v1/invoices.json"} {
+		var api parser.ApiDeclaration
+		content, err := ioutil.ReadFile(filepath.Join(dir, "spec", filepath.FromSlash(fileName)))
+		assert.Nil(t, err, "Api declaration of %s not written", resource)
+		assert.Nil(t, json.Unmarshal(content, &api), "Api declaration of %s not written as JSON", resource)
+		assert.Equal(t, "/"+resource, api.ResourcePath, "Api declaration of %s not written", resource)
+	}
+
+	blocked := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(blocked, []byte{}, 0644); err != nil {
+		t.Fatalf("Can not write file: %v", err)
+	}
+	assert.NotNil(t, p.WriteApiDescriptions(blocked), "Writing to a file instead of a directory should fail")
+}
+
+func TestMergedApiDeclaration(t *testing.T) {
+	p := parser.NewParser()
+	p.Listing.ApiVersion = "1.0.0"
+	p.BasePath = "/api"
+	p.ParseTypeDefinitions(ExamplePackageName)
+	p.CurrentPackage = ExamplePackageName
+	parseOperation(t, p, `
+// @Title GetOrder
+// @Success 200 {object} SimpleStructure
+// @Router /orders/{id} [get]
+`)
+	parseOperation(t, p, `
+// @Title DeleteOrder
+// @Router /orders/{id} [delete]
+`)
+	parseOperation(t, p, `
+// @Title GetCustomers
+// @Produce xml
+// @Success 200 {array} subpackage.SimpleStructure
+// @Router /customers [get]
+`)
+
+	merged := p.MergedApiDeclaration()
+	assert.Equal(t, "/", merged.ResourcePath, "Merged api declaration should be of the root resource")
+	assert.Equal(t, "1.0.0", merged.ApiVersion, "Api version not set")
+	assert.Equal(t, "/api", merged.BasePath, "Base path not set")
+	if assert.Len(t, merged.Apis, 2, "Apis of every resource should be merged") {
+		assert.Equal(t, "/customers", merged.Apis[0].Path, "Apis should be sorted by path")
+		assert.Equal(t, "/orders/{id}", merged.Apis[1].Path, "Apis should be sorted by path")
+		if assert.Len(t, merged.Apis[1].Operations, 2, "Operations of an api should be kept") {
+			assert.Equal(t, "DELETE", merged.Apis[1].Operations[0].HttpMethod, "Operations should be sorted by http method")
+			assert.Equal(t, "GET", merged.Apis[1].Operations[1].HttpMethod, "Operations should be sorted by http method")
+		}
+	}
+	assert.Contains(t, merged.Models, exampleModelPrefix+"SimpleStructure", "Models of every resource should be merged")
+	assert.Contains(t, merged.Models, exampleModelPrefix+"subpackage.SimpleStructure", "Models of every resource should be merged")
+	assert.ElementsMatch(t, []string{parser.ContentTypeJson, parser.ContentTypeXml}, merged.Produces, "Content types of every resource should be merged")
+	assert.Len(t, p.TopLevelApis, 2, "Resources should be left as they are")
+
+	var api parser.ApiDeclaration
+	assert.Nil(t, json.Unmarshal(p.GetMergedApiJson(), &api), "Merged api declaration not serialised")
+	assert.Len(t, api.Apis, 2, "Merged api declaration not serialised")
+}
+
+func TestAddOperationAliases(t *testing.T) {
+	p := parser.NewParser()
+	op := parser.NewOperation(p, "test")
+	for _, line := range []string{
+		"// @Title GetUser",
+		"// @Param id path int true \"User ID\"",
+		"// @Success 200 {string} string",
+		"// @Router /users/{id} [get]",
+		"// @Router /people/{id} [get]",
+	} {
+		assert.Nil(t, op.ParseComment(line), "Can not parse operation comment")
+	}
+	assert.Equal(t, "/users/{id}", op.Path, "First route should be the path of the operation")
+	assert.Equal(t, []parser.Route{{Path: "/users/{id}", HttpMethod: "GET"}, {Path: "/people/{id}", HttpMethod: "GET"}}, op.Routes, "Routes not parsed")
+	p.AddOperation(op)
+
+	for resource, operationPath := range map[string]string{"users": "/users/{id}", "people": "/people/{id}"} {
+		api, ok := p.TopLevelApis[resource]
+		if !assert.True(t, ok, "Operation not added at %s", operationPath) {
+			continue
+		}
+		assert.Equal(t, operationPath, api.Apis[0].Path, "Operation not added at %s", operationPath)
+		alias := api.Apis[0].Operations[0]
+		assert.Equal(t, "GET", alias.HttpMethod, "Operation not added at %s", operationPath)
+		assert.Equal(t, "GetUser", alias.Nickname, "Aliases should share the operation metadata")
+		assert.Equal(t, op.Parameters, alias.Parameters, "Aliases should share the operation metadata")
+		assert.Equal(t, op.ResponseMessages, alias.ResponseMessages, "Aliases should share the operation metadata")
+	}
+	assert.Len(t, p.Listing.Apis, 2, "Both paths should be in the resource listing")
+}
+
+func TestRoutePrefix(t *testing.T) {
+	for _, prefix := range []string{"/api/v2", "api/v2/", "/api/v2/"} {
+		p := parser.NewParser()
+		p.RoutePrefix = prefix
+		op := parser.NewOperation(p, "test")
+		for _, line := range []string{"// @Router /orders/{id} [get]", "// @Router orders/{id}/items [get]"} {
+			assert.Nil(t, op.ParseComment(line), "Can not parse operation comment")
+		}
+		p.AddOperation(op)
+
+		api, ok := p.TopLevelApis["orders"]
+		if !assert.True(t, ok, "Resource should be derived from the path without the prefix %s", prefix) {
+			continue
+		}
+		assert.Len(t, p.TopLevelApis, 1, "Resource should be derived from the path without the prefix %s", prefix)
+		assert.Equal(t, "/orders", api.ResourcePath, "Resource should be derived from the path without the prefix %s", prefix)
+		paths := make([]string, 0)
+		for _, subApi := range api.Apis {
+			paths = append(paths, subApi.Path)
+		}
+		sort.Strings(paths)
+		assert.Equal(t, []string{"/api/v2/orders/{id}", "/api/v2/orders/{id}/items"}, paths, "Paths not prefixed with %s", prefix)
+	}
+
+	mounted := parser.NewParser()
+	mounted.RoutePrefix = "/api"
+	again := parser.NewOperation(mounted, "test")
+	assert.Nil(t, again.ParseComment("// @Router /orders/{id} [get]"), "Can not parse operation comment")
+	mounted.AddOperation(again)
+	mounted.AddOperation(again)
+	assert.Equal(t, "/api/orders/{id}", again.Path, "Operation added again should be prefixed once")
+	assert.Contains(t, mounted.TopLevelApis, "orders", "Operation added again should stay in its resource")
+	assert.Len(t, mounted.TopLevelApis, 1, "Operation added again should stay in its resource")
+
+	p := parser.NewParser()
+	op := parser.NewOperation(p, "test")
+	assert.Nil(t, op.ParseComment("// @Router /orders/{id} [get]"), "Can not parse operation comment")
+	p.AddOperation(op)
+	assert.Equal(t, "/orders/{id}", op.Path, "Path should be kept without a prefix")
+}
+
+func TestPathNormalizer(t *testing.T) {
+	assert.Equal(t, "/users/{id}/orders/{orderId}", parser.ColonPathParams("/users/:id/orders/:orderId"), "Colon params not rewritten")
+	assert.Equal(t, "/users/{id}/a:b/:", parser.ColonPathParams("/users/{id}/a:b/:"), "Only whole segments should be rewritten")
+
+	p := parser.NewParser()
+	p.PathNormalizer = parser.ColonPathParams
+	p.RoutePrefix = "/api"
+	op := parser.NewOperation(p, "test")
+	for _, line := range []string{"// @Router /:tenant/users/:id [get]", "// @Router /people/:id [get]"} {
+		assert.Nil(t, op.ParseComment(line), "Paths in the syntax of the router should be accepted")
+	}
+	p.AddOperation(op)
+
+	assert.Equal(t, "/api/{tenant}/users/{id}", op.Path, "Path not normalized")
+	_, ok := p.TopLevelApis["{tenant}"]
+	assert.True(t, ok, "Resource should be derived from the normalized path")
+	people, ok := p.TopLevelApis["people"]
+	if assert.True(t, ok, "Aliases should be normalized") {
+		assert.Equal(t, "/api/people/{id}", people.Apis[0].Path, "Aliases should be normalized")
+	}
+
+	assert.NotNil(t, parser.NewOperation(parser.NewParser(), "test").ParseComment("// @Router /users/:id [get]"), "Colon params should be rejected without a normalizer")
+}
+
+func TestResourceListingStability(t *testing.T) {
+	p := parser.NewParser()
+	p.ParseSubApiDescription("@SubApi Order management API [/orders]")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			op := parser.NewOperation(p, "test")
+			op.Summary = fmt.Sprintf("Operation %d", i)
+			for _, line := range []string{fmt.Sprintf("// @Router /%s/%d [get]", []string{"orders", "users", "carts"}[i%3], i)} {
+				assert.Nil(t, op.ParseComment(line), "Can not parse operation comment")
+			}
+			p.AddOperation(op)
+			// the listing is sorted and read while other operations are added
+			p.SortApiDescriptions()
+			p.Operations()
+		}(i)
+	}
+	wg.Wait()
+
+	p.SortApiDescriptions()
+	assert.Equal(t, []*parser.ApiRef{
+		{Path: "/carts"},
+		{Path: "/orders", Description: "Order management API"},
+		{Path: "/users"},
+	}, p.Listing.Apis, "Each resource should be listed once, without the summary of one of its operations")
+
+	op := parser.NewOperation(p, "test")
+	for _, line := range []string{"// @Resource /payment Payment processing", "// @Router /invoices/{id}/payments [get]"} {
+		assert.Nil(t, op.ParseComment(line), "Can not parse operation comment")
+	}
+	assert.Equal(t, "payment", op.ForceResource, "Resource not parsed")
+	assert.Equal(t, "Payment processing", op.ResourceDescription, "Resource description not parsed")
+	p.AddOperation(op)
+	other := parser.NewOperation(p, "test")
+	assert.Nil(t, other.ParseComment("// @Resource payment Other description"), "Can not parse operation comment")
+	p.AddOperation(other)
+	assert.Equal(t, "Payment processing", p.Listing.Apis[3].Description, "The first @Resource description should be kept")
+
+	p.ParseSubApiDescription("@SubApi Payment API [/payment]")
+	assert.Equal(t, "Payment API", p.Listing.Apis[3].Description, "The @SubApi description should take precedence")
+	assert.Len(t, p.Listing.Apis, 4, "Resources should not be listed twice")
+
+	assert.NotNil(t, op.ParseComment("// @Resource /"), "Resource comment without a name should fail")
+}
+
+func TestValidateOperationIds(t *testing.T) {
+	p := parser.NewParser()
+	for _, comment := range [][]string{
+		{"// @ID getUser", "// @Router /users/{id} [get]", "// @Router /people/{id} [get]"},
+		{"// @ID createUser", "// @Router /users [post]"},
+		{"// @ID createUser", "// @Router /admin/users [post]"},
+	} {
+		op := parser.NewOperation(p, "test")
+		for _, line := range comment {
+			assert.Nil(t, op.ParseComment(line), "Can not parse operation comment")
+		}
+		p.AddOperation(op)
+	}
+
+	assert.Equal(t, "getUser2", p.TopLevelApis["people"].Apis[0].Operations[0].OperationId, "Aliases should be numbered after the operation")
+	errs := p.Validate()
+	if assert.Len(t, errs, 1, "Duplicate operation ids should be reported") {
+		assert.Equal(t, "Operation id createUser is given to several operations: POST /admin/users, POST /users", errs[0].Error(), "Duplicate operation ids should be reported")
+	}
+}
+
+func TestValidateApi(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	source := `package orders
+
+type Order struct {
+	Id int
+}
+
+// @Success 200 {object} Missing
+// @Router /orders [get]
+func GetOrders() {}
+
+// @Param id path
+// @Success 200 {object} Order
+// @Router /orders/{id} [get]
+func GetOrder() {}
+
+// @Router /orders [fetch]
+func PatchOrders() {}
+
+// @ID GetOrders
+// @Router /archive [get]
+func GetArchive() {}
+`
+	dir := filepath.Join(gopath, "src", "example.com", "orders")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "orders.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Can not write package source: %v", err)
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	errs := p.ValidateApi("example.com/orders")
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	fileName := filepath.Join(dir, "orders.go")
+	if assert.Len(t, messages, 5, "Every problem should be reported") {
+		assert.Contains(t, messages[0], fileName+":7:1: Can not parse comment for function: GetOrders", "Missing model should be reported with its position")
+		assert.Contains(t, messages[0], "Can not find definition of Missing model", "Missing model should be reported")
+		assert.Contains(t, messages[1], fileName+":11:1: Can not parse comment for function: GetOrder", "Malformed @Param should be reported with its position")
+		assert.Contains(t, messages[2], fileName+":16:1: Can not parse comment for function: PatchOrders", "Malformed @Router should be reported with its position")
+		assert.Contains(t, messages[3], fileName+":17: Operation of handler PatchOrders skipped", "Operation without path should be reported with its position")
+		assert.Equal(t, "Operation id GetOrders is given to several operations: GET /archive ("+fileName+":21), GET /orders ("+fileName+":9)", messages[4], "Duplicate operation ids should be reported with their positions")
+	}
+	assert.Contains(t, p.TopLevelApis, "orders", "Operations should be parsed despite the problems")
+	assert.False(t, p.LibraryMode, "ValidateApi should not change the mode of the parser")
+}
+
+func TestMalformedModelSchema(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	source := `package money
+
+// @Schema {"type":"string"
+type Money struct {
+	Cents int64 ` + "`json:\"cents\"`" + `
+}
+
+// @Schema {"type":"string","format":"date-time"}
+type Timestamp struct {
+	Seconds int64
+}
+`
+	dir := filepath.Join(gopath, "src", "example.com", "money")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "money.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Can not write package source: %v", err)
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.LibraryMode = true
+	assert.Nil(t, p.ParseTypeDefinitions("example.com/money"), "Malformed schema should not stop the parsing")
+	if assert.Len(t, p.Warnings, 1, "Malformed schema should be collected") {
+		assert.Contains(t, p.Warnings[0].Error(), filepath.Join(dir, "money.go")+":3:1: Can not parse schema of Money", "Malformed schema should be reported with its type and position")
+	}
+
+	money := parser.NewModel(p)
+	err, _ = money.ParseModel("Money", "example.com/money", map[string]bool{})
+	assert.Nil(t, err, "Can not parse model with malformed schema")
+	assert.Contains(t, money.Properties, "cents", "Model with malformed schema should keep its fields")
+
+	timestamp := parser.NewModel(p)
+	err, _ = timestamp.ParseModel("Timestamp", "example.com/money", map[string]bool{})
+	assert.Nil(t, err, "Can not parse model with schema")
+	json, _ := json.Marshal(timestamp)
+	assert.Contains(t, string(json), `"id":"`+timestamp.Id+`"`, "Schema should keep the model id the references resolve to")
+	assert.Contains(t, string(json), `"format":"date-time"`, "Schema should be used verbatim")
+}
+
+func TestControllerMethods(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	source := `package shop
+
+type OrderController struct{}
+
+type Store[T any] struct{}
+
+// @Router /orders [get]
+func (c *OrderController) List() {}
+
+// @Router /orders/{id} [get]
+func (c OrderController) Get() {}
+
+// @Tags archive
+// @Router /orders/archive [get]
+func (c *OrderController) Archive() {}
+
+// @Resource stock
+// @Router /items [get]
+func (s *Store[T]) Items() {}
+
+// @Router /health [get]
+func Health() {}
+`
+	dir := filepath.Join(gopath, "src", "example.com", "shop")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "shop.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Can not write package source: %v", err)
+	}
+	t.Setenv("GOPATH", gopath)
+
+	receivers := map[string]string{"List": "OrderController", "Get": "OrderController", "Archive": "OrderController", "Items": "Store", "Health": ""}
+	p := parser.NewParser()
+	p.ParseApi("example.com/shop")
+	for _, op := range p.Operations() {
+		assert.Equal(t, receivers[op.Handler], op.Receiver, "Receiver of %s not parsed", op.Handler)
+	}
+	assert.Len(t, p.TopLevelApis["orders"].Apis, 3, "Methods should be grouped by their path by default")
+
+	p = parser.NewParser()
+	p.GroupByReceiver = true
+	p.ParseApi("example.com/shop")
+	resources := make([]string, 0)
+	for resource := range p.TopLevelApis {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	assert.Equal(t, []string{"health", "order", "stock"}, resources, "Methods should be grouped by their receiver")
+	tags := make(map[string][]string)
+	for _, op := range p.Operations() {
+		tags[op.Handler] = op.Tags
+	}
+	assert.Equal(t, []string{"order"}, tags["List"], "Methods should be tagged after their receiver")
+	assert.Equal(t, []string{"order"}, tags["Get"], "Methods with a value receiver should be tagged after it")
+	assert.Equal(t, []string{"archive"}, tags["Archive"], "@Tags should override the receiver")
+	assert.Equal(t, []string{"stock"}, tags["Items"], "@Resource should override the receiver")
+	assert.Equal(t, []string{"health"}, tags["Health"], "Functions should be grouped by their path")
+
+	assert.Equal(t, "order", parser.ReceiverResource("OrderController"))
+	assert.Equal(t, "userAccount", parser.ReceiverResource("UserAccountHandler"))
+	assert.Equal(t, "controller", parser.ReceiverResource("Controller"))
+}
+
+func TestSortApiDescriptions(t *testing.T) {
+	p := parser.NewParser()
+	for _, router := range []string{
+		"// @Router /users/{id} [put]",
+		"// @Router /orders [get]",
+		"// @Router /users/{id} [delete]",
+		"// @Router /users [post]",
+		"// @Router /users/{id} [get]",
+	} {
+		op := parser.NewOperation(p, "test")
+		assert.Nil(t, op.ParseComment(router), "Can not parse router comment")
+		p.AddOperation(op)
+	}
+	p.SortApiDescriptions()
+
+	assert.Equal(t, "/orders", p.Listing.Apis[0].Path, "Resources not sorted by path")
+	assert.Equal(t, "/users", p.Listing.Apis[1].Path, "Resources not sorted by path")
+	users := p.TopLevelApis["users"]
+	assert.Equal(t, "/users", users.Apis[0].Path, "Apis not sorted by path")
+	assert.Equal(t, "/users/{id}", users.Apis[1].Path, "Apis not sorted by path")
+	methods := []string{}
+	for _, op := range users.Apis[1].Operations {
+		methods = append(methods, op.HttpMethod)
+	}
+	assert.Equal(t, []string{"DELETE", "GET", "PUT"}, methods, "Operations not sorted by http method")
+
+	// the files of a package are parsed in the same order on every run as well
+	parse := func() ([]byte, []byte) {
+		p := parser.NewParser()
+		p.BasePath = exampleBasePath
+		p.ParseApi(ExamplePackageName)
+		return p.GetResourceListingJson(), p.GetApiDescriptionJson()
+	}
+	listing, apiDescription := parse()
+	for i := 0; i < 3; i++ {
+		otherListing, otherApiDescription := parse()
+		assert.Equal(t, string(listing), string(otherListing), "Resource listing differs between runs")
+		assert.Equal(t, string(apiDescription), string(otherApiDescription), "API description differs between runs")
+	}
+}
+
+func TestReset(t *testing.T) {
+	p := parser.NewParser()
+	p.BasePath = exampleBasePath
+	p.MaxScanDepth = 2
+	p.TypeMappings["decimal.Decimal"] = parser.TypeMapping{Type: "string"}
+	p.TypesImplementingMarshalInterface["database/sql.NullString"] = "string"
+	assert.Nil(t, p.ParseGeneralAPIInfoFromSrc([]byte("// @APIVersion 1.0.0\n// @Schemes https\n// @Accept xml\n// @Produce xml\npackage main\n")), "Can not parse general API info")
+	p.ParseApi(ExamplePackageName)
+	assert.NotEmpty(t, p.TopLevelApis, "Can not parse API")
+	money := p.CheckRealPackagePath(ExamplePackageName) + ".Money"
+	assert.True(t, p.IsImplementMarshalInterface(money), "Can not find the MarshalJSON methods")
+
+	p.Reset()
+	assert.Equal(t, parser.NewParser().Listing, p.Listing, "Resource listing not reset")
+	for name, state := range map[string]int{
+		"TopLevelApis":     len(p.TopLevelApis),
+		"PackagesCache":    len(p.PackagesCache),
+		"TypeDefinitions":  len(p.TypeDefinitions),
+		"EnumValues":       len(p.EnumValues),
+		"PackagePathCache": len(p.PackagePathCache),
+		"PackageImports":   len(p.PackageImports),
+		"ModelSchemas":     len(p.ModelSchemas),
+		"Schemes":          len(p.Schemes),
+		"Consumes":         len(p.Consumes),
+		"Produces":         len(p.Produces),
+	} {
+		assert.Equal(t, 0, state, "%s not reset", name)
+	}
+	assert.Equal(t, exampleBasePath, p.BasePath, "Configuration should be kept")
+	assert.Equal(t, 2, p.MaxScanDepth, "Configuration should be kept")
+	assert.Contains(t, p.TypeMappings, "decimal.Decimal", "Configuration should be kept")
+	assert.Equal(t, map[string]string{"database/sql.NullString": "string"}, p.TypesImplementingMarshalInterface, "Configuration should be kept")
+	assert.False(t, p.IsImplementMarshalInterface(money), "MarshalJSON methods found by parsing not reset")
+
+	// the parser can be reused
+	p.ParseApi(ExamplePackageName)
+	assert.NotEmpty(t, p.TopLevelApis, "Can not parse API again after reset")
+	assert.NotEmpty(t, p.TypeDefinitions, "Can not parse API again after reset")
+
+	// a base path from @BasePath is parsed again, the next one may differ
+	annotated := parser.NewParser()
+	assert.Nil(t, annotated.ParseGeneralAPIInfoFromSrc([]byte("// @BasePath /v1\npackage main\n")), "Can not parse general API info")
+	annotated.Reset()
+	assert.Equal(t, "", annotated.BasePath, "Base path from @BasePath not reset")
+	assert.Nil(t, annotated.ParseGeneralAPIInfoFromSrc([]byte("// @BasePath /v2\npackage main\n")), "Can not parse general API info")
+	assert.Equal(t, "/v2", annotated.BasePath, "Base path from @BasePath not parsed again after reset")
+}
+
+func TestTypeMappingsOfRenamedImports(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	writeSource := func(name string, source string) {
+		dir := filepath.Join(gopath, "src", "example.com/mapped", name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".go"), []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	writeSource("clock", "package clock\n\ntype Time struct {\n\tHour int\n}\n")
+	writeSource("models", `package models
+
+import (
+	. "encoding/json"
+	t "time"
+	c "example.com/mapped/clock"
+)
+
+type Event struct {
+	At      t.Time
+	Payload RawMessage
+	Tick    c.Time
+}
+`)
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.TypeMappings["example.com/mapped/clock.Time"] = parser.TypeMapping{Type: "string", Format: "time"}
+	p.ParseTypeDefinitions("example.com/mapped/models")
+	m := parser.NewModel(p)
+	err, innerModels := m.ParseModel("Event", "example.com/mapped/models", map[string]bool{})
+	if assert.Nil(t, err, "Can not parse Event definition") {
+		assert.Equal(t, "string", m.Properties["At"].Type, "Aliased import of a mapped type should be mapped")
+		assert.Equal(t, "date-time", m.Properties["At"].Format, "Aliased import of a mapped type should be mapped")
+		assert.Equal(t, "object", m.Properties["Payload"].Type, "Dot import of a mapped type should be mapped")
+		assert.Equal(t, "time", m.Properties["Tick"].Format, "Type mapped by the import path of its package should be mapped")
+		assert.Empty(t, innerModels, "Mapped types should not be models")
+	}
+}
+
+func TestReparsePackage(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	writeSource := func(name string, source string) {
+		dir := filepath.Join(gopath, "src", "example.com/watch", name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".go"), []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	api := `package api
+
+import "example.com/watch/models"
+
+// @Success 200 {object} models.User
+// @Router /users/{id} [get]
+func GetUser() models.User { return models.User{} }
+`
+	writeSource("models", "package models\n\ntype User struct {\n\tName string\n}\n")
+	writeSource("api", api)
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.ParseApi("example.com/watch/api")
+	modelId := "example.com.watch.models.User"
+	if assert.Contains(t, p.TopLevelApis, "users", "Can not parse API") {
+		assert.NotContains(t, p.TopLevelApis["users"].Models[modelId].Properties, "Email", "Can not parse API")
+	}
+
+	// a changed model package is parsed again, along with the operations using its models
+	writeSource("models", "package models\n\ntype User struct {\n\tName  string\n\tEmail string\n}\n")
+	assert.Nil(t, p.ReparsePackage("example.com/watch/models"), "Can not reparse package")
+	if assert.Contains(t, p.TopLevelApis, "users", "Operations of dependent package lost") {
+		assert.Contains(t, p.TopLevelApis["users"].Models[modelId].Properties, "Email", "Changed model not parsed again")
+		assert.Len(t, p.TopLevelApis["users"].Apis, 1, "Operations of dependent package should be replaced")
+		assert.Len(t, p.TopLevelApis["users"].Apis[0].Operations, 1, "Operations of dependent package should be replaced")
+	}
+
+	// removed operations are gone, added ones are there
+	writeSource("api", strings.Replace(api, "/users/{id}", "/people/{id}", 1))
+	assert.Nil(t, p.ReparsePackage("example.com/watch/api"), "Can not reparse package")
+	assert.NotContains(t, p.TopLevelApis, "users", "Removed operation should be gone")
+	assert.Contains(t, p.TopLevelApis, "people", "Added operation not parsed")
+	assert.Len(t, p.Listing.Apis, 1, "Resource listing should only list the parsed resources")
+
+	p.InvalidatePackage("example.com/watch/models")
+	modelsPath := filepath.Join(gopath, "src", "example.com/watch/models")
+	if evaluated, err := filepath.EvalSymlinks(modelsPath); err == nil {
+		modelsPath = evaluated
+	}
+	assert.NotContains(t, p.TypeDefinitions, modelsPath, "Type definitions of invalidated package should be dropped")
+	assert.NotContains(t, p.PackagesCache, modelsPath, "Syntax tree of invalidated package should be dropped")
+	assert.NotContains(t, p.PackageImports, modelsPath, "Imports of invalidated package should be dropped")
+}
+
+func TestScanPackagesIgnoreDirs(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	for _, dir := range []string{"api/v1", "vendor/example.com/lib", "Godeps/_workspace", "mocks"} {
+		if err := os.MkdirAll(filepath.Join(gopath, "src", "example.com/svc", dir), 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.IgnoreDirs = append(p.IgnoreDirs, "mocks")
+	packages := p.ScanPackages([]string{"example.com/svc"})
+
+	assert.Equal(t, []string{"example.com/svc", "example.com/svc/api", "example.com/svc/api/v1"}, packages, "Ignored directories should not be scanned")
+}
+
+func TestScanPackagesExclusions(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	for _, dir := range []string{"api/v1", "api/v2", "internal/db/migrations", "internal/auth", "cmd/server"} {
+		if err := os.MkdirAll(filepath.Join(gopath, "src", "example.com/svc", dir), 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	for _, test := range []struct {
+		patterns []string
+		packages []string
+	}{
+		{[]string{"example.com/svc/api/...", "example.com/svc/internal/..."}, []string{"example.com/svc/api", "example.com/svc/api/v1", "example.com/svc/api/v2",
+			"example.com/svc/internal", "example.com/svc/internal/auth", "example.com/svc/internal/db", "example.com/svc/internal/db/migrations"}},
+		{[]string{"example.com/svc/...", "-example.com/svc/internal/...", "-example.com/svc/cmd/..."}, []string{"example.com/svc", "example.com/svc/api", "example.com/svc/api/v1", "example.com/svc/api/v2"}},
+		// without "/...", only the package itself is excluded, not the packages below it
+		{[]string{"example.com/svc/internal/...", "-example.com/svc/internal/db"}, []string{"example.com/svc/internal", "example.com/svc/internal/auth", "example.com/svc/internal/db/migrations"}},
+		{[]string{"example.com/svc/internal", "-example.com/svc/internal"}, []string{"example.com/svc/internal/auth", "example.com/svc/internal/db", "example.com/svc/internal/db/migrations"}},
+		// an exclusion applies below every package given, whatever their order
+		{[]string{"-example.com/svc/api/v2/...", "example.com/svc/api", "example.com/svc/api/v1"}, []string{"example.com/svc/api", "example.com/svc/api/v1"}},
+		{[]string{"example.com/svc/api/...", "-example.com/svc/api/..."}, []string{}},
+		{[]string{"example.com/svc/api", "-example.com/svc/apis/..."}, []string{"example.com/svc/api", "example.com/svc/api/v1", "example.com/svc/api/v2"}},
+	} {
+		assert.Equal(t, test.packages, p.ScanPackages(test.patterns), "Packages not selected by %v", test.patterns)
+	}
+}
+
+func TestScanPackagesMultipleGopaths(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	// the name of the package is a substring of the directory of the second GOPATH entry
+	secondGopath, err := ioutil.TempDir("", "api-gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(secondGopath)
+
+	for _, dir := range []string{"src/example.com/svc", filepath.Join(secondGopath, "src/api/v1/api"), filepath.Join(secondGopath, "src/example.com/svc/api")} {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(gopath, dir)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", strings.Join([]string{gopath, secondGopath}, string(filepath.ListSeparator)))
+
+	p := parser.NewParser()
+	assert.Equal(t, []string{"api", "api/v1", "api/v1/api"}, p.ScanPackages([]string{"api"}), "Nested packages of the second GOPATH entry not found")
+	assert.Equal(t, []string{"example.com/svc"}, p.ScanPackages([]string{"example.com/svc"}), "Package should be scanned in the first GOPATH entry it is found in")
+}
+
+func TestParseTypeDefinitionsCyclicImports(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	sources := map[string]string{
+		"a": "package a\n\nimport \"example.com/cycle/b\"\n\ntype A struct {\n\tB *b.B\n}\n",
+		"b": "package b\n\nimport \"example.com/cycle/a\"\n\ntype B struct {\n\tA *a.A\n}\n",
+	}
+	for name, source := range sources {
+		dir := filepath.Join(gopath, "src", "example.com/cycle", name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".go"), []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.ParseTypeDefinitions("example.com/cycle/a")
+	aPath := p.GetRealPackagePath("example.com/cycle/a")
+	bPath := p.GetRealPackagePath("example.com/cycle/b")
+	assert.Contains(t, p.TypeDefinitions[aPath], "A", "Types of the parsed package not found")
+	assert.Contains(t, p.TypeDefinitions[bPath], "B", "Types of the cyclically imported package not found")
+
+	// Both packages are parsed already, so parsing them again must not redo the work
+	delete(p.TypeDefinitions[bPath], "B")
+	p.ParseTypeDefinitions("example.com/cycle/a")
+	p.ParseTypeDefinitions("example.com/cycle/b")
+	assert.NotContains(t, p.TypeDefinitions[bPath], "B", "Package should be parsed exactly once")
+}
+
+func TestLibraryMode(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	sources := map[string]string{
+		"missingmodel": "package missingmodel\n\n// @Cacheable soon\n// @Success 200 {object} Missing\n// @Router /orders [get]\nfunc GetOrders() {}\n",
+		"syntaxerror":  "package syntaxerror\n\nfunc Broken( {\n",
+	}
+	for name, source := range sources {
+		dir := filepath.Join(gopath, "src", "example.com/faulty", name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".go"), []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	for _, packageName := range []string{"example.com/faulty/missingmodel", "example.com/faulty/syntaxerror", "example.com/faulty/nothere"} {
+		p := parser.NewParser()
+		p.LibraryMode = true
+		err := p.ParseApiContext(context.Background(), packageName)
+		assert.IsType(t, &parser.FatalError{}, err, "Failure parsing %s should be returned", packageName)
+	}
+
+	p := parser.NewParser()
+	p.LibraryMode = true
+	err = p.ParseApiContext(context.Background(), "example.com/faulty/missingmodel")
+	assert.Contains(t, err.Error(), "Can not find definition of Missing model", "Failure should be returned")
+	assert.Len(t, p.Warnings, 1, "Failure parsing goes on after should be collected")
+	assert.Contains(t, p.Warnings[0].Error(), "Can not parse cacheable comment", "Failure parsing goes on after should be collected")
+	assert.NotNil(t, p.GetResourceListingJson(), "Resource listing should still be serialised")
+
+	// the exported methods called on their own do not terminate the process nor panic either
+	p = parser.NewParser()
+	p.LibraryMode = true
+	assert.IsType(t, &parser.FatalError{}, p.ParseTypeDefinitions("example.com/faulty/nothere"), "Failure parsing type definitions should be returned")
+	assert.IsType(t, &parser.FatalError{}, p.ParseApiDescription("example.com/faulty/syntaxerror"), "Failure parsing operations should be returned")
+	op := parser.NewOperation(p, "example.com/faulty/missingmodel")
+	assert.IsType(t, &parser.FatalError{}, op.ParseComment("// @Success 200 {object} Missing"), "Failure parsing an operation comment should be returned")
+	assert.Empty(t, p.Warnings, "Failures returned should not be collected")
+
+	assert.Empty(t, p.GetRealPackagePath("example.com/faulty/nothere"), "Package path should be empty on failure")
+	assert.Nil(t, p.GetPackageAst(filepath.Join(gopath, "src", "example.com/faulty/syntaxerror")), "Package should be nil on failure")
+	typeSpec, _ := p.FindModelDefinition("Missing", "example.com/faulty/missingmodel")
+	assert.Nil(t, typeSpec, "Model should be nil on failure")
+	if assert.Len(t, p.Warnings, 3, "Failures of methods without an error result should be collected") {
+		assert.Contains(t, p.Warnings[0].Error(), "Can not find package example.com/faulty/nothere", "Failure should be collected")
+		assert.Contains(t, p.Warnings[2].Error(), "Can not find definition of Missing model", "Failure should be collected")
+	}
+
+	assert.NotPanics(t, func() {
+		p.Swagger20()
+		p.OpenAPI3()
+		p.GetMergedApiJson()
+	}, "Specs of a failed parse should still be built")
+
+	t.Setenv("GOPATH", "")
+	assert.Empty(t, p.CheckRealPackagePath("example.com/faulty/elsewhere"), "Package path should be empty on failure")
+	assert.Contains(t, p.Warnings[len(p.Warnings)-1].Error(), "Please, set $GOPATH", "Failure should be collected")
+
+	p = parser.NewParser()
+	p.LibraryMode = true
+	p.ParseApi("example.com/faulty/nothere")
+	if assert.Len(t, p.Warnings, 1, "Failure of ParseApi should be collected") {
+		assert.Contains(t, p.Warnings[0].Error(), "Can not parse API", "Failure of ParseApi should be collected")
+	}
+}
+
+func TestUnresolvableImports(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	sources := map[string]string{
+		"orders": "package orders\n\nimport _ \"example.com/buildonly\"\n\ntype Order struct {\n\tId int\n}\n\n" +
+			"// @Success 200 {object} Order\n// @Router /orders [get]\nfunc GetOrders() {}\n",
+		"shims": "package shims\n\nimport \"example.com/cshim\"\n\nvar _ = cshim.Init\n\n" +
+			"// @Success 200 {object} cshim.Handle\n// @Router /shims [get]\nfunc GetShims() {}\n",
+	}
+	for name, source := range sources {
+		dir := filepath.Join(gopath, "src", "example.com", name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".go"), []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.LibraryMode = true
+	assert.Nil(t, p.ParseApiContext(context.Background(), "example.com/orders"), "Unresolvable import should not fail the parse")
+	assert.Contains(t, p.TopLevelApis, "orders", "Operations should be parsed despite an unresolvable import")
+	if assert.Len(t, p.Warnings, 1, "Unresolvable import should be warned about") {
+		assert.Contains(t, p.Warnings[0].Error(), "Can not find package example.com/buildonly imported by example.com/orders", "Unresolvable import should be warned about")
+	}
+
+	p = parser.NewParser()
+	p.LibraryMode = true
+	err = p.ParseApiContext(context.Background(), "example.com/shims")
+	if assert.IsType(t, &parser.FatalError{}, err, "Model of an unresolvable import should fail the parse") {
+		assert.Contains(t, err.Error(), "Can not find definition of Handle model, package example.com/cshim imported by example.com/shims can not be found", "Failure should name the package")
+	}
+}
+
+func TestStandardPackagePaths(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	// a package of the same path below $GOPATH/src does not hide the standard library
+	if err := os.MkdirAll(filepath.Join(gopath, "src", "net", "url"), 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	t.Setenv("GOPATH", gopath)
+
+	goroot, err := filepath.EvalSymlinks(build.Default.GOROOT)
+	if err != nil {
+		t.Fatalf("Can not find GOROOT: %v", err)
+	}
+	p := parser.NewParser()
+	for _, packagePath := range []string{"net/url", "math/big"} {
+		assert.Equal(t, filepath.Join(goroot, "src", filepath.FromSlash(packagePath)), p.CheckRealPackagePath(packagePath), "Package %s should be found in GOROOT", packagePath)
+	}
+	assert.True(t, parser.IsStandardPackage("math/big"), "math/big is a standard package")
+	assert.False(t, parser.IsStandardPackage("github.com/myuser/myproject"), "A package of a domain is not a standard package")
+	assert.False(t, parser.IsStandardPackage("./api"), "A local package is not a standard package")
+
+	// a toolchain reached through a symbolic link, given in a $GOROOT of several entries
+	toolchains, err := ioutil.TempDir("", "toolchains")
+	if err != nil {
+		t.Fatalf("Can not create toolchains directory: %v", err)
+	}
+	defer os.RemoveAll(toolchains)
+	toolchains, _ = filepath.EvalSymlinks(toolchains)
+	if err := os.MkdirAll(filepath.Join(toolchains, "go1.99", "src", "fakestd"), 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(toolchains, "go1.99"), filepath.Join(toolchains, "current")); err != nil {
+		t.Skipf("Can not create symbolic link: %v", err)
+	}
+	t.Setenv("GOROOT", strings.Join([]string{filepath.Join(toolchains, "missing"), filepath.Join(toolchains, "current")}, string(filepath.ListSeparator)))
+	assert.Equal(t, filepath.Join(toolchains, "go1.99", "src", "fakestd"), parser.NewParser().CheckRealPackagePath("fakestd"), "Package should be found in the toolchain linked from $GOROOT")
+}
+
+func TestLocalPackagePaths(t *testing.T) {
+	root, err := ioutil.TempDir("", "local")
+	if err != nil {
+		t.Fatalf("Can not create directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+	root, _ = filepath.EvalSymlinks(root)
+	sources := map[string]string{
+		"shop/go.mod": "module example.com/shop // the shop\n\ngo 1.21\n",
+		"shop/internal/api/orders.go": "package api\n\nimport \"example.com/shop/models\"\n\nvar _ models.Order\n\n" +
+			"// @Success 200 {object} models.Order\n// @Router /orders [get]\nfunc GetOrders() {}\n",
+		"shop/internal/api/admin/users.go":        "package admin\n\n// @Router /users [get]\nfunc GetUsers() {}\n",
+		"shop/models/order.go":                    "package models\n\ntype Order struct {\n\tId int\n}\n",
+		"gopath/src/example.com/legacy/legacy.go": "package legacy\n\n// @Router /legacy [get]\nfunc GetLegacy() {}\n",
+	}
+	for name, source := range sources {
+		fileName := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+		if err := ioutil.WriteFile(fileName, []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", filepath.Join(root, "gopath"))
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(filepath.Join(root, "shop")); err != nil {
+		t.Fatalf("Can not change directory: %v", err)
+	}
+
+	assert.True(t, parser.IsLocalPackagePath("./internal/api"))
+	assert.True(t, parser.IsLocalPackagePath(".."))
+	assert.False(t, parser.IsLocalPackagePath("example.com/shop/internal/api"))
+
+	p := parser.NewParser()
+	p.LibraryMode = true
+	assert.Equal(t, []string{"example.com/shop/internal/api", "example.com/shop/internal/api/admin"}, p.ScanPackages([]string{"./internal/api/..."}), "Local package should be scanned by its import path")
+	assert.Equal(t, filepath.Join(root, "shop", "models"), p.CheckRealPackagePath("example.com/shop/models"), "Packages of the module should be found in its directory")
+
+	assert.Nil(t, p.ParseApiContext(context.Background(), "./internal/api,../gopath/src/example.com/legacy"), "Can not parse local packages")
+	assert.Contains(t, p.TopLevelApis, "orders", "Operations of a local package not parsed")
+	assert.Contains(t, p.TopLevelApis, "users", "Operations of a package below a local package not parsed")
+	assert.Contains(t, p.TopLevelApis, "legacy", "Operations of a local package below $GOPATH/src not parsed")
+	assert.Contains(t, p.TopLevelApis["orders"].Models, "example.com.shop.models.Order", "Model imported from the module not parsed")
+}
+
+func TestAliasedAndDotImports(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	sources := map[string]string{
+		"models": "package models\n\ntype User struct {\n\tId int\n}\n",
+		"orders": "package orders\n\ntype Status string\n\nconst (\n\tStatusOpen Status = \"open\"\n\tStatusClosed Status = \"closed\"\n)\n\n" +
+			"type Order struct {\n\tId int\n\tStatus Status\n}\n",
+		"api": "package api\n\nimport (\n\tm \"example.com/models\"\n\t. \"example.com/orders\"\n)\n\n" +
+			"type Cart struct {\n\tOwner m.User\n\tOrders []Order\n\tStatus Status\n}\n\n" +
+			"// @Param user body m.User true \"The user\"\n// @Success 200 {array} Order\n// @Router /orders [post]\nfunc CreateOrders(user m.User) []Order { return nil }\n\n" +
+			"// @Success 200 {object} Cart\n// @Router /cart [get]\nfunc GetCart() {}\n",
+	}
+	for name, source := range sources {
+		dir := filepath.Join(gopath, "src", "example.com", name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".go"), []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.LibraryMode = true
+	assert.Nil(t, p.ParseApiContext(context.Background(), "example.com/api"), "Can not parse aliased and dot imports")
+	assert.Equal(t, "example.com/models", p.PackageImports[p.CheckRealPackagePath("example.com/api")]["m"], "Aliased import should be keyed by its alias")
+
+	ordersApi := p.TopLevelApis["orders"]
+	if assert.NotNil(t, ordersApi, "Operation not parsed") {
+		op := ordersApi.Apis[0].Operations[0]
+		assert.Equal(t, "example.com.models.User", op.Parameters[0].Type, "Model of an aliased import should be resolved")
+		assert.Equal(t, "example.com.orders.Order", op.Items.Ref, "Model of a dot import should be resolved")
+		assert.Contains(t, ordersApi.Models, "example.com.models.User", "Model of an aliased import should be added")
+		assert.Contains(t, ordersApi.Models, "example.com.orders.Order", "Model of a dot import should be added")
+	}
+	cartApi := p.TopLevelApis["cart"]
+	if assert.NotNil(t, cartApi, "Operation not parsed") {
+		cart := cartApi.Models["example.com.api.Cart"]
+		if assert.NotNil(t, cart, "Model not parsed") {
+			assert.Equal(t, "example.com.models.User", cart.Properties["Owner"].Type, "Field of an aliased import should reference its model")
+			assert.Equal(t, "example.com.orders.Order", cart.Properties["Orders"].Items.Ref, "Field of a dot import should reference its model")
+			assert.Equal(t, []string{"open", "closed"}, cart.Properties["Status"].Enum, "Enum of a dot import should be resolved")
+		}
+	}
+}
+
+func TestVersionedImportPaths(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	sources := map[string]string{
+		"example.com/org/proj/models":         "package models\n\ntype User struct {\n\tId int\n}\n",
+		"example.com/org/proj/models/v2":      "package models\n\ntype User struct {\n\tId string\n}\n",
+		"gopkg.in/yaml.v2":                    "package yaml\n\ntype Node struct {\n\tValue string\n}\n",
+		"example.com/org/proj/internal/a/b/c": "package c\n\ntype Deep struct {\n\tLevel int\n}\n",
+		"example.com/org/proj/api": "package api\n\nimport (\n\t\"example.com/org/proj/models/v2\"\n\t\"gopkg.in/yaml.v2\"\n)\n\n" +
+			"var _ models.User\nvar _ yaml.Node\n",
+	}
+	for packagePath, source := range sources {
+		dir := filepath.Join(gopath, "src", packagePath)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "types.go"), []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.ParseTypeDefinitions("example.com/org/proj/api")
+	for modelName, expectedPackage := range map[string]string{
+		"example.com/org/proj/models/v2.User":      "example.com/org/proj/models/v2",
+		"example.com.org.proj.models.v2.User":      "example.com/org/proj/models/v2",
+		"example.com/org/proj/models.User":         "example.com/org/proj/models",
+		"example.com.org.proj.models.User":         "example.com/org/proj/models",
+		"gopkg.in/yaml.v2.Node":                    "gopkg.in/yaml.v2",
+		"gopkg.in.yaml.v2.Node":                    "gopkg.in/yaml.v2",
+		"example.com.org.proj.internal.a.b.c.Deep": "example.com/org/proj/internal/a/b/c",
+		"models.User":                              "example.com/org/proj/models/v2",
+		"yaml.Node":                                "gopkg.in/yaml.v2",
+	} {
+		model, modelPackage := p.FindModelDefinition(modelName, "example.com/org/proj/api")
+		if assert.NotNil(t, model, "Can not find model %s", modelName) {
+			assert.Equal(t, expectedPackage, modelPackage, "Model %s found in the wrong package", modelName)
+		}
+	}
+
+	assert.Equal(t, "models", parser.ImportName("example.com/org/proj/models/v2"), "Major version should not name the import")
+	assert.Equal(t, "yaml", parser.ImportName("gopkg.in/yaml.v2"), "Major version should not name the import")
+	assert.Equal(t, "users", parser.ImportName("example.com/users"), "Last segment should name the import")
+}
+
+func TestWalkOperations(t *testing.T) {
+	p := parser.NewParser()
+	p.ParseApi(ExamplePackageName)
+
+	operations := p.Operations()
+	if assert.NotEmpty(t, operations, "Operations not returned") {
+		for i := 1; i < len(operations); i++ {
+			assert.True(t, operations[i-1].Path <= operations[i].Path, "Operations not sorted by path")
+		}
+	}
+
+	visited := 0
+	err := p.WalkOperations(func(op *parser.Operation) error {
+		visited++
+		return op.ParseComment(`// @Failure 500 {object} TreeNode "Internal error"`)
+	})
+	assert.Nil(t, err, "Can not walk operations")
+	assert.Equal(t, len(operations), visited, "Every operation should be visited")
+	treeNodeId := "github.com.RobotsAndPencils.go-swaggerLite.example.TreeNode"
+	for _, api := range p.TopLevelApis {
+		assert.Contains(t, api.Models, treeNodeId, "Models of the added responses should be added to the resources")
+	}
+	lastResponse := operations[0].ResponseMessages[len(operations[0].ResponseMessages)-1]
+	assert.Equal(t, 500, lastResponse.Code, "Response not added to the operation")
+	assert.Contains(t, string(p.GetApiDescriptionJson()), `"message": "Internal error"`, "Added response not serialised")
+
+	visited = 0
+	err = p.WalkOperations(func(op *parser.Operation) error {
+		visited++
+		return op.ParseComment("// @Failure oops")
+	})
+	assert.NotNil(t, err, "Error of the visit should be returned")
+	assert.Equal(t, 1, visited, "Walk should stop at the first error")
+}
+
+func TestLogger(t *testing.T) {
+	var trace bytes.Buffer
+	p := parser.NewParser()
+	p.Logger = log.New(&trace, "", 0)
+	p.ParseTypeDefinitions(ExamplePackageName)
+	m := parser.NewModel(p)
+	err, _ := m.ParseModel("subpackage.SimpleStructure", ExamplePackageName, map[string]bool{})
+	assert.Nil(t, err, "Can not parse model")
+
+	for _, message := range []string{
+		"Parse type definitions of " + ExamplePackageName,
+		"Package " + ExamplePackageName + " resolved to ",
+		"Parse model subpackage.SimpleStructure of package " + ExamplePackageName,
+		"Model subpackage.SimpleStructure of package " + ExamplePackageName + " found in package " + ExamplePackageName + "/subpackage",
+	} {
+		assert.Contains(t, trace.String(), message, "Parsing not traced")
+	}
+}
+
+func TestBuildContext(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	dir := filepath.Join(gopath, "src", "example.com/platform")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	sources := map[string]string{
+		"common.go":     "package platform\n\ntype Common struct{}\n",
+		"os_linux.go":   "package platform\n\ntype Settings struct {\n\tHome string\n}\n",
+		"os_windows.go": "package platform\n\ntype Settings struct {\n\tProfile string\n}\n",
+		"enterprise.go": "//go:build enterprise\n\npackage platform\n\ntype License struct{}\n",
+		"legacy.go":     "// +build ignore\n\npackage platform\n\ntype Common struct {\n\tLegacy bool\n}\n",
+	}
+	for name, source := range sources {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	settingsField := func(p *parser.Parser) string {
+		p.ParseTypeDefinitions("example.com/platform")
+		settings := p.GetModelDefinition("Settings", "example.com/platform")
+		if settings == nil {
+			return ""
+		}
+		return settings.Type.(*ast.StructType).Fields.List[0].Names[0].Name
+	}
+
+	windows := build.Default
+	windows.GOOS = "windows"
+	windows.BuildTags = []string{"enterprise"}
+	p := parser.NewParser()
+	p.BuildContext = &windows
+	assert.Equal(t, "Profile", settingsField(p), "Only the files of the GOOS should be parsed")
+	assert.NotNil(t, p.GetModelDefinition("License", "example.com/platform"), "Files of the build tags should be parsed")
+	assert.Len(t, p.GetModelDefinition("Common", "example.com/platform").Type.(*ast.StructType).Fields.List, 0, "Ignored files should not be parsed")
+
+	linux := build.Default
+	linux.GOOS = "linux"
+	p = parser.NewParser()
+	p.BuildContext = &linux
+	assert.Equal(t, "Home", settingsField(p), "Only the files of the GOOS should be parsed")
+	assert.Nil(t, p.GetModelDefinition("License", "example.com/platform"), "Files of other build tags should not be parsed")
+}
+
+func TestFileFilter(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	dir := filepath.Join(gopath, "src", "example.com/fixtures")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	sources := map[string]string{
+		"fixtures.go":      "package fixtures\n\ntype Order struct{}\n",
+		"fixtures_test.go": "package fixtures\n\ntype OrderFixture struct{}\n",
+		"orders_gen.go":    "package fixtures\n\ntype GeneratedOrder struct{}\n",
+	}
+	for name, source := range sources {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.ParseTypeDefinitions("example.com/fixtures")
+	assert.NotNil(t, p.GetModelDefinition("GeneratedOrder", "example.com/fixtures"), "Go files should be parsed by default")
+	assert.Nil(t, p.GetModelDefinition("OrderFixture", "example.com/fixtures"), "Tests should not be parsed by default")
+
+	p = parser.NewParser()
+	p.FileFilter = func(info os.FileInfo) bool {
+		return strings.HasSuffix(info.Name(), ".go") && !strings.HasSuffix(info.Name(), "_gen.go")
+	}
+	p.ParseTypeDefinitions("example.com/fixtures")
+	assert.NotNil(t, p.GetModelDefinition("OrderFixture", "example.com/fixtures"), "Files of the FileFilter should be parsed")
+	assert.Nil(t, p.GetModelDefinition("GeneratedOrder", "example.com/fixtures"), "Files the FileFilter rejects should not be parsed")
+}
+
+func TestDeclarationCache(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	dir := filepath.Join(gopath, "src", "example.com/cached")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	source := "package cached\n\n// Order is cached\ntype Order struct {\n\tId int\n}\n\n" +
+		"// @Router /orders [get]\nfunc GetOrders() {\n\t// @SubApi Orders API [/orders]\n\tprintln(\"/* orders */\")\n}\n"
+	fileName := filepath.Join(dir, "cached.go")
+	if err := ioutil.WriteFile(fileName, []byte(source), 0644); err != nil {
+		t.Fatalf("Can not write package source: %v", err)
+	}
+	t.Setenv("GOPATH", gopath)
+	cacheDir := filepath.Join(gopath, "cache")
+
+	parse := func() (*parser.Parser, string) {
+		var trace bytes.Buffer
+		p := parser.NewParser()
+		p.Logger = log.New(&trace, "", 0)
+		assert.Nil(t, p.EnableDeclarationCache(cacheDir), "Can not enable declaration cache")
+		p.ParseApi("example.com/cached")
+		return p, trace.String()
+	}
+
+	_, trace := parse()
+	assert.NotContains(t, trace, "loaded from the declaration cache", "Package should be parsed on the first run")
+	p, trace := parse()
+	assert.Contains(t, trace, "Package "+dir+" loaded from the declaration cache", "Package should be loaded from the declaration cache")
+	order := p.GetModelDefinition("Order", "example.com/cached")
+	if assert.NotNil(t, order, "Types should be loaded from the declaration cache") {
+		assert.Equal(t, "Order is cached\n", order.Doc.Text(), "Doc comments should be loaded from the declaration cache")
+	}
+	assert.Equal(t, "Orders API", p.Listing.Apis[0].Description, "Comments in functions should be loaded from the declaration cache")
+
+	if err := ioutil.WriteFile(fileName, []byte(strings.Replace(source, "Order struct", "Invoice struct", 1)), 0644); err != nil {
+		t.Fatalf("Can not write package source: %v", err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(fileName, future, future)
+	p, trace = parse()
+	assert.NotContains(t, trace, "loaded from the declaration cache", "Package should be parsed again once its files changed")
+	assert.NotNil(t, p.GetModelDefinition("Invoice", "example.com/cached"), "Package should be parsed again once its files changed")
+}
+
+// BenchmarkDeclarationCache compares parsing the whole source of a package, this one, with parsing the
+// declarations the cache keeps of it
+func BenchmarkDeclarationCache(b *testing.B) {
+	packagePath, err := filepath.Abs(".")
+	if err != nil {
+		b.Fatalf("Can not find package directory: %v", err)
+	}
+	cacheDir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		b.Fatalf("Can not create cache directory: %v", err)
+	}
+	defer os.RemoveAll(cacheDir)
+	b.Run("Source", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			parser.NewParser().GetPackageAst(packagePath)
+		}
+	})
+	b.Run("Declarations", func(b *testing.B) {
+		newParser := func() *parser.Parser {
+			p := parser.NewParser()
+			if err := p.EnableDeclarationCache(cacheDir); err != nil {
+				b.Fatalf("Can not enable declaration cache: %v", err)
+			}
+			return p
+		}
+		newParser().GetPackageAst(packagePath)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			newParser().GetPackageAst(packagePath)
+		}
+	})
+}
+
+func TestParseApiFile(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	dir := filepath.Join(gopath, "src", "example.com/shop")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	sources := map[string]string{
+		"orders.go": "package shop\n\ntype Order struct {\n\tId int\n}\n\n" +
+			"// @Success 200 {object} Order\n// @Router /orders/{id} [get]\nfunc GetOrder() {}\n",
+		"users.go": "package shop\n\n// @Router /users [get]\nfunc GetUsers() {}\n",
+	}
+	for name, source := range sources {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	assert.Nil(t, p.ParseApiFile(filepath.Join(dir, "orders.go")), "Can not parse API file")
+	assert.NotContains(t, p.TopLevelApis, "users", "Only the controllers of the file should be parsed")
+	if assert.Contains(t, p.TopLevelApis, "orders", "Can not parse API file") {
+		assert.Contains(t, p.TopLevelApis["orders"].Models, "example.com.shop.Order", "Models of the file's package should be resolved")
+	}
+
+	source := strings.Replace(sources["orders.go"], "/orders/{id} [get]", "/orders/{id} [delete]", 1)
+	if err := ioutil.WriteFile(filepath.Join(dir, "orders.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Can not write package source: %v", err)
+	}
+	assert.Nil(t, p.ParseApiFile(filepath.Join(dir, "orders.go")), "Can not parse API file again")
+	if assert.Len(t, p.TopLevelApis["orders"].Apis, 1, "Operations of the file should be replaced") {
+		operations := p.TopLevelApis["orders"].Apis[0].Operations
+		if assert.Len(t, operations, 1, "Operations of the file should be replaced") {
+			assert.Equal(t, "DELETE", operations[0].HttpMethod, "Operations of the file should be replaced")
+		}
+	}
+
+	assert.NotNil(t, p.ParseApiFile(filepath.Join(dir, "missing.go")), "Missing file should not be parsed")
+	outside, err := ioutil.TempFile("", "outside*.go")
+	if err != nil {
+		t.Fatalf("Can not create file: %v", err)
+	}
+	defer os.Remove(outside.Name())
+	outside.Close()
+	assert.NotNil(t, p.ParseApiFile(outside.Name()), "File outside of $GOPATH should not be parsed")
+}
+
+func TestParseApiFileOfModule(t *testing.T) {
+	root, err := ioutil.TempDir("", "module")
+	if err != nil {
+		t.Fatalf("Can not create directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+	root, _ = filepath.EvalSymlinks(root)
+	sources := map[string]string{
+		"go.mod": "module example.com/shop\n\ngo 1.21\n",
+		"api/orders.go": "package api\n\nimport \"example.com/shop/models\"\n\nvar _ models.Order\n\n" +
+			"// @Success 200 {object} models.Order\n// @Router /orders/{id} [get]\nfunc GetOrder() {}\n",
+		"api/users.go":    "package api\n\n// @Router /users [get]\nfunc GetUsers() {}\n",
+		"models/order.go": "package models\n\ntype Order struct {\n\tId int\n}\n",
+	}
+	for name, source := range sources {
+		fileName := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+		if err := ioutil.WriteFile(fileName, []byte(source), 0644); err != nil {
+			t.Fatalf("Can not write package source: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", filepath.Join(root, "gopath"))
+
+	p := parser.NewParser()
+	assert.Nil(t, p.ParseApiFile(filepath.Join(root, "api", "orders.go")), "Can not parse API file of a module package")
+	assert.NotContains(t, p.TopLevelApis, "users", "Only the controllers of the file should be parsed")
+	if assert.Contains(t, p.TopLevelApis, "orders", "Can not parse API file of a module package") {
+		assert.Contains(t, p.TopLevelApis["orders"].Models, "example.com.shop.models.Order", "Models of the packages of the module should be resolved")
+	}
+}
+
+func TestScanPackagesPrunedDirs(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	for _, dir := range []string{"api/v1/internal", ".git/objects", "web/node_modules/lib", "api/testdata"} {
+		if err := os.MkdirAll(filepath.Join(gopath, "src", "example.com/svc", dir), 0755); err != nil {
+			t.Fatalf("Can not create package directory: %v", err)
+		}
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	packages := p.ScanPackages([]string{"example.com/svc"})
+	assert.Equal(t, []string{"example.com/svc", "example.com/svc/api", "example.com/svc/api/v1", "example.com/svc/api/v1/internal", "example.com/svc/web"}, packages, "Pruned directories should not be scanned")
+
+	p2 := parser.NewParser()
+	p2.MaxScanDepth = 2
+	packages2 := p2.ScanPackages([]string{"example.com/svc"})
+	assert.Equal(t, []string{"example.com/svc", "example.com/svc/api", "example.com/svc/api/v1", "example.com/svc/web"}, packages2, "Packages deeper than MaxScanDepth should not be scanned")
+}
+
+func TestHasRouterAnnotation(t *testing.T) {
+	src := `package example
+
+// @Title GetOrder
+// @Router /orders/{id} [get]
+func GetOrder() {}
+
+// @Route /orders [post]
+func CreateOrder() {}
+
+// Helper is not a controller
+func Helper() {}
+
+func Undocumented() {}
+`
+	fileTree, err := goparser.ParseFile(token.NewFileSet(), "", src, goparser.ParseComments)
+	if err != nil {
+		t.Fatalf("Can not parse source: %v", err)
+	}
+
+	expected := map[string]bool{"GetOrder": true, "CreateOrder": true, "Helper": false, "Undocumented": false}
+	for _, decl := range fileTree.Decls {
+		funcDeclaration := decl.(*ast.FuncDecl)
+		assert.Equal(t, expected[funcDeclaration.Name.Name], parser.HasRouterAnnotation(funcDeclaration), "Controller not detected: %s", funcDeclaration.Name.Name)
+	}
+
+	p := parser.NewParser()
+	assert.NotNil(t, p.IsController, "Parser should have a default IsController")
+	p.IsController = IsController
+	p.UseRouterAnnotationDetection()
+	assert.True(t, p.IsController(fileTree.Decls[0].(*ast.FuncDecl)), "Can not restore the default IsController")
+}
+
+func TestGoSwaggerControllers(t *testing.T) {
+	src := `package example
+
+// swagger:route GET /pets pets listPets
+func ListPets() {}
+
+// swagger:operation POST /pets pets createPet
+func CreatePet() {}
+
+// @Router /pets/{id} [get]
+func GetPet() {}
+`
+	fileTree, err := goparser.ParseFile(token.NewFileSet(), "", src, goparser.ParseComments)
+	if err != nil {
+		t.Fatalf("Can not parse source: %v", err)
+	}
+
+	p := parser.NewParser()
+	assert.False(t, p.IsController(fileTree.Decls[0].(*ast.FuncDecl)), "go-swagger routes should only be detected with GoSwaggerAnnotations")
+	p.GoSwaggerAnnotations = true
+	for _, decl := range fileTree.Decls {
+		funcDeclaration := decl.(*ast.FuncDecl)
+		assert.True(t, p.IsController(funcDeclaration), "Controller not detected: %s", funcDeclaration.Name.Name)
+	}
+}
+
 func TestParserSuite(t *testing.T) {
 	suite.Run(t, &ParserSuite{})
 }
+
+func TestAnnotationErrorPositions(t *testing.T) {
+	err := parser.NewParser().ParseGeneralAPIInfoFromSrc([]byte("// @APIVersion 1.0.0\n//\n// @Schemes ftp\npackage main\n"))
+	assert.NotNil(t, err, "Unknown scheme should fail")
+	assert.True(t, strings.HasPrefix(err.Error(), "3:1: Can not parse schemes comment"), "Failure should give the line of the comment: %v", err)
+
+	gopath, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("Can not create GOPATH: %v", err)
+	}
+	defer os.RemoveAll(gopath)
+	dir := filepath.Join(gopath, "src", "example.com/faulty/orders")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Can not create package directory: %v", err)
+	}
+	source := "package orders\n\n// @Title GetOrders\n\t// @Cacheable soon\n// @Router /orders [get]\nfunc GetOrders() {}\n"
+	fileName := filepath.Join(dir, "orders.go")
+	if err := ioutil.WriteFile(fileName, []byte(source), 0644); err != nil {
+		t.Fatalf("Can not write package source: %v", err)
+	}
+	mainFileName := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(mainFileName, []byte("// @APIVersion 1.0.0\n//\n// @Schemes ftp\npackage orders\n"), 0644); err != nil {
+		t.Fatalf("Can not write main API file: %v", err)
+	}
+	t.Setenv("GOPATH", gopath)
+
+	p := parser.NewParser()
+	p.LibraryMode = true
+	err = p.ParseGeneralAPIInfo(mainFileName)
+	if assert.NotNil(t, err, "Unknown scheme should fail") {
+		assert.True(t, strings.HasPrefix(err.Error(), mainFileName+":3:1: Can not parse schemes comment"), "Failure should start with the file, line and column of the comment: %v", err)
+	}
+	assert.Nil(t, p.ParseApiContext(context.Background(), "example.com/faulty/orders"), "Can not parse API")
+	if assert.Len(t, p.Warnings, 1, "Failure parsing the operation should be collected") {
+		assert.True(t, strings.HasPrefix(p.Warnings[0].Error(), fileName+":4:2: Can not parse comment for function: GetOrders"), "Failure should start with the file, line and column of the comment: %v", p.Warnings[0])
+	}
+}
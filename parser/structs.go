@@ -1,15 +1,19 @@
 package parser
 
 import (
+	"encoding/json"
 	"errors"
 )
 
-const SwaggerVersion = "1.2"
+const SwaggerVersion = "1.2" // the default version of the resource listing and the api declarations
 const (
-	ContentTypeJson  = "application/json"
-	ContentTypeXml   = "application/xml"
-	ContentTypePlain = "text/plain"
-	ContentTypeHtml  = "text/html"
+	ContentTypeJson        = "application/json"
+	ContentTypeXml         = "application/xml"
+	ContentTypePlain       = "text/plain"
+	ContentTypeHtml        = "text/html"
+	ContentTypeForm        = "application/x-www-form-urlencoded"
+	ContentTypeMultipart   = "multipart/form-data"
+	ContentTypeOctetStream = "application/octet-stream"
 )
 
 var CommentIsEmptyError = errors.New("Comment is empty")
@@ -18,8 +22,33 @@ type ResourceListing struct {
 	ApiVersion     string `json:"apiVersion"`
 	SwaggerVersion string `json:"swaggerVersion"`
 	//	BasePath       string     `json:"basePath"`
-	Apis  []*ApiRef  `json:"apis"`
-	Infos Infomation `json:"info"`
+	Apis           []*ApiRef                           `json:"apis"`
+	Infos          Infomation                          `json:"info"`
+	Authorizations map[string]*AuthorizationDefinition `json:"authorizations,omitempty"`
+	Tags           []Tag                               `json:"tags,omitempty"`
+	ExternalDocs   *ExternalDocs                       `json:"x-externalDocs,omitempty"`
+	Extensions     Extensions                          `json:"-"` // of the API, from the general API info
+}
+
+// MarshalJSON writes the vendor extensions of the API as fields of the resource listing
+func (listing *ResourceListing) MarshalJSON() ([]byte, error) {
+	type resourceListing ResourceListing
+	object, err := json.Marshal((*resourceListing)(listing))
+	if err != nil {
+		return nil, err
+	}
+	return marshalWithExtensions(object, listing.Extensions)
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ExternalDocs links to documentation of the API or of an operation outside of the spec, from @ExternalDocs
+type ExternalDocs struct {
+	Description string `json:"description,omitempty"`
+	Url         string `json:"url"`
 }
 
 type ApiRef struct {
@@ -52,22 +81,53 @@ type Protocol struct {
 }
 
 type ResponseMessage struct {
-	Code          int    `json:"code"`
-	Message       string `json:"message"`
-	ResponseModel string `json:"responseModel"`
+	Code          int                       `json:"code"`
+	Message       string                    `json:"message"`
+	ResponseModel string                    `json:"responseModel"`
+	Headers       map[string]ResponseHeader `json:"headers,omitempty"`
+	Composition
+	isArray     bool              // of a {array} response, whose model is the type of its items
+	ContentType string            `json:"-"` // the media type of the model, if given, e.g. "application/xml"
+	Variants    []ResponseMessage `json:"-"` // the same response in other media types, with their own models
+	// the name of the @GlobalResponse the response is, which Swagger 2.0 and OpenAPI 3.0 reference
+	GlobalRef string `json:"-"`
+}
+
+// Composition lists the ids of the models a schema is composed of
+type Composition struct {
+	AllOf []string `json:"allOf,omitempty"`
+	OneOf []string `json:"oneOf,omitempty"`
+	AnyOf []string `json:"anyOf,omitempty"`
+}
+
+type ResponseHeader struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
 }
 
 type Parameter struct {
-	ParamType     string `json:"paramType"` // path,query,body,header,form
-	Name          string `json:"name"`
-	Description   string `json:"description"`
-	DataType      string `json:"dataType"` // 1.2 needed?
-	Type          string `json:"type"`     // integer
-	Format        string `json:"format"`   // int64
-	AllowMultiple bool   `json:"allowMultiple"`
-	Required      bool   `json:"required"`
-	Minimum       int    `json:"minimum"`
-	Maximum       int    `json:"maximum"`
+	ParamType     string          `json:"paramType"` // path,query,body,header,form
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	DataType      string          `json:"dataType"` // 1.2 needed?
+	Type          string          `json:"type"`     // integer
+	Format        string          `json:"format"`   // int64
+	AllowMultiple bool            `json:"allowMultiple"`
+	Required      bool            `json:"required"`
+	Minimum       *float64        `json:"minimum,omitempty"`   // numeric types only
+	Maximum       *float64        `json:"maximum,omitempty"`   // numeric types only
+	MinLength     *int            `json:"minLength,omitempty"` // strings only
+	MaxLength     *int            `json:"maxLength,omitempty"` // strings only
+	Pattern       string          `json:"pattern,omitempty"`   // strings only
+	Enum          []string        `json:"enum,omitempty"`
+	Default       interface{}     `json:"defaultValue,omitempty"`
+	Example       interface{}     `json:"example,omitempty"`
+	Items         *OperationItems `json:"items,omitempty"` // only set when Type is "array"
+	// how the values of an array are serialized, e.g. csv for ?ids=1,2,3 or multi for ?ids=1&ids=2
+	CollectionFormat string `json:"x-collectionFormat,omitempty"`
+	Composition
+	// the name of the @Parameter the param is reused from, which Swagger 2.0 and OpenAPI 3.0 reference
+	Ref string `json:"-"`
 }
 
 type ErrorResponse struct {
@@ -75,10 +135,17 @@ type ErrorResponse struct {
 	Reason string `json:"reason"`
 }
 
-// https://github.com/wordnik/swagger-core/wiki/authorizations
-type Authorization struct {
-	LocalOAuth OAuth  `json:"local-oauth"`
-	ApiKey     ApiKey `json:"apiKey"`
+// https://github.com/wordnik/swagger-spec/blob/master/versions/1.2.md#514-authorization-object
+type AuthorizationDefinition struct {
+	Type    string `json:"type"`              // basicAuth or apiKey
+	PassAs  string `json:"passAs,omitempty"`  // header or query, apiKey only
+	Keyname string `json:"keyname,omitempty"` // e.g. X-API-Key, apiKey only
+}
+
+// https://github.com/wordnik/swagger-spec/blob/master/versions/1.2.md#515-scope-object
+type AuthorizationScope struct {
+	Scope       string `json:"scope"`
+	Description string `json:"description,omitempty"`
 }
 
 // https://github.com/wordnik/swagger-core/wiki/authorizations
@@ -0,0 +1,737 @@
+package parser
+
+import (
+	"encoding/json"
+	"go/ast"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Swagger20Version is the version of the specs built by Swagger20
+const Swagger20Version = "2.0"
+
+// https://github.com/OAI/OpenAPI-Specification/blob/main/versions/2.0.md#swagger-object
+type Swagger20Spec struct {
+	Swagger             string                                    `json:"swagger"`
+	Info                SpecInfo                                  `json:"info"`
+	Host                string                                    `json:"host,omitempty"`
+	BasePath            string                                    `json:"basePath,omitempty"`
+	Schemes             []string                                  `json:"schemes,omitempty"`
+	Paths               map[string]map[string]*Swagger20Operation `json:"paths"`
+	Definitions         map[string]*Schema                        `json:"definitions,omitempty"`
+	Parameters          map[string]*Swagger20Parameter            `json:"parameters,omitempty"`
+	Responses           map[string]*Swagger20Response             `json:"responses,omitempty"`
+	SecurityDefinitions map[string]*SecurityScheme                `json:"securityDefinitions,omitempty"`
+	Tags                []Tag                                     `json:"tags,omitempty"`
+	ExternalDocs        *ExternalDocs                             `json:"externalDocs,omitempty"`
+	Extensions          Extensions                                `json:"-"`
+}
+
+func (spec *Swagger20Spec) MarshalJSON() ([]byte, error) {
+	type swagger20Spec Swagger20Spec
+	object, err := json.Marshal((*swagger20Spec)(spec))
+	if err != nil {
+		return nil, err
+	}
+	return marshalWithExtensions(object, spec.Extensions)
+}
+
+// SpecInfo is the info object of Swagger 2.0 and OpenAPI 3.0 specs
+type SpecInfo struct {
+	Title          string       `json:"title"`
+	Description    string       `json:"description,omitempty"`
+	TermsOfService string       `json:"termsOfService,omitempty"`
+	Contact        *SpecContact `json:"contact,omitempty"`
+	License        *SpecLicense `json:"license,omitempty"`
+	Version        string       `json:"version"`
+}
+
+type SpecContact struct {
+	Email string `json:"email,omitempty"`
+}
+
+type SpecLicense struct {
+	Name string `json:"name"`
+	Url  string `json:"url,omitempty"`
+}
+
+// SecurityScheme is a security definition of Swagger 2.0, or a security scheme of OpenAPI 3.0
+type SecurityScheme struct {
+	Type   string `json:"type"`             // basic or apiKey, OpenAPI 3.0: http or apiKey
+	Scheme string `json:"scheme,omitempty"` // basic, OpenAPI 3.0 http only
+	In     string `json:"in,omitempty"`     // header or query, apiKey only
+	Name   string `json:"name,omitempty"`   // e.g. X-API-Key, apiKey only
+}
+
+// Schema is the JSON schema of a model, property or param, as Swagger 2.0 and OpenAPI 3.0 describe them
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Example              interface{}        `json:"example,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	XML                  *XMLObject         `json:"xml,omitempty"`
+	EnumVarNames         []string           `json:"x-enum-varnames,omitempty"`
+	Nullable             bool               `json:"x-nullable,omitempty"`
+	OpenAPINullable      bool               `json:"nullable,omitempty"` // Nullable, as OpenAPI 3.0 writes it
+	Tags                 []string           `json:"x-tags,omitempty"`
+	Discriminator        interface{}        `json:"discriminator,omitempty"` // the property name, an *OpenAPI3Discriminator in OpenAPI 3.0
+}
+
+// https://github.com/OAI/OpenAPI-Specification/blob/main/versions/2.0.md#operation-object
+type Swagger20Operation struct {
+	Tags         []string                      `json:"tags,omitempty"`
+	Summary      string                        `json:"summary,omitempty"`
+	Description  string                        `json:"description,omitempty"`
+	OperationId  string                        `json:"operationId,omitempty"`
+	Consumes     []string                      `json:"consumes,omitempty"`
+	Produces     []string                      `json:"produces,omitempty"`
+	Parameters   []*Swagger20Parameter         `json:"parameters,omitempty"`
+	Responses    map[string]*Swagger20Response `json:"responses"`
+	Deprecated   bool                          `json:"deprecated,omitempty"`
+	Security     []map[string][]string         `json:"security,omitempty"`
+	Sunset       string                        `json:"x-sunset,omitempty"`
+	Idempotent   bool                          `json:"x-idempotent,omitempty"`
+	Cacheable    string                        `json:"x-cacheable,omitempty"`
+	ExternalDocs *ExternalDocs                 `json:"externalDocs,omitempty"`
+	Extensions   Extensions                    `json:"-"`
+}
+
+func (operation *Swagger20Operation) MarshalJSON() ([]byte, error) {
+	type swagger20Operation Swagger20Operation
+	object, err := json.Marshal((*swagger20Operation)(operation))
+	if err != nil {
+		return nil, err
+	}
+	return marshalWithExtensions(object, operation.Extensions)
+}
+
+// https://github.com/OAI/OpenAPI-Specification/blob/main/versions/2.0.md#parameter-object
+type Swagger20Parameter struct {
+	Name             string        `json:"name"`
+	In               string        `json:"in"` // path, query, header, body or formData
+	Description      string        `json:"description,omitempty"`
+	Required         bool          `json:"required"`
+	Schema           *Schema       `json:"schema,omitempty"` // body only, the other fields describe the others
+	Type             string        `json:"type,omitempty"`
+	Format           string        `json:"format,omitempty"`
+	Items            *Schema       `json:"items,omitempty"`
+	CollectionFormat string        `json:"collectionFormat,omitempty"`
+	Enum             []interface{} `json:"enum,omitempty"`
+	Default          interface{}   `json:"default,omitempty"`
+	Example          interface{}   `json:"x-example,omitempty"` // Swagger 2.0 has examples of body params only
+	Minimum          *float64      `json:"minimum,omitempty"`
+	Maximum          *float64      `json:"maximum,omitempty"`
+	MinLength        *int          `json:"minLength,omitempty"`
+	MaxLength        *int          `json:"maxLength,omitempty"`
+	Pattern          string        `json:"pattern,omitempty"`
+	Ref              string        `json:"-"` // of a reused param, which is written as the reference alone
+}
+
+func (param *Swagger20Parameter) MarshalJSON() ([]byte, error) {
+	if param.Ref != "" {
+		return json.Marshal(map[string]string{"$ref": param.Ref})
+	}
+	type parameter Swagger20Parameter
+	return json.Marshal((*parameter)(param))
+}
+
+// https://github.com/OAI/OpenAPI-Specification/blob/main/versions/2.0.md#response-object
+type Swagger20Response struct {
+	Description string             `json:"description"`
+	Schema      *Schema            `json:"schema,omitempty"`
+	Headers     map[string]*Schema `json:"headers,omitempty"`
+	Ref         string             `json:"-"` // of a global response, which is written as the reference alone
+}
+
+func (response *Swagger20Response) MarshalJSON() ([]byte, error) {
+	if response.Ref != "" {
+		return json.Marshal(map[string]string{"$ref": response.Ref})
+	}
+	type swagger20Response Swagger20Response
+	return json.Marshal((*swagger20Response)(response))
+}
+
+// specLocation splits the BasePath, which is the URL of the API in Swagger 1.2, e.g. "http://127.0.0.1:3000/api",
+// into the host, the base path starting with "/" and the schemes of Swagger 2.0. The @Schemes of the general API
+// info take precedence over the scheme of the URL
+func (parser *Parser) specLocation() (host string, basePath string, schemes []string) {
+	schemes = parser.Schemes
+	basePath = parser.BasePath
+	if strings.Contains(basePath, "://") {
+		if baseUrl, err := url.Parse(basePath); err == nil {
+			host, basePath = baseUrl.Host, baseUrl.Path
+			if len(schemes) == 0 && baseUrl.Scheme != "" {
+				schemes = []string{strings.ToLower(baseUrl.Scheme)}
+			}
+		}
+	}
+	if host != "" && basePath == "" {
+		basePath = "/"
+	}
+	if basePath != "" && !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	return host, basePath, schemes
+}
+
+// Swagger20 builds a single Swagger 2.0 spec of the parsed API, its resource listing and the api declarations
+// of all resources. The models the operations reference are its definitions, see referencedSchemas
+func (parser *Parser) Swagger20() *Swagger20Spec {
+	parser.SortApiDescriptions()
+	const refPrefix = "#/definitions/"
+
+	host, basePath, schemes := parser.specLocation()
+	spec := &Swagger20Spec{
+		Swagger:      parser.SpecVersion(FormatSwagger20),
+		Info:         parser.specInfo(),
+		Host:         host,
+		BasePath:     basePath,
+		Schemes:      schemes,
+		Paths:        make(map[string]map[string]*Swagger20Operation),
+		Definitions:  parser.specSchemas(refPrefix),
+		Tags:         parser.Listing.Tags,
+		ExternalDocs: parser.Listing.ExternalDocs,
+		Extensions:   parser.Listing.Extensions,
+	}
+	for name, definition := range parser.Listing.Authorizations {
+		if spec.SecurityDefinitions == nil {
+			spec.SecurityDefinitions = make(map[string]*SecurityScheme)
+		}
+		scheme := &SecurityScheme{Type: definition.Type, In: definition.PassAs, Name: definition.Keyname}
+		if definition.Type == "basicAuth" {
+			scheme.Type = "basic"
+		}
+		spec.SecurityDefinitions[name] = scheme
+	}
+	for name, param := range parser.ReusableParams {
+		if spec.Parameters == nil {
+			spec.Parameters = make(map[string]*Swagger20Parameter)
+		}
+		spec.Parameters[name] = swagger20Parameter(param, refPrefix)
+	}
+
+	// the global responses the operations have are defined once, see addGlobalResponses
+	for _, globalResponse := range parser.GlobalResponses {
+		if global := parser.globalResponseOperations[globalResponse.Name]; global != nil {
+			if spec.Responses == nil {
+				spec.Responses = make(map[string]*Swagger20Response)
+			}
+			spec.Responses[globalResponse.Name] = global.swagger20Response(global.ResponseMessages[0], refPrefix)
+		}
+	}
+
+	for _, op := range parser.Operations() {
+		operation := &Swagger20Operation{
+			Tags:         op.Tags,
+			Summary:      op.Summary,
+			Description:  op.Notes,
+			OperationId:  op.specOperationId(),
+			Consumes:     op.Consumes,
+			Produces:     op.Produces,
+			Responses:    make(map[string]*Swagger20Response),
+			Deprecated:   op.Deprecated != "",
+			Security:     op.specSecurity(),
+			Sunset:       op.Sunset,
+			Idempotent:   op.Idempotent,
+			Cacheable:    op.Cacheable,
+			ExternalDocs: op.ExternalDocs,
+			Extensions:   op.Extensions,
+		}
+		for _, param := range op.Parameters {
+			if param.Ref != "" {
+				operation.Parameters = append(operation.Parameters, &Swagger20Parameter{Ref: "#/parameters/" + param.Ref})
+				continue
+			}
+			operation.Parameters = append(operation.Parameters, swagger20Parameter(param, refPrefix))
+		}
+		for _, response := range op.ResponseMessages {
+			if _, ok := spec.Responses[response.GlobalRef]; ok && response.GlobalRef != "" {
+				operation.Responses[strconv.Itoa(response.Code)] = &Swagger20Response{Ref: "#/responses/" + response.GlobalRef}
+				continue
+			}
+			operation.Responses[strconv.Itoa(response.Code)] = op.swagger20Response(response, refPrefix)
+		}
+
+		if _, ok := spec.Paths[op.Path]; !ok {
+			spec.Paths[op.Path] = make(map[string]*Swagger20Operation)
+		}
+		spec.Paths[op.Path][strings.ToLower(op.HttpMethod)] = operation
+	}
+	spec.Definitions = parser.referencedSchemas(spec.Definitions, refPrefix, spec.operationSchemas())
+	return spec
+}
+
+func (operation *Operation) swagger20Response(response ResponseMessage, refPrefix string) *Swagger20Response {
+	swaggerResponse := &Swagger20Response{
+		Description: response.description(),
+		Schema:      operation.responseSchema(response, refPrefix),
+	}
+	for name, header := range response.Headers {
+		if swaggerResponse.Headers == nil {
+			swaggerResponse.Headers = make(map[string]*Schema)
+		}
+		headerSchema := typeSchema(header.Type, refPrefix)
+		headerSchema.Description = header.Description
+		swaggerResponse.Headers[name] = headerSchema
+	}
+	return swaggerResponse
+}
+
+func swagger20Parameter(param Parameter, refPrefix string) *Swagger20Parameter {
+	swaggerParam := &Swagger20Parameter{
+		Name:        param.Name,
+		In:          param.ParamType,
+		Description: param.Description,
+		Required:    param.Required || param.ParamType == "path",
+	}
+	schema := param.schema(refPrefix)
+	switch param.ParamType {
+	case "body":
+		swaggerParam.Schema = schema
+		return swaggerParam
+	case "form":
+		swaggerParam.In = "formData"
+	}
+	swaggerParam.Type = schema.Type
+	swaggerParam.Format = schema.Format
+	swaggerParam.Items = schema.Items
+	swaggerParam.CollectionFormat = param.CollectionFormat
+	swaggerParam.Enum = schema.Enum
+	swaggerParam.Default = schema.Default
+	swaggerParam.Example = schema.Example
+	swaggerParam.Minimum = schema.Minimum
+	swaggerParam.Maximum = schema.Maximum
+	swaggerParam.MinLength = schema.MinLength
+	swaggerParam.MaxLength = schema.MaxLength
+	swaggerParam.Pattern = schema.Pattern
+	return swaggerParam
+}
+
+// specInfo is the info object of the resource listing
+func (parser *Parser) specInfo() SpecInfo {
+	infos := parser.Listing.Infos
+	info := SpecInfo{
+		Title:          infos.Title,
+		Description:    infos.Description,
+		TermsOfService: infos.TermsOfServiceUrl,
+		Version:        parser.Listing.ApiVersion,
+	}
+	if infos.Contact != "" {
+		info.Contact = &SpecContact{Email: infos.Contact}
+	}
+	if infos.License != "" {
+		info.License = &SpecLicense{Name: infos.License, Url: infos.LicenseUrl}
+	}
+	return info
+}
+
+// specSchemas returns the schemas of the models of every resource, by model id
+func (parser *Parser) specSchemas(refPrefix string) map[string]*Schema {
+	schemas := make(map[string]*Schema)
+	var baseModels []*Model
+	// a resource of operations producing XML has a copy of the model with its xml objects, which is defined then
+	models := make(map[string]*Model)
+	for _, api := range parser.TopLevelApis {
+		for id, model := range api.Models {
+			if kept, ok := models[id]; !ok || model.xmlEnabled && !kept.xmlEnabled {
+				models[id] = model
+			}
+		}
+	}
+	if parser.IncludeAllModels {
+		for _, model := range parser.scannedModels() {
+			if _, ok := models[model.Id]; !ok {
+				models[model.Id] = model
+			}
+		}
+	}
+	for id, model := range models {
+		schemas[id] = parser.modelSchema(model, refPrefix)
+		if len(model.SubTypes) > 0 {
+			baseModels = append(baseModels, model)
+		}
+	}
+	// the subtypes of a model with a discriminator are composed of it
+	sort.Slice(baseModels, func(i, j int) bool { return baseModels[i].Id < baseModels[j].Id })
+	for _, baseModel := range baseModels {
+		for _, subTypeId := range baseModel.SubTypes {
+			if schema, ok := schemas[subTypeId]; ok {
+				schemas[subTypeId] = composeSchema(schema, baseModel.Id, refPrefix)
+			}
+		}
+	}
+	if len(schemas) == 0 {
+		return nil
+	}
+	return schemas
+}
+
+// scannedModels returns the models of the exported struct types ParseTypeDefinitions found outside the standard
+// library, which IncludeAllModels defines along with the models of the operations
+func (parser *Parser) scannedModels() []*Model {
+	packagePaths := make([]string, 0)
+	for packagePath, pkgRealPath := range parser.PackagePathCache {
+		if _, ok := parser.TypeDefinitions[pkgRealPath]; ok && pkgRealPath != "" && !IsStandardPackage(packagePath) {
+			packagePaths = append(packagePaths, packagePath)
+		}
+	}
+	sort.Strings(packagePaths)
+
+	models := make([]*Model, 0)
+	knownModelNames := make(map[string]bool)
+	scannedPackages := make(map[string]bool)
+	for _, packagePath := range packagePaths {
+		pkgRealPath := parser.PackagePathCache[packagePath]
+		if scannedPackages[pkgRealPath] {
+			continue
+		}
+		scannedPackages[pkgRealPath] = true
+		typeNames := make([]string, 0)
+		for typeName, typeSpec := range parser.TypeDefinitions[pkgRealPath] {
+			if _, ok := typeSpec.Type.(*ast.StructType); ok && typeSpec.TypeParams == nil && ast.IsExported(typeName) {
+				typeNames = append(typeNames, typeName)
+			}
+		}
+		sort.Strings(typeNames)
+		for _, typeName := range typeNames {
+			if knownModelNames[parser.qualifyTypeName(typeName, packagePath)] {
+				continue
+			}
+			model := NewModel(parser)
+			err, innerModels := model.ParseModel(typeName, packagePath, knownModelNames)
+			if err != nil {
+				parser.warnf("Can not parse model of type %s of package %s: %v\n", typeName, packagePath, err)
+				continue
+			}
+			models = append(append(models, model), innerModels...)
+		}
+	}
+	return models
+}
+
+// referencedSchemas returns the schemas referenced from the roots, the schemas of the operations, directly or through
+// the schemas they reference, e.g. not the model of a response only given in XML, which Swagger 2.0 has no schema for.
+// The subtypes of a model with a discriminator are kept along with it. All the schemas are kept when IncludeAllModels is set
+func (parser *Parser) referencedSchemas(schemas map[string]*Schema, refPrefix string, roots []*Schema) map[string]*Schema {
+	if parser.IncludeAllModels || len(schemas) == 0 {
+		return schemas
+	}
+	subTypes := make(map[string][]string)
+	for _, api := range parser.TopLevelApis {
+		for id, model := range api.Models {
+			subTypes[id] = model.SubTypes
+		}
+	}
+
+	referenced := make(map[string]*Schema)
+	pending := make([]string, 0)
+	for _, root := range roots {
+		pending = append(pending, root.refs(refPrefix)...)
+	}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		schema, ok := schemas[id]
+		if _, seen := referenced[id]; seen || !ok {
+			continue
+		}
+		referenced[id] = schema
+		pending = append(pending, schema.refs(refPrefix)...)
+		pending = append(pending, subTypes[id]...)
+	}
+	if len(referenced) == 0 {
+		return nil
+	}
+	return referenced
+}
+
+// refs returns the ids of the schemas the schema references, itself or through its properties, items or compositions
+func (schema *Schema) refs(refPrefix string) []string {
+	if schema == nil {
+		return nil
+	}
+	ids := make([]string, 0)
+	if strings.HasPrefix(schema.Ref, refPrefix) {
+		ids = append(ids, schema.Ref[len(refPrefix):])
+	}
+	for _, property := range schema.Properties {
+		ids = append(ids, property.refs(refPrefix)...)
+	}
+	for _, schemas := range [][]*Schema{schema.AllOf, schema.OneOf, schema.AnyOf, {schema.Items, schema.AdditionalProperties}} {
+		for _, inner := range schemas {
+			ids = append(ids, inner.refs(refPrefix)...)
+		}
+	}
+	return ids
+}
+
+// operationSchemas returns the schemas of the params and responses of the spec, which reference its definitions
+func (spec *Swagger20Spec) operationSchemas() []*Schema {
+	schemas := make([]*Schema, 0)
+	addParameter := func(param *Swagger20Parameter) {
+		schemas = append(schemas, param.Schema, param.Items)
+	}
+	addResponse := func(response *Swagger20Response) {
+		schemas = append(schemas, response.Schema)
+		for _, header := range response.Headers {
+			schemas = append(schemas, header)
+		}
+	}
+	for _, operations := range spec.Paths {
+		for _, operation := range operations {
+			for _, param := range operation.Parameters {
+				addParameter(param)
+			}
+			for _, response := range operation.Responses {
+				addResponse(response)
+			}
+		}
+	}
+	for _, param := range spec.Parameters {
+		addParameter(param)
+	}
+	for _, response := range spec.Responses {
+		addResponse(response)
+	}
+	return schemas
+}
+
+// composeSchema returns the schema composed of the model, unless it already is, e.g. by @AllOf
+func composeSchema(schema *Schema, modelId string, refPrefix string) *Schema {
+	ref := refPrefix + modelId
+	for _, composed := range schema.AllOf {
+		if composed.Ref == ref {
+			return schema
+		}
+	}
+	if len(schema.AllOf) > 0 && schema.Type == "" {
+		schema.AllOf = append([]*Schema{{Ref: ref}}, schema.AllOf...)
+		return schema
+	}
+	composed := &Schema{AllOf: []*Schema{{Ref: ref}, schema}, Tags: schema.Tags, XML: schema.XML}
+	schema.Tags, schema.XML = nil, nil
+	return composed
+}
+
+func (parser *Parser) modelSchema(model *Model, refPrefix string) *Schema {
+	if model.Schema != nil {
+		schema := &Schema{}
+		if err := json.Unmarshal(model.Schema, schema); err != nil {
+			parser.warnf("Can not convert the @Schema of model %s: %v\n", model.Id, err)
+		}
+		return schema
+	}
+
+	schema := &Schema{
+		Type:       "object",
+		Required:   model.Required,
+		Properties: make(map[string]*Schema),
+		XML:        model.XML,
+		Tags:       model.Tags,
+	}
+	if model.Discriminator != "" {
+		schema.Discriminator = model.Discriminator
+	}
+	for name, property := range model.Properties {
+		schema.Properties[name] = property.schema(refPrefix)
+	}
+	if len(model.AllOf) > 0 {
+		// the properties of the model are added to the ones of the models it is composed of
+		composed := &Schema{AllOf: refSchemas(model.AllOf, refPrefix), Tags: schema.Tags, XML: schema.XML}
+		schema.Tags, schema.XML = nil, nil
+		composed.AllOf = append(composed.AllOf, schema)
+		return composed
+	}
+	schema.OneOf = refSchemas(model.OneOf, refPrefix)
+	schema.AnyOf = refSchemas(model.AnyOf, refPrefix)
+	return schema
+}
+
+func (property *ModelProperty) schema(refPrefix string) *Schema {
+	var schema *Schema
+	switch {
+	case property.Type == "array":
+		schema = &Schema{Type: "array", Items: property.Items.schema(refPrefix)}
+	case property.AdditionalProperties != nil:
+		schema = &Schema{Type: "object", AdditionalProperties: property.AdditionalProperties.schema(refPrefix)}
+	default:
+		schema = typeSchema(property.Type, refPrefix)
+	}
+	if property.Format != "" {
+		schema.Format = property.Format
+	}
+	schema.Description = property.Description
+	schema.Enum = enumValues(property.Enum, property.Type)
+	schema.EnumVarNames = property.EnumVarNames
+	schema.Example = property.Example
+	schema.Nullable = property.Nullable
+	schema.XML = property.XML
+	return schema
+}
+
+func (items *ModelPropertyItems) schema(refPrefix string) *Schema {
+	var schema *Schema
+	switch {
+	case items.Ref != "":
+		schema = &Schema{Ref: refPrefix + items.Ref}
+	case items.Type == "array" && items.Items != nil:
+		schema = &Schema{Type: "array", Items: items.Items.schema(refPrefix)}
+	case items.AdditionalProperties != nil:
+		schema = &Schema{Type: "object", AdditionalProperties: items.AdditionalProperties.schema(refPrefix)}
+	default:
+		schema = typeSchema(items.Type, refPrefix)
+	}
+	if items.Format != "" {
+		schema.Format = items.Format
+	}
+	schema.XML = items.XML
+	return schema
+}
+
+func (param Parameter) schema(refPrefix string) *Schema {
+	var schema *Schema
+	switch {
+	case len(param.AllOf) > 0 || len(param.OneOf) > 0 || len(param.AnyOf) > 0:
+		schema = param.Composition.schema(refPrefix)
+	case param.Type == "array" && param.Items != nil:
+		schema = &Schema{Type: "array", Items: param.Items.schema(refPrefix)}
+	default:
+		schema = typeSchema(param.Type, refPrefix)
+	}
+	if param.Format != "" {
+		schema.Format = param.Format
+	}
+	schema.Enum = enumValues(param.Enum, param.Type)
+	schema.Default = param.Default
+	schema.Example = param.Example
+	schema.Minimum = param.Minimum
+	schema.Maximum = param.Maximum
+	schema.MinLength = param.MinLength
+	schema.MaxLength = param.MaxLength
+	schema.Pattern = param.Pattern
+	return schema
+}
+
+func (items *OperationItems) schema(refPrefix string) *Schema {
+	if items.Ref != "" {
+		return &Schema{Ref: refPrefix + items.Ref}
+	}
+	return typeSchema(items.Type, refPrefix)
+}
+
+func (composition Composition) schema(refPrefix string) *Schema {
+	return &Schema{
+		AllOf: refSchemas(composition.AllOf, refPrefix),
+		OneOf: refSchemas(composition.OneOf, refPrefix),
+		AnyOf: refSchemas(composition.AnyOf, refPrefix),
+	}
+}
+
+// responseSchema is the schema of the model of a response, or nil if it has none
+func (operation *Operation) responseSchema(response ResponseMessage, refPrefix string) *Schema {
+	if len(response.AllOf) > 0 || len(response.OneOf) > 0 || len(response.AnyOf) > 0 {
+		return response.Composition.schema(refPrefix)
+	}
+	if response.ResponseModel == "" {
+		return nil
+	}
+	schema := typeSchema(response.ResponseModel, refPrefix)
+	if response.isArray || response.Code == 200 && operation.Type == "array" {
+		return &Schema{Type: "array", Items: schema}
+	}
+	return schema
+}
+
+// description is the message of the response, or the status text of its code if it has none
+func (response ResponseMessage) description() string {
+	if response.Message != "" {
+		return response.Message
+	}
+	return http.StatusText(response.Code)
+}
+
+// specSecurity lists each of the authorizations of the operation as a security requirement of its own
+func (operation *Operation) specSecurity() []map[string][]string {
+	names := make([]string, 0, len(operation.Authorizations))
+	for name := range operation.Authorizations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var security []map[string][]string
+	for _, name := range names {
+		scopes := make([]string, 0)
+		for _, scope := range operation.Authorizations[name] {
+			scopes = append(scopes, scope.Scope)
+		}
+		security = append(security, map[string][]string{name: scopes})
+	}
+	return security
+}
+
+func refSchemas(ids []string, refPrefix string) []*Schema {
+	var schemas []*Schema
+	for _, id := range ids {
+		schemas = append(schemas, &Schema{Ref: refPrefix + id})
+	}
+	return schemas
+}
+
+// typeSchema is the schema of a Go basic type, e.g. an integer of format int64 for int64, or a reference
+// to the model of any other type
+func typeSchema(typeName string, refPrefix string) *Schema {
+	switch typeName {
+	case "bool", "boolean":
+		return &Schema{Type: "boolean"}
+	case "int", "int8", "int16", "uint", "uint8", "uint16", "byte", "integer":
+		return &Schema{Type: "integer"}
+	case "int32", "uint32", "rune":
+		return &Schema{Type: "integer", Format: "int32"}
+	case "int64", "uint64":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "float32":
+		return &Schema{Type: "number", Format: "float"}
+	case "float64":
+		return &Schema{Type: "number", Format: "double"}
+	case "number", "string", "object", "array", "file":
+		return &Schema{Type: typeName}
+	case "":
+		return &Schema{}
+	}
+	if IsBasicType(typeName) {
+		// interfaces
+		return &Schema{Type: "object"}
+	}
+	return &Schema{Ref: refPrefix + typeName}
+}
+
+// enumValues converts the enum values of a type from their literals, e.g. 1 rather than "1" of an int
+func enumValues(literals []string, typeName string) []interface{} {
+	var values []interface{}
+	for _, literal := range literals {
+		value, err := CoerceLiteral(literal, typeName)
+		if err != nil {
+			value = literal
+		}
+		values = append(values, value)
+	}
+	return values
+}